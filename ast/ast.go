@@ -10,6 +10,10 @@ package ast
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gosh-lang.org/gosh/tokens"
 )
 
 // Node is a common interface for all AST nodes.
@@ -20,11 +24,44 @@ type Node interface {
 
 // Program is a root of AST tree.
 type Program struct {
+	File       string      // source file name (slash-separated); empty for programs without files
+	Package    *Identifier // package name from the package clause; or nil
 	Statements []Statement
+	Globals    []string     // names declared by all files of multi-file package, so functions can use them; or nil
+	Lines      tokens.Lines // line offsets of the source for positions in messages; or nil
+
+	// Slots is the number of top-level entities declared by the program; they are bound by resolver
+	// to slots of the program's block scope.
+	Slots int
+
+	resolved atomic.Value // *resolved
+}
+
+// resolved stores the value returned by Resolved.
+type resolved struct {
+	once  sync.Once
+	value interface{}
+}
+
+// Resolved returns the value returned by resolve when Resolved is called for the program for the first time.
+// It is used to resolve identifiers of the program once, even if it is run concurrently.
+func (p *Program) Resolved(resolve func() interface{}) interface{} {
+	r, _ := p.resolved.Load().(*resolved)
+	if r == nil {
+		p.resolved.CompareAndSwap(nil, new(resolved))
+		r = p.resolved.Load().(*resolved)
+	}
+	r.once.Do(func() { r.value = resolve() })
+	return r.value
 }
 
 func (p *Program) String() string {
 	var res strings.Builder
+	if p.Package != nil {
+		res.WriteString("package ")
+		res.WriteString(p.Package.String())
+		res.WriteString(";\n")
+	}
 	for _, s := range p.Statements {
 		res.WriteString(s.String())
 		res.WriteString(";\n")
@@ -8,6 +8,8 @@
 package ast
 
 import (
+	"go/constant"
+	"strconv"
 	"strings"
 
 	"gosh-lang.org/gosh/tokens"
@@ -23,6 +25,20 @@ type Expression interface {
 type Identifier struct {
 	Token tokens.Token // tokens.IDENT
 	Value string
+
+	// Entities declared in blocks are bound by resolver to slots of block scopes:
+	// the entity is in slot Slot of the scope Depth levels up from the current one.
+	// Top-level entities declared by the program are bound to slots of the program's block scope
+	// the same way, but Global is set instead of Local.
+	// Other identifiers (predeclared entities and top-level entities of other files) are looked up by name,
+	// starting from the program's scope Depth levels up from the current one.
+	Local  bool
+	Global bool
+	Depth  int
+	Slot   int
+
+	// Predeclared is set by resolver for identifiers of predeclared entities that are not shadowed.
+	Predeclared bool
 }
 
 func (i *Identifier) String() string {
@@ -34,8 +50,9 @@ func (i *Identifier) expression() {}
 
 // IntegerLiteral represents an integer literal expression.
 type IntegerLiteral struct {
-	Token tokens.Token // tokens.INT
-	Value int
+	Token tokens.Token   // tokens.INT
+	Value int            // 0 if literal overflows int; exact value is in Token.Literal
+	Exact constant.Value // exact value set by parser, so it is not parsed again on each evaluation
 }
 
 func (il *IntegerLiteral) String() string {
@@ -47,8 +64,9 @@ func (il *IntegerLiteral) expression() {}
 
 // FloatLiteral represents an float literal expression.
 type FloatLiteral struct {
-	Token tokens.Token //tokens.FLOAT
-	Value float64
+	Token tokens.Token   //tokens.FLOAT
+	Value float64        // ±Inf if literal overflows float64; exact value is in Token.Literal
+	Exact constant.Value // exact value set by parser
 }
 
 func (fl *FloatLiteral) String() string {
@@ -57,6 +75,20 @@ func (fl *FloatLiteral) String() string {
 func (fl *FloatLiteral) node()       {}
 func (fl *FloatLiteral) expression() {}
 
+// ImaginaryLiteral represents an imaginary literal expression.
+type ImaginaryLiteral struct {
+	Token tokens.Token // tokens.Imaginary
+	Value complex128
+	Exact constant.Value // exact value set by parser
+}
+
+func (il *ImaginaryLiteral) String() string {
+	return il.Token.Literal
+}
+
+func (il *ImaginaryLiteral) node()       {}
+func (il *ImaginaryLiteral) expression() {}
+
 // StringLiteral represents a string literal expression.
 type StringLiteral struct {
 	Token tokens.Token // tokens.INT
@@ -70,6 +102,20 @@ func (sl *StringLiteral) String() string {
 func (sl *StringLiteral) node()       {}
 func (sl *StringLiteral) expression() {}
 
+// RuneLiteral represents a rune literal expression.
+type RuneLiteral struct {
+	Token tokens.Token // tokens.Rune
+	Value rune
+	Exact constant.Value // exact value set by parser
+}
+
+func (rl *RuneLiteral) String() string {
+	return rl.Token.Literal
+}
+
+func (rl *RuneLiteral) node()       {}
+func (rl *RuneLiteral) expression() {}
+
 // BooleanLiteral represents a boolean literal expression.
 type BooleanLiteral struct {
 	Token tokens.Token // tokens.TRUE or tokens.FALSE
@@ -83,6 +129,21 @@ func (bl *BooleanLiteral) String() string {
 func (bl *BooleanLiteral) node()       {}
 func (bl *BooleanLiteral) expression() {}
 
+// Constant represents an expression replaced by its constant value (e.g. a folded constant expression).
+// It is created by optimizer, not by parser.
+type Constant struct {
+	X     Expression     // original expression
+	Value constant.Value // exact value
+	Rune  bool           // true for rune constants; their Value has constant.Int kind
+}
+
+func (c *Constant) String() string {
+	return c.X.String()
+}
+
+func (c *Constant) node()       {}
+func (c *Constant) expression() {}
+
 // PrefixExpression represents prefix expression (e.g. `!x`).
 type PrefixExpression struct {
 	Token tokens.Token // tokens.NOT, tokens.SUB
@@ -127,23 +188,80 @@ func (ie *InfixExpression) expression() {}
 type FunctionLiteral struct {
 	Token      tokens.Token
 	Parameters []*Identifier
+	Types      []Expression // parameter types, like `a, b int`; nil for untyped parameters
+	Results    []*Result    // named results
 	Body       *BlockStatement
 }
 
 func (fl *FunctionLiteral) String() string {
-	params := make([]string, len(fl.Parameters))
-	for i, p := range fl.Parameters {
-		params[i] = p.String()
-	}
-
 	var res strings.Builder
 	res.WriteString("func(")
-	res.WriteString(strings.Join(params, ", "))
+	res.WriteString(ParametersString(fl.Parameters, fl.Types))
 	res.WriteString(") ")
+	if len(fl.Results) > 0 {
+		res.WriteString(ResultsString(fl.Results))
+		res.WriteString(" ")
+	}
 	res.WriteString(fl.Body.String())
 	return res.String()
 }
 
+// ParametersString returns a string representation of parameters list with optional types.
+// Consecutive parameters with the same type are grouped like `a, b int`.
+func ParametersString(params []*Identifier, types []Expression) string {
+	var res strings.Builder
+	for i, p := range params {
+		if i > 0 {
+			res.WriteString(", ")
+		}
+		res.WriteString(p.String())
+		if types != nil && (i == len(params)-1 || types[i] != types[i+1]) {
+			res.WriteString(" ")
+			res.WriteString(types[i].String())
+		}
+	}
+	return res.String()
+}
+
+// Result represents a named function result with optional type (e.g. `err error`).
+// Like in Go, unnamed result (e.g. `int`) gets a name that can't be used in the source code.
+type Result struct {
+	Name *Identifier
+	Type Expression // nil if not given
+}
+
+// UnnamedResult returns a name of unnamed result with given index.
+func UnnamedResult(n int) string {
+	return "~r" + strconv.Itoa(n)
+}
+
+// Unnamed returns true for unnamed result.
+func (r *Result) Unnamed() bool {
+	return strings.HasPrefix(r.Name.Value, "~")
+}
+
+func (r *Result) String() string {
+	if r.Unnamed() {
+		return r.Type.String()
+	}
+	if r.Type == nil {
+		return r.Name.String()
+	}
+	return r.Name.String() + " " + r.Type.String()
+}
+
+// ResultsString returns a string representation of named results list.
+func ResultsString(results []*Result) string {
+	if len(results) == 1 && results[0].Unnamed() {
+		return results[0].String()
+	}
+	s := make([]string, len(results))
+	for i, r := range results {
+		s[i] = r.String()
+	}
+	return "(" + strings.Join(s, ", ") + ")"
+}
+
 func (fl *FunctionLiteral) node()       {}
 func (fl *FunctionLiteral) expression() {}
 
@@ -171,13 +289,75 @@ func (ce *CallExpression) String() string {
 func (ce *CallExpression) node()       {}
 func (ce *CallExpression) expression() {}
 
+// SelectorExpression represents a selector expression (e.g. `strings.Repeat`).
+type SelectorExpression struct {
+	Token tokens.Token // tokens.Period
+	X     Expression
+	Sel   *Identifier
+}
+
+func (se *SelectorExpression) String() string {
+	return se.X.String() + "." + se.Sel.String()
+}
+
+func (se *SelectorExpression) node()       {}
+func (se *SelectorExpression) expression() {}
+
+// IndexExpression represents an index expression (e.g. `s[i]`).
+type IndexExpression struct {
+	Token tokens.Token // tokens.LBRACK
+	Left  Expression
+	Index Expression
+}
+
+func (ie *IndexExpression) String() string {
+	return ie.Left.String() + "[" + ie.Index.String() + "]"
+}
+
+func (ie *IndexExpression) node()       {}
+func (ie *IndexExpression) expression() {}
+
+// SliceExpression represents a slice expression (e.g. `s[i:j]`).
+type SliceExpression struct {
+	Token tokens.Token // tokens.LBRACK
+	Left  Expression
+	Low   Expression // or nil
+	High  Expression // or nil
+}
+
+func (se *SliceExpression) String() string {
+	var res strings.Builder
+	res.WriteString(se.Left.String())
+	res.WriteString("[")
+	if se.Low != nil {
+		res.WriteString(se.Low.String())
+	}
+	res.WriteString(":")
+	if se.High != nil {
+		res.WriteString(se.High.String())
+	}
+	res.WriteString("]")
+	return res.String()
+}
+
+func (se *SliceExpression) node()       {}
+func (se *SliceExpression) expression() {}
+
 // check interfaces
 var (
 	_ Expression = (*Identifier)(nil)
 	_ Expression = (*IntegerLiteral)(nil)
+	_ Expression = (*FloatLiteral)(nil)
+	_ Expression = (*ImaginaryLiteral)(nil)
+	_ Expression = (*StringLiteral)(nil)
+	_ Expression = (*RuneLiteral)(nil)
+	_ Expression = (*IndexExpression)(nil)
+	_ Expression = (*SliceExpression)(nil)
 	_ Expression = (*BooleanLiteral)(nil)
+	_ Expression = (*Constant)(nil)
 	_ Expression = (*PrefixExpression)(nil)
 	_ Expression = (*InfixExpression)(nil)
 	_ Expression = (*FunctionLiteral)(nil)
 	_ Expression = (*CallExpression)(nil)
+	_ Expression = (*SelectorExpression)(nil)
 )
@@ -8,6 +8,7 @@
 package ast
 
 import (
+	"path"
 	"strings"
 
 	"gosh-lang.org/gosh/tokens"
@@ -19,15 +20,62 @@ type Statement interface {
 	statement()
 }
 
+// ImportStatement represents an import declaration with one or more (grouped) import specs.
+type ImportStatement struct {
+	Token tokens.Token // tokens.Import
+	Specs []*ImportSpec
+}
+
+func (is *ImportStatement) String() string {
+	if len(is.Specs) == 1 {
+		return "import " + is.Specs[0].String()
+	}
+
+	var res strings.Builder
+	res.WriteString("import (\n")
+	for _, s := range is.Specs {
+		res.WriteString(s.String() + "\n")
+	}
+	res.WriteString(")")
+	return res.String()
+}
+
+func (is *ImportStatement) node()      {}
+func (is *ImportStatement) statement() {}
+
+// ImportSpec represents a single import (e.g. `u "./lib/util.gosh"`).
+type ImportSpec struct {
+	Name *Identifier // local name; or nil
+	Path *StringLiteral
+}
+
+func (is *ImportSpec) String() string {
+	if is.Name != nil {
+		return is.Name.String() + " " + is.Path.String()
+	}
+	return is.Path.String()
+}
+
+// LocalName returns the name of imported module in the importing program:
+// the explicit name, or the last element of the import path without ".gosh" extension.
+func (is *ImportSpec) LocalName() string {
+	if is.Name != nil {
+		return is.Name.Value
+	}
+	return strings.TrimSuffix(path.Base(is.Path.Value), ".gosh")
+}
+
+func (is *ImportSpec) node() {}
+
 // IncrementDecrementStatement represents increment or decrement statement (e.g. `x++`, `x--`).
 type IncrementDecrementStatement struct {
 	Token tokens.Token // tokens.Increment or tokens.Decrement
-	Name  *Identifier  // TODO it can be a more complex expression
+	X     Expression   // addressable expression
 }
 
 func (ids *IncrementDecrementStatement) String() string {
 	var res strings.Builder
-	res.WriteString(ids.Name.String())
+	res.WriteString(ids.X.String())
 	res.WriteString(ids.Token.Literal)
 	return res.String()
 }
@@ -37,15 +85,24 @@ func (ids *IncrementDecrementStatement) statement() {}
 
 // VarStatement represents a var statement.
 type VarStatement struct {
-	Token tokens.Token // tokens.Var
+	Token tokens.Token // tokens.Var, or tokens.Func for function declarations
 	Name  *Identifier
-	Value Expression
+	Type  Expression // type; or nil
+	Value Expression // initial value; or nil
 }
 
 func (vs *VarStatement) String() string {
+	if lit, ok := vs.Value.(*FunctionLiteral); ok && vs.Token.Type == tokens.Func {
+		return "func " + vs.Name.String() + strings.TrimPrefix(lit.String(), "func")
+	}
+
 	var res strings.Builder
 	res.WriteString("var ")
 	res.WriteString(vs.Name.String())
+	if vs.Type != nil {
+		res.WriteString(" ")
+		res.WriteString(vs.Type.String())
+	}
 	if vs.Value != nil {
 		res.WriteString(" = ")
 		res.WriteString(vs.Value.String())
@@ -56,16 +113,35 @@ func (vs *VarStatement) String() string {
 func (vs *VarStatement) node()      {}
 func (vs *VarStatement) statement() {}
 
+// TypeStatement represents a type declaration statement.
+type TypeStatement struct {
+	Token tokens.Token // tokens.TypeKeyword
+	Name  *Identifier
+	Type  Expression
+}
+
+func (ts *TypeStatement) String() string {
+	return "type " + ts.Name.String() + " " + ts.Type.String()
+}
+
+func (ts *TypeStatement) node()      {}
+func (ts *TypeStatement) statement() {}
+
 // AssignStatement represents an assign statement.
 type AssignStatement struct {
-	Token tokens.Token // tokens.Assignment or tokens.XXXAssignment
-	Name  *Identifier  // TODO it can be a more complex expression
+	Token tokens.Token  // tokens.Assignment or tokens.XXXAssignment
+	Name  Expression    // assigned variable: identifier, index or selector expression
+	Rest  []*Identifier // following variables of multi-value assignment (e.g. `v, ok := <-ch`), or nil
 	Value Expression
 }
 
 func (as *AssignStatement) String() string {
 	var res strings.Builder
 	res.WriteString(as.Name.String())
+	for _, id := range as.Rest {
+		res.WriteString(", ")
+		res.WriteString(id.String())
+	}
 	res.WriteString(" ")
 	res.WriteString(as.Token.Literal)
 	res.WriteString(" ")
@@ -95,6 +171,91 @@ func (rs *ReturnStatement) String() string {
 func (rs *ReturnStatement) node()      {}
 func (rs *ReturnStatement) statement() {}
 
+// DeferStatement represents a defer statement.
+type DeferStatement struct {
+	Token tokens.Token // tokens.Defer
+	Call  *CallExpression
+}
+
+func (ds *DeferStatement) String() string {
+	return "defer " + ds.Call.String()
+}
+
+func (ds *DeferStatement) node()      {}
+func (ds *DeferStatement) statement() {}
+
+// GoStatement represents a go statement.
+type GoStatement struct {
+	Token tokens.Token // tokens.Go
+	Call  *CallExpression
+}
+
+func (gs *GoStatement) String() string {
+	return "go " + gs.Call.String()
+}
+
+func (gs *GoStatement) node()      {}
+func (gs *GoStatement) statement() {}
+
+// SendStatement represents a send statement (e.g. `ch <- v`).
+type SendStatement struct {
+	Token   tokens.Token // tokens.Arrow
+	Channel Expression
+	Value   Expression
+}
+
+func (ss *SendStatement) String() string {
+	return ss.Channel.String() + " <- " + ss.Value.String()
+}
+
+func (ss *SendStatement) node()      {}
+func (ss *SendStatement) statement() {}
+
+// SelectStatement represents a select statement.
+type SelectStatement struct {
+	Token tokens.Token // tokens.Select
+	Cases []*CommClause
+}
+
+func (ss *SelectStatement) String() string {
+	var res strings.Builder
+	res.WriteString("select {\n")
+	for _, c := range ss.Cases {
+		res.WriteString(c.String())
+	}
+	res.WriteString("}")
+	return res.String()
+}
+
+func (ss *SelectStatement) node()      {}
+func (ss *SelectStatement) statement() {}
+
+// CommClause represents a case of select statement.
+type CommClause struct {
+	Token tokens.Token // tokens.Case or tokens.Default
+	Comm  Statement    // send statement, receive expression statement or assignment; nil for default case
+	Body  []Statement
+	Slots int // number of variables declared in the clause; set by resolver
+}
+
+func (cc *CommClause) String() string {
+	var res strings.Builder
+	if cc.Comm == nil {
+		res.WriteString("default:\n")
+	} else {
+		res.WriteString("case ")
+		res.WriteString(cc.Comm.String())
+		res.WriteString(":\n")
+	}
+	for _, s := range cc.Body {
+		res.WriteString(s.String() + ";\n")
+	}
+	return res.String()
+}
+
+func (cc *CommClause) node()      {}
+func (cc *CommClause) statement() {}
+
 // ContinueStatement represents a continue statement.
 type ContinueStatement struct {
 	Token tokens.Token // tokens.Continue
@@ -135,11 +296,18 @@ type ForStatement struct {
 	Cond  Expression       // condition; or nil
 	Post  Statement        // post iteration statement; or nil
 	Body  *BlockStatement
+	Slots int // number of variables declared by initialization statement; set by resolver
 }
 
 func (fs *ForStatement) String() string {
 	var res strings.Builder
 	res.WriteString("for ")
+	if fs.Init == nil && fs.Cond == nil && fs.Post == nil {
+		if fs.Body != nil {
+			res.WriteString(fs.Body.String())
+		}
+		return res.String()
+	}
 	if fs.Init != nil {
 		res.WriteString(fs.Init.String())
 	}
@@ -177,10 +345,47 @@ func (es *ExpressionStatement) String() string {
 func (es *ExpressionStatement) node()      {}
 func (es *ExpressionStatement) statement() {}
 
+// RangeStatement represents a for statement with a range clause.
+type RangeStatement struct {
+	Token  tokens.Token // tokens.For
+	Key    *Identifier  // or nil
+	Value  *Identifier  // or nil
+	Define bool         // true for :=, false for =
+	X      Expression   // range expression
+	Body   *BlockStatement
+	Slots  int // number of iteration variables declared with :=; set by resolver
+}
+
+func (rs *RangeStatement) String() string {
+	var res strings.Builder
+	res.WriteString("for ")
+	if rs.Key != nil {
+		res.WriteString(rs.Key.String())
+		if rs.Value != nil {
+			res.WriteString(", ")
+			res.WriteString(rs.Value.String())
+		}
+		if rs.Define {
+			res.WriteString(" := ")
+		} else {
+			res.WriteString(" = ")
+		}
+	}
+	res.WriteString("range ")
+	res.WriteString(rs.X.String())
+	res.WriteString(" ")
+	res.WriteString(rs.Body.String())
+	return res.String()
+}
+
+func (rs *RangeStatement) node()      {}
+func (rs *RangeStatement) statement() {}
+
 // BlockStatement represents a block statement.
 type BlockStatement struct {
 	Token      tokens.Token // tokens.LBRACE
 	Statements []Statement
+	Slots      int // number of entities declared in the block (including function parameters and results); set by resolver
 }
 
 func (bs *BlockStatement) String() string {
@@ -198,11 +403,16 @@ func (bs *BlockStatement) statement() {}
 
 // check interfaces
 var (
+	_ Statement = (*ImportStatement)(nil)
+	_ Node      = (*ImportSpec)(nil)
 	_ Statement = (*IncrementDecrementStatement)(nil)
 	_ Statement = (*VarStatement)(nil)
+	_ Statement = (*TypeStatement)(nil)
 	_ Statement = (*AssignStatement)(nil)
 	_ Statement = (*ReturnStatement)(nil)
+	_ Statement = (*DeferStatement)(nil)
 	_ Statement = (*ForStatement)(nil)
+	_ Statement = (*RangeStatement)(nil)
 	_ Statement = (*ExpressionStatement)(nil)
 	_ Statement = (*BlockStatement)(nil)
 )
@@ -0,0 +1,148 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ast
+
+import (
+	"strings"
+
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Type expressions are expressions too: they are used in declarations and conversions.
+// Named types are represented by Identifier.
+
+// SliceType represents a slice type (e.g. `[]int`).
+type SliceType struct {
+	Token tokens.Token // tokens.LBRACK
+	Elem  Expression
+}
+
+func (st *SliceType) String() string {
+	return "[]" + st.Elem.String()
+}
+
+func (st *SliceType) node()       {}
+func (st *SliceType) expression() {}
+
+// MapType represents a map type (e.g. `map[string]int`).
+type MapType struct {
+	Token tokens.Token // tokens.Map
+	Key   Expression
+	Value Expression
+}
+
+func (mt *MapType) String() string {
+	return "map[" + mt.Key.String() + "]" + mt.Value.String()
+}
+
+func (mt *MapType) node()       {}
+func (mt *MapType) expression() {}
+
+// PointerType represents a pointer type (e.g. `*int`).
+type PointerType struct {
+	Token tokens.Token // tokens.Product
+	Elem  Expression
+}
+
+func (pt *PointerType) String() string {
+	return "*" + pt.Elem.String()
+}
+
+func (pt *PointerType) node()       {}
+func (pt *PointerType) expression() {}
+
+// ChanDir is a direction of channel type.
+type ChanDir int
+
+// Channel type directions.
+const (
+	SendRecv ChanDir = iota // chan T
+	SendOnly                // chan<- T
+	RecvOnly                // <-chan T
+)
+
+// ChanType represents a channel type (e.g. `chan int`, `chan<- int` or `<-chan int`).
+type ChanType struct {
+	Token tokens.Token // tokens.Chan, or tokens.Arrow for receive-only channels
+	Dir   ChanDir
+	Elem  Expression
+}
+
+func (ct *ChanType) String() string {
+	switch ct.Dir {
+	case SendOnly:
+		return "chan<- " + ct.Elem.String()
+	case RecvOnly:
+		return "<-chan " + ct.Elem.String()
+	default:
+		return "chan " + ct.Elem.String()
+	}
+}
+
+func (ct *ChanType) node()       {}
+func (ct *ChanType) expression() {}
+
+// FuncType represents a function type (e.g. `func(int, string) bool`).
+type FuncType struct {
+	Token   tokens.Token // tokens.Func
+	Params  []Expression
+	Results []Expression
+}
+
+func (ft *FuncType) String() string {
+	params := make([]string, len(ft.Params))
+	for i, p := range ft.Params {
+		params[i] = p.String()
+	}
+	results := make([]string, len(ft.Results))
+	for i, r := range ft.Results {
+		results[i] = r.String()
+	}
+
+	var res strings.Builder
+	res.WriteString("func(")
+	res.WriteString(strings.Join(params, ", "))
+	res.WriteString(")")
+	switch len(results) {
+	case 0:
+		// nothing
+	case 1:
+		res.WriteString(" ")
+		res.WriteString(results[0])
+	default:
+		res.WriteString(" (")
+		res.WriteString(strings.Join(results, ", "))
+		res.WriteString(")")
+	}
+	return res.String()
+}
+
+func (ft *FuncType) node()       {}
+func (ft *FuncType) expression() {}
+
+// InterfaceType represents an empty interface type `interface{}`.
+type InterfaceType struct {
+	Token tokens.Token // tokens.Interface
+}
+
+func (it *InterfaceType) String() string {
+	return "interface{}"
+}
+
+func (it *InterfaceType) node()       {}
+func (it *InterfaceType) expression() {}
+
+// check interfaces
+var (
+	_ Expression = (*SliceType)(nil)
+	_ Expression = (*MapType)(nil)
+	_ Expression = (*PointerType)(nil)
+	_ Expression = (*ChanType)(nil)
+	_ Expression = (*FuncType)(nil)
+	_ Expression = (*InterfaceType)(nil)
+)
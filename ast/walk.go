@@ -0,0 +1,184 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ast
+
+import (
+	"fmt"
+)
+
+// Inspect traverses an AST in depth-first order: it starts by calling f(node);
+// node must not be nil. If f returns true, Inspect invokes f recursively
+// for each of the non-nil children of node.
+//nolint:gocyclo
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		if n.Package != nil {
+			Inspect(n.Package, f)
+		}
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+
+	// expressions
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *ImaginaryLiteral, *StringLiteral, *RuneLiteral, *BooleanLiteral:
+		// nothing
+	case *Constant:
+		Inspect(n.X, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *IndexExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Index, f)
+	case *SliceExpression:
+		Inspect(n.Left, f)
+		if n.Low != nil {
+			Inspect(n.Low, f)
+		}
+		if n.High != nil {
+			Inspect(n.High, f)
+		}
+	case *FunctionLiteral:
+		for i, p := range n.Parameters {
+			Inspect(p, f)
+			if n.Types != nil && (i == len(n.Parameters)-1 || n.Types[i] != n.Types[i+1]) {
+				Inspect(n.Types[i], f)
+			}
+		}
+		for _, r := range n.Results {
+			Inspect(r.Name, f)
+			if r.Type != nil {
+				Inspect(r.Type, f)
+			}
+		}
+		Inspect(n.Body, f)
+	case *SelectorExpression:
+		Inspect(n.X, f)
+		Inspect(n.Sel, f)
+	case *CallExpression:
+		Inspect(n.Function, f)
+		for _, a := range n.Arguments {
+			Inspect(a, f)
+		}
+
+	// types
+	case *SliceType:
+		Inspect(n.Elem, f)
+	case *MapType:
+		Inspect(n.Key, f)
+		Inspect(n.Value, f)
+	case *PointerType:
+		Inspect(n.Elem, f)
+	case *ChanType:
+		Inspect(n.Elem, f)
+	case *FuncType:
+		for _, p := range n.Params {
+			Inspect(p, f)
+		}
+		for _, r := range n.Results {
+			Inspect(r, f)
+		}
+	case *InterfaceType:
+		// nothing
+
+	// statements
+	case *ImportStatement:
+		for _, s := range n.Specs {
+			Inspect(s, f)
+		}
+	case *ImportSpec:
+		if n.Name != nil {
+			Inspect(n.Name, f)
+		}
+		Inspect(n.Path, f)
+	case *IncrementDecrementStatement:
+		Inspect(n.X, f)
+	case *TypeStatement:
+		Inspect(n.Name, f)
+		Inspect(n.Type, f)
+	case *VarStatement:
+		Inspect(n.Name, f)
+		if n.Type != nil {
+			Inspect(n.Type, f)
+		}
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+	case *AssignStatement:
+		Inspect(n.Name, f)
+		for _, id := range n.Rest {
+			Inspect(id, f)
+		}
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+	case *DeferStatement:
+		Inspect(n.Call, f)
+	case *GoStatement:
+		Inspect(n.Call, f)
+	case *SendStatement:
+		Inspect(n.Channel, f)
+		Inspect(n.Value, f)
+	case *SelectStatement:
+		for _, c := range n.Cases {
+			Inspect(c, f)
+		}
+	case *CommClause:
+		if n.Comm != nil {
+			Inspect(n.Comm, f)
+		}
+		for _, s := range n.Body {
+			Inspect(s, f)
+		}
+	case *ContinueStatement:
+		// nothing
+	case *IfStatement:
+		Inspect(n.Cond, f)
+		Inspect(n.Body, f)
+	case *ForStatement:
+		if n.Init != nil {
+			Inspect(n.Init, f)
+		}
+		if n.Cond != nil {
+			Inspect(n.Cond, f)
+		}
+		if n.Post != nil {
+			Inspect(n.Post, f)
+		}
+		Inspect(n.Body, f)
+	case *RangeStatement:
+		if n.Key != nil {
+			Inspect(n.Key, f)
+		}
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+		Inspect(n.X, f)
+		Inspect(n.Body, f)
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Inspect(n.Expression, f)
+		}
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Inspect: unexpected node type %T", n))
+	}
+}
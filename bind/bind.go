@@ -0,0 +1,244 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package bind
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/constant"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config configures generated bindings.
+type Config struct {
+	// GoVersion is the minimal Go version of generated code, like "go1.23".
+	// Standard library symbols added by later versions (according to $GOROOT/api files) are skipped,
+	// so bindings generated by newer toolchains still build with that version. Empty means the current toolchain.
+	GoVersion string
+}
+
+// Generate returns formatted Go source of package pkgName with bindings for Go package with given import path.
+// Config may be nil.
+//
+// Generic functions and types can't be used without instantiation, so they are skipped.
+// Untyped integer constants that don't fit into int32 get explicit int64 or uint64 type
+// so bindings compile on all platforms; constants that don't fit into uint64 are skipped.
+func Generate(importPath, pkgName string, config *Config) ([]byte, error) {
+	if config == nil {
+		config = new(Config)
+	}
+
+	pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var newer map[string]string
+	if config.GoVersion != "" {
+		if newer, err = newerSymbols(importPath, config.GoVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	// bound package should not shadow imports of generated code
+	alias := pkg.Name()
+	switch alias {
+	case "reflect", "objects":
+		alias = "go" + alias
+	}
+
+	var values, typs bytes.Buffer
+	scope := pkg.Scope()
+	for _, name := range scope.Names() { // sorted
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+
+		if v := newer[name]; v != "" {
+			fmt.Fprintf(&values, "// %s requires %s\n", name, v)
+			continue
+		}
+
+		qualified := alias + "." + name
+		switch obj := obj.(type) {
+		case *types.Func:
+			if obj.Type().(*types.Signature).TypeParams() != nil {
+				fmt.Fprintf(&values, "// %s is generic\n", name)
+				continue
+			}
+			fmt.Fprintf(&values, "%q: reflect.ValueOf(%s),\n", name, qualified)
+
+		case *types.Const:
+			v := constantValue(obj, qualified)
+			if v == "" {
+				fmt.Fprintf(&values, "// %s overflows uint64\n", name)
+				continue
+			}
+			fmt.Fprintf(&values, "%q: reflect.ValueOf(%s),\n", name, v)
+
+		case *types.Var:
+			// addressable value, so the current value is used
+			fmt.Fprintf(&values, "%q: reflect.ValueOf(&%s).Elem(),\n", name, qualified)
+
+		case *types.TypeName:
+			if isGeneric(obj) {
+				fmt.Fprintf(&typs, "// %s is generic\n", name)
+				continue
+			}
+			fmt.Fprintf(&typs, "%q: reflect.TypeOf((*%s)(nil)).Elem(),\n", name, qualified)
+
+		default:
+			return nil, fmt.Errorf("%s: unexpected object %T", name, obj)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gosh bind %s; DO NOT EDIT.\n\n", importPath)
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import (\n\"reflect\"\n\n\"gosh-lang.org/gosh/objects\"\n\n%s %q\n)\n\n", alias, importPath)
+	fmt.Fprintf(&buf, "func init() {\nobjects.RegisterGoPackage(&objects.GoPackage{\n")
+	fmt.Fprintf(&buf, "Path: %q,\nName: %q,\n", pkg.Path(), pkg.Name())
+	fmt.Fprintf(&buf, "Values: map[string]reflect.Value{\n%s},\n", values.Bytes())
+	fmt.Fprintf(&buf, "Types: map[string]reflect.Type{\n%s},\n", typs.Bytes())
+	fmt.Fprintf(&buf, "})\n}\n")
+
+	res, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %s", err)
+	}
+	return res, nil
+}
+
+// constantValue returns Go expression for the constant, or empty string if it can't be bound.
+func constantValue(c *types.Const, qualified string) string {
+	basic, ok := c.Type().(*types.Basic)
+	if !ok || basic.Info()&types.IsUntyped == 0 || c.Val().Kind() != constant.Int {
+		return qualified
+	}
+
+	// default type of untyped integer constant is int; it is 32 bits wide on some platforms
+	if basic.Kind() == types.UntypedRune {
+		return qualified
+	}
+	if n, exact := constant.Int64Val(c.Val()); exact {
+		if n >= math.MinInt32 && n <= math.MaxInt32 {
+			return qualified
+		}
+		return "int64(" + qualified + ")"
+	}
+	if _, exact := constant.Uint64Val(c.Val()); exact {
+		return "uint64(" + qualified + ")"
+	}
+	return ""
+}
+
+// isGeneric returns true if type has type parameters.
+func isGeneric(tn *types.TypeName) bool {
+	if tn.IsAlias() {
+		// alias of instantiated type is not generic, alias with own type parameters is
+		if a, ok := tn.Type().(*types.Alias); ok {
+			return a.TypeParams() != nil
+		}
+		return false
+	}
+	named, ok := tn.Type().(*types.Named)
+	return ok && named.TypeParams() != nil
+}
+
+// newerSymbols returns package-level symbols of standard library package with given import path
+// added after Go version, mapped to versions that added them.
+func newerSymbols(importPath, version string) (map[string]string, error) {
+	minor, ok := goMinor(version)
+	if !ok {
+		return nil, fmt.Errorf("invalid Go version %q", version)
+	}
+
+	files, err := filepath.Glob(filepath.Join(build.Default.GOROOT, "api", "go1.*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no API files in %s", filepath.Join(build.Default.GOROOT, "api"))
+	}
+
+	// the earliest version is reported
+	sort.Slice(files, func(i, j int) bool {
+		mi, _ := goMinor(strings.TrimSuffix(filepath.Base(files[i]), ".txt"))
+		mj, _ := goMinor(strings.TrimSuffix(filepath.Base(files[j]), ".txt"))
+		return mi < mj
+	})
+
+	res := make(map[string]string)
+	for _, file := range files {
+		v := strings.TrimSuffix(filepath.Base(file), ".txt")
+		if m, ok := goMinor(v); !ok || m <= minor {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// lines look like "pkg strings, func Lines(string) iter.Seq[string] #61901"
+		// or "pkg syscall (linux-386), const EPOLLRDHUP = 8192"
+		for _, line := range strings.Split(string(b), "\n") {
+			rest := strings.TrimPrefix(line, "pkg "+importPath)
+			if rest == line {
+				continue
+			}
+			if strings.HasPrefix(rest, " (") {
+				rest = rest[strings.Index(rest, ")")+1:]
+			}
+			if !strings.HasPrefix(rest, ", ") {
+				continue
+			}
+
+			fields := strings.Fields(rest[2:])
+			if len(fields) < 2 {
+				continue
+			}
+			name := strings.FieldsFunc(fields[1], func(r rune) bool { return r == '(' || r == '[' })[0]
+			switch fields[0] {
+			case "func", "const", "var":
+				// declared
+			case "type":
+				// but not a new field or method of existing struct or interface type
+				if len(fields) > 2 && strings.HasSuffix(fields[2], ",") {
+					continue
+				}
+			default:
+				continue
+			}
+			if res[name] == "" {
+				res[name] = v
+			}
+		}
+	}
+	return res, nil
+}
+
+// goMinor returns minor version of Go version like "go1.23" or "1.23".
+func goMinor(version string) (int, bool) {
+	parts := strings.Split(strings.TrimPrefix(version, "go"), ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(parts[1])
+	return n, err == nil
+}
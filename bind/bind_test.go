@@ -0,0 +1,98 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package bind_test
+
+//go:generate go run gosh-lang.org/gosh/cmd/gosh bind -o example_bind_test.go -p bind_test gosh-lang.org/gosh/bind/testdata/example
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/bind"
+	"gosh-lang.org/gosh/bind/testdata/example"
+	"gosh-lang.org/gosh/objects"
+)
+
+const examplePath = "gosh-lang.org/gosh/bind/testdata/example"
+
+func TestGenerate(t *testing.T) {
+	expected, err := ioutil.ReadFile("example_bind_test.go")
+	require.NoError(t, err)
+	actual, err := bind.Generate(examplePath, "bind_test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "run go generate")
+
+	_, err = bind.Generate("gosh-lang.org/gosh/no/such/package", "bind_test", nil)
+	assert.Error(t, err)
+}
+
+func TestGenerateGoVersion(t *testing.T) {
+	b, err := bind.Generate("strings", "bind_test", &bind.Config{GoVersion: "go1.23"})
+	require.NoError(t, err)
+	actual := string(b)
+	assert.Contains(t, actual, `"Cut":`)
+	assert.Contains(t, actual, "// Lines requires go1.24\n")
+	assert.NotContains(t, actual, `"Lines":`)
+	assert.NotContains(t, actual, `"SplitSeq":`)
+
+	_, err = bind.Generate("strings", "bind_test", &bind.Config{GoVersion: "1.x"})
+	assert.Error(t, err)
+}
+
+func TestRegistered(t *testing.T) {
+	p := objects.LookupGoPackage(examplePath)
+	require.NotNil(t, p)
+	assert.Equal(t, "example", p.Name)
+	assert.Nil(t, objects.LookupGoPackage("example"))
+	assert.Equal(t, []string{
+		"Big", "Celsius", "Counter", "Huge", "Incr", "Ints", "Letter", "Name", "Pi", "Shout", "Small", "Stringer", "Typed",
+	}, p.Names())
+	assert.Panics(t, func() { objects.RegisterGoPackage(&objects.GoPackage{Path: examplePath}) })
+
+	for name, expected := range map[string]objects.Object{
+		"Small":  &objects.Integer{Value: 42},
+		"Big":    &objects.Int64{Value: 1 << 40},
+		"Huge":   &objects.Uint64{Value: 1 << 63},
+		"Pi":     &objects.Float{Value: 3.14},
+		"Letter": &objects.Int32{Value: 'g'},
+		"Name":   &objects.String{Value: "gopher"},
+	} {
+		actual, ok := p.Member(name)
+		require.True(t, ok, name)
+		assert.Equal(t, expected, actual, name)
+	}
+	_, ok := p.Member("Max")
+	assert.False(t, ok, "generic functions are skipped")
+
+	typed, ok := p.Member("Typed")
+	require.True(t, ok)
+	assert.Equal(t, &objects.Float{Value: 36.6}, typed)
+
+	celsius, ok := p.Member("Celsius")
+	require.True(t, ok)
+	assert.Equal(t, "float64", celsius.String())
+
+	// variables are read when used
+	incr, ok := p.Member("Incr")
+	require.True(t, ok)
+	f := incr.(*objects.GoFunction).Func
+	assert.Equal(t, &objects.Integer{Value: 1}, f(context.Background()))
+	example.Counter = 41
+	assert.Equal(t, &objects.Integer{Value: 42}, f(context.Background()))
+	counter, ok := p.Member("Counter")
+	require.True(t, ok)
+	assert.Equal(t, &objects.Integer{Value: 42}, counter)
+
+	shout, ok := p.Member("Shout")
+	require.True(t, ok)
+	assert.Equal(t, &objects.String{Value: "GO!"}, shout.(*objects.GoFunction).Func(context.Background(), &objects.String{Value: "go"}))
+}
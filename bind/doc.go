@@ -0,0 +1,20 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package bind generates Go bindings for Go packages.
+//
+// Generated Go source registers all exported functions, types, constants and variables of a package
+// in Gosh module table (see objects.RegisterGoPackage) when it is initialized.
+// It is usually invoked by `gosh bind` command from go:generate comment:
+//
+//	//go:generate gosh bind -o strings_bind.go strings
+//
+// Standard library symbols added after the minimal Go version of the module can be skipped
+// with --go flag, so generated code builds with that version:
+//
+//	//go:generate gosh bind -o strings_bind.go --go go1.23 strings
+package bind // import "gosh-lang.org/gosh/bind"
@@ -0,0 +1,38 @@
+// Code generated by gosh bind gosh-lang.org/gosh/bind/testdata/example; DO NOT EDIT.
+
+package bind_test
+
+import (
+	"reflect"
+
+	"gosh-lang.org/gosh/objects"
+
+	example "gosh-lang.org/gosh/bind/testdata/example"
+)
+
+func init() {
+	objects.RegisterGoPackage(&objects.GoPackage{
+		Path: "gosh-lang.org/gosh/bind/testdata/example",
+		Name: "example",
+		Values: map[string]reflect.Value{
+			"Big":     reflect.ValueOf(int64(example.Big)),
+			"Counter": reflect.ValueOf(&example.Counter).Elem(),
+			"Huge":    reflect.ValueOf(uint64(example.Huge)),
+			"Incr":    reflect.ValueOf(example.Incr),
+			"Letter":  reflect.ValueOf(example.Letter),
+			// Max is generic
+			"Name": reflect.ValueOf(example.Name),
+			// Overflow overflows uint64
+			"Pi":    reflect.ValueOf(example.Pi),
+			"Shout": reflect.ValueOf(example.Shout),
+			"Small": reflect.ValueOf(example.Small),
+			"Typed": reflect.ValueOf(example.Typed),
+		},
+		Types: map[string]reflect.Type{
+			"Celsius": reflect.TypeOf((*example.Celsius)(nil)).Elem(),
+			"Ints":    reflect.TypeOf((*example.Ints)(nil)).Elem(),
+			// Pair is generic
+			"Stringer": reflect.TypeOf((*example.Stringer)(nil)).Elem(),
+		},
+	})
+}
@@ -0,0 +1,58 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package example is used for testing bindings generator.
+package example
+
+import "strings"
+
+// constants
+const (
+	Small            = 42
+	Big              = 1 << 40
+	Huge             = 1 << 63
+	Overflow         = 1 << 64
+	Pi               = 3.14
+	Letter           = 'g'
+	Name             = "gopher"
+	Typed    Celsius = 36.6
+	private          = 1
+)
+
+// Counter is a variable.
+var Counter int
+
+// Celsius is a defined type.
+type Celsius float64
+
+// Pair is a generic type.
+type Pair[T any] struct{ A, B T }
+
+// Ints is an alias of instantiated generic type.
+type Ints = Pair[int]
+
+// Stringer is an interface.
+type Stringer interface{ String() string }
+
+// Incr increments Counter and returns it.
+func Incr() int {
+	Counter++
+	return Counter
+}
+
+// Shout returns s in upper case.
+func Shout(s string) string { return strings.ToUpper(s) + "!" }
+
+// Max is a generic function.
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func private2() {}
@@ -0,0 +1,509 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Command gosh runs Gosh programs and starts Gosh REPL without arguments.
+//
+// Programs can import Gosh modules relative to their files (import "./lib/util.gosh"),
+// from directories listed in GOSHPATH environment variable (import "util" for file <dir>/util.gosh),
+// and Go packages fmt, math, strconv and strings.
+//
+// Go programs (files with .go extension) are run in compatibility mode, like `go run` does:
+// init functions are called, then main function. Only Go features supported by Gosh can be used.
+//
+// If file is a directory, all Gosh files in it (except _test.gosh files) are run as one package
+// with a shared global scope: `gosh run ./cmd/tool`. Functions can use names declared by any file;
+// init functions of each file are called in file name order, then main function of package main.
+//
+// Usage:
+//	gosh [flags] [run] [file]
+//	gosh bind [-o output] [-p package] [--go version] importpath
+//
+// To embed Gosh into your Go program use package gosh-lang.org/gosh.
+package main // import "gosh-lang.org/gosh/cmd/gosh"
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/peterh/liner"
+	"gopkg.in/alecthomas/kingpin.v2"
+
+	"gosh-lang.org/gosh"
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/bind"
+	"gosh-lang.org/gosh/internal/compat"
+	"gosh-lang.org/gosh/internal/pkgdir"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/optimizer"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+	_ "gosh-lang.org/gosh/stdlib"
+	"gosh-lang.org/gosh/tokens"
+)
+
+var (
+	// Version of the interpreter.
+	Version = "0.0.1-dev"
+)
+
+// flags
+var (
+	DebugScannerF *bool
+	DebugASTF     *bool
+	DebugParserF  *bool
+	OptimizeF     *bool
+)
+
+// Exit codes: like the go command, gosh exits with exitStatic for errors in the program source,
+// and like the Go runtime, with exitPanic for unrecovered panics and fatal errors.
+const (
+	exitStatic = 1
+	exitPanic  = 2
+)
+
+// importer imports modules of evaluated programs from the host filesystem.
+var importer interpreter.Importer
+
+// fsName returns the name of file in the host filesystem used by importer: absolute slash-separated path
+// without the leading slash.
+func fsName(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	return strings.TrimPrefix(filepath.ToSlash(filename), "/")
+}
+
+// newImporter returns importer for modules in the host filesystem and GOSHPATH directories.
+func newImporter() interpreter.Importer {
+	var path []string
+	for _, dir := range filepath.SplitList(os.Getenv("GOSHPATH")) {
+		if dir != "" {
+			path = append(path, fsName(dir))
+		}
+	}
+
+	return gosh.New(
+		gosh.WithStdin(os.Stdin),
+		gosh.WithStdout(os.Stdout),
+		gosh.WithStderr(os.Stderr),
+		gosh.WithEnv(os.Environ()),
+		gosh.WithFS(os.DirFS("/")),
+		gosh.WithModulePath(path...),
+	)
+}
+
+var versionRE = regexp.MustCompile(`go(\S+)`)
+
+func extractGoVersion(s string) string {
+	res := versionRE.FindStringSubmatch(s)
+	if len(res) == 2 {
+		return res[1]
+	}
+	return ""
+}
+
+func goVersion() string {
+	cmd := exec.Command("go", "version") //nolint:gosec
+	b, err := cmd.CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// eval evaluates code from the named file in the given scope and returns exit code: 0 if it succeeded.
+// Optimization should be enabled only for whole programs: inlined functions can't be reassigned by later code.
+func eval(filename, code string, scope *objects.Scope, optimize bool) int {
+	program, ok := parse(filename, code)
+	if !ok {
+		return exitStatic
+	}
+	if program == nil {
+		return 0
+	}
+	if filepath.Ext(filename) == ".go" {
+		if err := compat.Program(program); err != nil {
+			log.Printf("Go program error: %s.", err)
+			return exitStatic
+		}
+	}
+	if optimize {
+		program = optimizer.Optimize(program, scope)
+	}
+	if debug(program) {
+		return 0
+	}
+	return run(filename, code, program, scope)
+}
+
+// parse parses code from the named file and reports whether it succeeded.
+// It returns nil program if tokens were printed instead.
+func parse(filename, code string) (*ast.Program, bool) {
+	s, err := scanner.New(code, &scanner.Config{
+		SkipShebang: true,
+	})
+	if err != nil {
+		log.Printf("Scanner error: %s.", err)
+		return nil, false
+	}
+	if *DebugScannerF {
+		log.Print("Tokens:")
+		for {
+			t := s.NextToken()
+			log.Print(t)
+			switch t.Type {
+			case tokens.EOF, tokens.Illegal:
+				return nil, true
+			}
+		}
+	}
+
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		log.Print("Parser errors:\n")
+		for _, e := range p.Errors() {
+			log.Printf("\t%s", e)
+		}
+		return nil, false
+	}
+	program.File = fsName(filename)
+	return program, true
+}
+
+// debug prints AST or parsed program if requested by flags, and reports whether it was printed.
+func debug(program *ast.Program) bool {
+	if *DebugASTF {
+		cfg := &spew.ConfigState{
+			Indent:                  "  ",
+			DisableMethods:          true,
+			DisablePointerMethods:   true,
+			DisablePointerAddresses: true,
+			DisableCapacities:       true,
+			ContinueOnMethod:        true,
+		}
+		b := cfg.Sdump(program)
+		log.Printf("AST:\n%s", b)
+		return true
+	}
+	if *DebugParserF {
+		log.Printf("Parsed program:\n%s", program.String())
+		return true
+	}
+	return false
+}
+
+// run evaluates parsed program of the named file with given code in the given scope,
+// prints its result, and returns exit code: 0 if it succeeded.
+func run(filename, code string, program *ast.Program, scope *objects.Scope) int {
+	i := interpreter.New(&interpreter.Config{Importer: importer})
+	res, err := i.Eval(context.TODO(), program, scope)
+	if err != nil {
+		re, ok := err.(*interpreter.RuntimeError)
+		switch {
+		case !ok:
+			log.Printf("Runtime error: %s.", err)
+		case re.Static:
+			// like Go compiler, without call stack
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", displayName(filename, re.Position.File), re.Position.Line, re.Position.Column, re.Msg)
+			return exitStatic
+		default:
+			printPanic(os.Stderr, filename, code, program.Package != nil && program.Package.Value == "main", re)
+		}
+		return exitPanic
+	}
+	if n, ok := res.(*objects.Nil); !ok || n.T != nil {
+		fmt.Println(res.String())
+	}
+	return 0
+}
+
+// displayName returns the name of the file for messages about the program from the named file:
+// file's own name for the program itself, and the host filesystem path for imported modules and other files.
+func displayName(filename, file string) string {
+	if file == "" || file == fsName(filename) {
+		return filename
+	}
+	return "/" + file
+}
+
+// maxPrintedFrames is the maximum number of printed call stack frames, not counting the outermost one.
+const maxPrintedFrames = 100
+
+// printPanic prints unrecovered panic or fatal error and Gosh call stack like Go runtime does.
+// For package main, the outermost frame of the main goroutine is the package initialization:
+// like Go runtime, it is printed as init function, and hidden if it is a call of main function.
+func printPanic(w io.Writer, filename, code string, pkgMain bool, err *interpreter.RuntimeError) {
+	switch {
+	case err.Err == objects.ErrDeadlock:
+		fmt.Fprintf(w, "fatal error: %s\n\ngoroutine %d [blocked]:\n", err.Msg, err.Goroutine)
+	case err.Fatal:
+		fmt.Fprintf(w, "fatal error: %s\n\ngoroutine %d [running]:\n", err.Msg, err.Goroutine)
+	default:
+		fmt.Fprintf(w, "panic: %s\n\ngoroutine %d [running]:\n", err.Msg, err.Goroutine)
+	}
+
+	stack := err.Stack
+	if pkgMain && err.Goroutine == 1 && len(stack) > 0 {
+		stack = append([]interpreter.Frame(nil), stack...)
+		if len(stack) > 1 && stack[len(stack)-2].Function == "main" {
+			stack = stack[:len(stack)-1]
+		} else {
+			stack[len(stack)-1].Function = "init"
+		}
+	}
+
+	for n, f := range stack {
+		// like Go, print only the innermost frames of deep call stacks
+		if n == maxPrintedFrames && n < len(stack)-1 {
+			fmt.Fprintf(w, "...additional frames elided...\n")
+		}
+		if n >= maxPrintedFrames && n < len(stack)-1 {
+			continue
+		}
+
+		// frames of imported modules are in other files
+		name, src := displayName(filename, f.File), code
+		if name != filename {
+			b, _ := ioutil.ReadFile(filepath.FromSlash(name))
+			src = string(b)
+		}
+		var line int
+		if f.Offset <= len(src) {
+			line = strings.Count(src[:f.Offset], "\n") + 1
+		}
+
+		// the outermost frame of other goroutines is the go statement
+		if err.Goroutine != 1 && n == len(stack)-1 {
+			fmt.Fprintf(w, "created by main.%s\n\t%s:%d\n", f.Function, name, line)
+			continue
+		}
+
+		args := "(...)"
+		if f.Function == "main" || f.Function == "init" {
+			args = "()"
+		}
+		fmt.Fprintf(w, "main.%s%s\n\t%s:%d\n", f.Function, args, name, line)
+	}
+}
+
+func evalFile(filename string) {
+	if fi, err := os.Stat(filename); err == nil && fi.IsDir() {
+		evalDir(filename)
+		return
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scope := objects.NewScope(objects.Builtin(os.Stdout))
+	if code := eval(filename, string(b), scope, *OptimizeF); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// evalDir evaluates all Gosh files in directory dir as one package; see pkgdir.Programs.
+// Programs of the package are not optimized: functions declared by one file can be reassigned by another.
+func evalDir(dir string) {
+	names, err := pkgdir.Files(os.DirFS(dir), ".")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// each program is reported as a part of its own file
+	type source struct {
+		filename, code string
+	}
+	sources := make(map[string]source, len(names))
+	files := make([]*ast.Program, 0, len(names))
+	for _, name := range names {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		program, ok := parse(filename, string(b))
+		if !ok {
+			log.Printf("Failed to parse %s.", filename)
+			os.Exit(exitStatic)
+		}
+		if program != nil {
+			sources[program.File] = source{filename: filename, code: string(b)}
+			files = append(files, program)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	programs, err := pkgdir.Programs(files)
+	if err != nil {
+		log.Printf("Package error: %s.", err)
+		os.Exit(exitStatic)
+	}
+
+	scope := objects.NewScope(objects.Builtin(os.Stdout))
+	for _, program := range programs {
+		if debug(program) {
+			continue
+		}
+		src := sources[program.File]
+		if code := run(src.filename, src.code, program, scope); code != 0 {
+			os.Exit(code)
+		}
+	}
+}
+
+// readREPLHistory reads REPL history from from file and returns file name where it should be wrote at exit.
+// It returns empty string if history can't be wrote at exit.
+func readREPLHistory(liner *liner.State) string {
+	var historyFilename string
+	u, err := user.Current()
+	if err == nil && u.HomeDir != "" {
+		historyFilename = filepath.Join(u.HomeDir, ".gosh_history")
+	}
+	if historyFilename == "" {
+		// we failed to detect user's home directory, so we can't read and write history file
+		return ""
+	}
+
+	f, err := os.Open(historyFilename)
+	if err != nil && os.IsNotExist(err) {
+		// history file does not exist, but we can create it at exit
+		return historyFilename
+	}
+	if err != nil {
+		log.Printf("Warning: failed to open history file %s: %s.", historyFilename, err)
+		return ""
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			log.Printf("Warning: failed to close history file %s: %s.", historyFilename, err)
+		}
+	}()
+
+	if _, err = liner.ReadHistory(f); err != nil {
+		log.Printf("Warning: failed to read history file %s: %s.", historyFilename, err)
+	}
+	return historyFilename
+}
+
+func writeREPLHistory(liner *liner.State, historyFilename string) {
+	f, err := os.Create(historyFilename)
+	if err != nil {
+		log.Printf("Warning: failed to create history file %s: %s.", historyFilename, err)
+		return
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			log.Printf("Warning: failed to close history file %s: %s.", historyFilename, err)
+		}
+	}()
+
+	if _, err = liner.WriteHistory(f); err != nil {
+		log.Printf("Warning: failed to write history file %s: %s.", historyFilename, err)
+	}
+}
+
+func runREPL() {
+	fmt.Printf("Gosh v%s. https://gosh-lang.org/\n", Version)
+	fmt.Printf("Built with Go v%s.\n", extractGoVersion(runtime.Version()))
+	fmt.Printf("Runtime Go v%s.\n", extractGoVersion(goVersion()))
+
+	liner := liner.NewLiner()
+	historyFilename := readREPLHistory(liner)
+	defer func() {
+		fmt.Println()
+		writeREPLHistory(liner, historyFilename)
+		if err := liner.Close(); err != nil {
+			log.Printf("Failed to close liner: %s.", err)
+		}
+	}()
+
+	scope := objects.NewScope(objects.Builtin(os.Stdout))
+	for {
+		line, err := liner.Prompt(`\ʕ•ϖ•ʔ/ >> `)
+		switch err {
+		case nil:
+			liner.AppendHistory(line)
+			eval("<repl>", line, scope, false)
+		case io.EOF:
+			return
+		default:
+			log.Fatal(err)
+		}
+	}
+}
+
+// bindGo writes bindings for Go package to the named file, or to stdout if filename is empty.
+// Standard library symbols added after goVersion are skipped, if it is not empty.
+func bindGo(importPath, pkgName, goVersion, filename string) {
+	b, err := bind.Generate(importPath, pkgName, &bind.Config{GoVersion: goVersion})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if filename == "" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		err = ioutil.WriteFile(filename, b, 0666)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+
+	DebugScannerF = kingpin.Flag("debug-scanner", "Print tokens and exit.").Bool()
+	DebugASTF = kingpin.Flag("debug-ast", "Print AST (optimized with --optimize) and exit.").Bool()
+	DebugParserF = kingpin.Flag("debug-parser", "Print parsed (and optimized with --optimize) program and exit.").Bool()
+	OptimizeF = kingpin.Flag("optimize", "Optimize program before evaluation (files only).").Bool()
+
+	runCmd := kingpin.Command("run", "Run Gosh program file, or start REPL.").Default()
+	fileArg := runCmd.Arg("file", "Gosh program file or package directory.").String()
+
+	// go generate sets GOPACKAGE
+	defaultPackage := os.Getenv("GOPACKAGE")
+	if defaultPackage == "" {
+		defaultPackage = "bindings"
+	}
+	bindCmd := kingpin.Command("bind", "Generate Go source with bindings for Go package.")
+	bindOutputF := bindCmd.Flag("output", "Output file (default stdout).").Short('o').String()
+	bindPackageF := bindCmd.Flag("package", "Package name of generated code.").Short('p').Default(defaultPackage).String()
+	bindGoF := bindCmd.Flag("go", "Minimal Go version of generated code, like go1.23 (default current toolchain).").String()
+	importPathArg := bindCmd.Arg("importpath", "Go package import path.").Required().String()
+
+	kingpin.CommandLine.HelpFlag.Short('h')
+	cmd := kingpin.Parse()
+	importer = newImporter()
+	switch cmd {
+	case bindCmd.FullCommand():
+		bindGo(*importPathArg, *bindPackageF, *bindGoF, *bindOutputF)
+	default:
+		if *fileArg == "" {
+			runREPL()
+		} else {
+			evalFile(*fileArg)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+//go:generate stringer -type Opcode
+
+// Opcode is a bytecode instruction opcode.
+type Opcode byte
+
+// The list of opcodes. Operands are described in definitions.
+//
+// Most instructions operate on the value stack. Operands "node", "name" and "constant" are indexes in
+// Program.Nodes, Program.Names and Program.Constants; the node is used for evaluation rules that depend
+// on the source and for error messages. Variables are addressed by resolved "depth" and "slot" operands
+// (see ast.Identifier); the slot is NoSlot for entities declared by name. Constant expressions are folded
+// at compile time: "default value" is the constant of their value of default type, or nil constant if that
+// type can't represent it. Jump targets are byte offsets in the function's instructions.
+const (
+	OpPos            Opcode = iota // start statement at byte offset: set frame offset and reset the result
+	OpFail                         // fail at the current statement with constant message
+	OpCheck                        // resolve identifiers and check constant expressions of the program node before execution, and push the scope of its top-level entities
+	OpConstant                     // push constant
+	OpNil                          // push no type (for assignments without static type)
+	OpPop                          // pop value
+	OpDup                          // push copies of the given number of values on top of the stack
+	OpResult                       // pop value and make it the statement result
+	OpNilResult                    // reset the statement result to nil
+	OpContinueResult               // make continue the statement result
+	OpGet                          // push the value of named entity
+	OpGetLocal                     // push the value of entity bound to a slot of block scope
+	OpTypeOf                       // push the type of variable, or no type
+	OpValueType                    // pop value and push its type, or no type
+	OpDefine                       // pop value and declare a variable of var statement
+	OpShortDefine                  // pop value and declare a variable of := statement
+	OpAssign                       // pop value and assign it to the named variable
+	OpAssignLocal                  // pop value and assign it to the variable bound to a slot of block scope
+	OpPushScope                    // enter a new block scope with the given number of slots; reusable scopes are never captured
+	OpPopScope                     // leave given number of block scopes
+	OpCopyScope                    // replace the current scope with its copy (for loop iterations)
+	OpType                         // check that the value of type expression node is a type
+	OpMakeType                     // pop component types and push a type of type literal node
+	OpDefineType                   // pop type and declare a defined type of type statement node
+	OpZero                         // pop type and push its zero value
+	OpAssigned                     // pop value and type, and push the value of expression node assigned to that type
+	OpAssignedConst                // pop type and push the value of constant expression node assigned to that type
+	OpUnary                        // pop operand and push the result of prefix expression node
+	OpBinary                       // pop operands and push the result of infix expression node
+	OpConstOperand                 // push constant operand (left or right) of infix expression node typed as the other one
+	OpLogical                      // check the left operand of && or || node; jump if it determines the result
+	OpShift                        // pop operands and push the result of shift expression node
+	OpJump                         // jump
+	OpJumpIfFalse                  // pop boolean condition and jump if it is false
+	OpJumpIfType                   // jump to conversion if the function value of call node is a type
+	OpCall                         // pop arguments and function, and push the result of call node
+	OpConvert                      // pop value and type, and push the result of conversion node
+	OpConvertConst                 // pop type and push the result of conversion node with constant argument
+	OpClosure                      // push a closure of the function in the current scope
+	OpIndexable                    // check that the value can be indexed by index or slice expression node
+	OpAddressable                  // check that the value can be indexed by assigned index expression node
+	OpCheckIndex                   // check that the value of index expression node is an integer
+	OpIndex                        // pop index and value, and push the element
+	OpAssignIndex                  // pop value, index and slice, and assign the element of index expression node
+	OpSlice                        // pop indexes and value, and push the result of slice expression node
+	OpIncDecIndex                  // pop index and slice, and increment or decrement the element of statement node
+	OpIncDecValue                  // pop value and push it incremented or decremented by statement node
+	OpRange                        // pop range expression value and start iteration of range statement node
+	OpIterNext                     // push the next key and value of the current iteration, or finish it and jump
+	OpRangeSet                     // pop key and value, and set iteration variables of range statement node
+	OpReturn                       // return the statement result, the popped value, or the named result
+	OpResultType                   // push the type of the named result
+	OpSetResult                    // set the named result to the value on the stack
+	OpNotConversion                // check that the function value of defer or go statement node is not a type
+	OpDefer                        // pop arguments and function, and defer the call of statement node
+	OpGo                           // pop arguments and function, and start a goroutine for statement node
+	OpSendCheck                    // check the channel of send statement node and push its element type
+	OpSend                         // pop value and channel, and send
+	OpRecvCheck                    // check the channel of receive expression node
+	OpRecv                         // pop channel and push the received value
+	OpRecvOK                       // pop channel and push the received value and a flag
+	OpAssignValues                 // pop values and assign them to variables of multi-value assignment statement node
+	OpSelect                       // pop case values of select statement node, perform it and jump to the chosen clause
+	OpCheckContext                 // stop if the context is done
+	OpImport                       // import modules of import statement node
+	OpSelector                     // pop module and push its member selected by selector expression node
+	OpAssignSelector               // pop value and module, and assign the member selected by selector expression node
+)
+
+// definition describes an opcode: operand widths in bytes.
+type definition struct {
+	operands []int
+}
+
+var definitions = map[Opcode]*definition{
+	OpPos:            {[]int{4}}, // offset
+	OpFail:           {[]int{2}}, // constant
+	OpCheck:          {[]int{2}}, // node
+	OpConstant:       {[]int{2}}, // constant
+	OpNil:            {nil},
+	OpPop:            {nil},
+	OpDup:            {[]int{1}}, // count
+	OpResult:         {nil},
+	OpNilResult:      {nil},
+	OpContinueResult: {nil},
+	OpGet:            {[]int{2, 2}},    // name, depth
+	OpGetLocal:       {[]int{2, 2, 2}}, // name, depth, slot
+	OpTypeOf:         {[]int{2, 2, 2}}, // name, depth, slot
+	OpValueType:      {nil},
+	OpDefine:         {[]int{2, 2}},    // name, slot
+	OpShortDefine:    {[]int{2, 2}},    // name, slot
+	OpAssign:         {[]int{2, 2}},    // name, depth
+	OpAssignLocal:    {[]int{2, 2, 2}}, // name, depth, slot
+	OpPushScope:      {[]int{2, 1}},    // slots, reusable
+	OpPopScope:       {[]int{2}},       // count
+	OpCopyScope:      {nil},
+	OpType:           {[]int{2}}, // node
+	OpMakeType:       {[]int{2}}, // node
+	OpDefineType:     {[]int{2}}, // node
+	OpZero:           {nil},
+	OpAssigned:       {[]int{2}},       // node
+	OpAssignedConst:  {[]int{2, 2}},    // node, default value
+	OpUnary:          {[]int{2}},       // node
+	OpBinary:         {[]int{2}},       // node
+	OpConstOperand:   {[]int{2, 2, 1}}, // node, default value, side (0 for left, 1 for right)
+	OpLogical:        {[]int{2, 4}},    // node, target
+	OpShift:          {[]int{2}},       // node
+	OpJump:           {[]int{4}},       // target
+	OpJumpIfFalse:    {[]int{4}},       // target
+	OpJumpIfType:     {[]int{2, 4}},    // node, target
+	OpCall:           {[]int{2, 1}},    // node, CallValue, CallStatement or CallResults
+	OpConvert:        {[]int{2}},       // node
+	OpConvertConst:   {[]int{2}},       // node
+	OpClosure:        {[]int{2}},       // function
+	OpIndexable:      {[]int{2}},       // node
+	OpAddressable:    {[]int{2}},       // node
+	OpCheckIndex:     {[]int{2}},       // node
+	OpIndex:          {[]int{2}},       // node
+	OpAssignIndex:    {[]int{2}},       // node
+	OpSlice:          {[]int{2}},       // node
+	OpIncDecIndex:    {[]int{2}},       // node
+	OpIncDecValue:    {[]int{2}},       // node
+	OpRange:          {[]int{2}},       // node
+	OpIterNext:       {[]int{4}},       // target
+	OpRangeSet:       {[]int{2}},       // node
+	OpReturn:         {[]int{1}},       // ReturnResult, ReturnValue or ReturnNamed
+	OpResultType:     {nil},
+	OpSetResult:      {nil},
+	OpNotConversion:  {[]int{2}},    // node
+	OpDefer:          {[]int{2}},    // node
+	OpGo:             {[]int{2}},    // node
+	OpSendCheck:      {[]int{2}},    // node
+	OpSend:           {[]int{2}},    // node
+	OpRecvCheck:      {[]int{2}},    // node
+	OpRecv:           {[]int{2}},    // node
+	OpRecvOK:         {[]int{2}},    // node
+	OpAssignValues:   {[]int{2}},    // node
+	OpSelect:         {[]int{2, 2}}, // node, jump table
+	OpCheckContext:   {nil},
+	OpImport:         {[]int{2}}, // node
+	OpSelector:       {[]int{2}}, // node
+	OpAssignSelector: {[]int{2}}, // node
+}
+
+// NoSlot is the slot operand of entities declared by name rather than bound to slots by resolver.
+const NoSlot = math.MaxUint16
+
+// Operands of OpCall.
+const (
+	CallValue     = iota // push the single value of the result
+	CallStatement        // push the result of expression statement; multiple Go results are allowed
+	CallResults          // push all results of multi-value assignment
+)
+
+// Operands of OpReturn.
+const (
+	ReturnResult = iota // return the statement result
+	ReturnValue         // return the popped value
+	ReturnNamed         // return the named result
+)
+
+// Instructions is a sequence of bytecode instructions.
+type Instructions []byte
+
+// Make returns encoded instruction.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		panic(fmt.Sprintf("unknown opcode %d", op))
+	}
+	if len(operands) != len(def.operands) {
+		panic(fmt.Sprintf("%s: expected %d operands, got %d", op, len(def.operands), len(operands)))
+	}
+
+	size := 1
+	for _, w := range def.operands {
+		size += w
+	}
+	res := make([]byte, size)
+	res[0] = byte(op)
+	offset := 1
+	for n, o := range operands {
+		w := def.operands[n]
+		switch w {
+		case 1:
+			res[offset] = byte(o)
+		case 2:
+			binary.BigEndian.PutUint16(res[offset:], uint16(o))
+		case 4:
+			binary.BigEndian.PutUint32(res[offset:], uint32(o))
+		}
+		offset += w
+	}
+	return res
+}
+
+// ReadOperands decodes operands of instruction op from ins.
+// It returns decoded operands and the number of read bytes.
+func ReadOperands(op Opcode, ins Instructions) ([]int, int) {
+	def := definitions[op]
+	res := make([]int, len(def.operands))
+	var offset int
+	for n, w := range def.operands {
+		switch w {
+		case 1:
+			res[n] = int(ins[offset])
+		case 2:
+			res[n] = int(ReadUint16(ins[offset:]))
+		case 4:
+			res[n] = int(ReadUint32(ins[offset:]))
+		}
+		offset += w
+	}
+	return res, offset
+}
+
+// ReadUint16 decodes 2-byte operand.
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint32 decodes 4-byte operand.
+func ReadUint32(ins Instructions) uint32 {
+	return binary.BigEndian.Uint32(ins)
+}
+
+// String returns disassembled instructions, one per line.
+func (ins Instructions) String() string {
+	var res strings.Builder
+	for ip := 0; ip < len(ins); {
+		op := Opcode(ins[ip])
+		if _, ok := definitions[op]; !ok {
+			fmt.Fprintf(&res, "%04d ERROR: unknown opcode %d\n", ip, op)
+			ip++
+			continue
+		}
+		operands, n := ReadOperands(op, ins[ip+1:])
+		fmt.Fprintf(&res, "%04d %s", ip, op)
+		for _, o := range operands {
+			fmt.Fprintf(&res, " %d", o)
+		}
+		res.WriteString("\n")
+		ip += 1 + n
+	}
+	return res.String()
+}
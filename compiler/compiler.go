@@ -0,0 +1,893 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"runtime"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Program is a compiled Gosh program.
+type Program struct {
+	File      string           // source file name; empty for programs without files
+	Lines     tokens.Lines     // line offsets of that file; or nil
+	Main      *Function        // the program itself: the body of main function
+	Functions []*Function      // function literals, referenced by OpClosure
+	Constants []objects.Object // values of constant expressions and error messages
+	Names     []string         // identifiers
+	Nodes     []ast.Node       // AST nodes for evaluation rules that depend on the source and for error messages
+}
+
+// Function is a compiled function body.
+type Function struct {
+	Program      *Program
+	Literal      *ast.FunctionLiteral // nil for the program itself
+	Instructions Instructions
+	Types        []*Function // code evaluating parameter types; nil for untyped parameters
+	Results      []*Function // code evaluating types of named results; nil elements for results without types
+	Tables       [][]int     // jump tables of select statements: targets of clauses in source order
+}
+
+// scope is a compilation state of a function.
+type scope struct {
+	fn      *Function
+	blocks  []block   // block scopes entered in the function, innermost last
+	targets []*target // continue targets, innermost last
+	results bool      // true if the function has named results
+}
+
+// block is a block scope entered in a function.
+type block struct {
+	pos       int // position of OpPushScope instruction
+	functions int // number of function literals compiled before the block
+}
+
+// target is a target of continue statements.
+type target struct {
+	blocks int   // number of block scopes at the target
+	result bool  // true if continue makes the result of the enclosing function or top-level statement
+	jumps  []int // positions of jump instructions
+}
+
+// compiler lowers AST to bytecode.
+type compiler struct {
+	program *Program
+	scope   *scope
+	names   map[string]int
+	nodes   map[ast.Node]int
+	err     error
+}
+
+func newCompiler() *compiler {
+	return &compiler{
+		program: new(Program),
+		names:   make(map[string]int),
+		nodes:   make(map[ast.Node]int),
+	}
+}
+
+// Compile resolves identifiers of program and compiles it.
+// Errors in the program are reported when they are reached by the virtual machine, like the interpreter does;
+// the returned error is non-nil only if program is too large.
+func Compile(program *ast.Program) (*Program, error) {
+	// errors are reported by OpCheck
+	ops.Resolve(program)
+
+	c := newCompiler()
+	c.program.File = program.File
+	c.program.Lines = program.Lines
+	main := &Function{Program: c.program}
+	c.program.Main = main
+	c.scope = &scope{fn: main}
+
+	c.emit(OpCheck, c.node(program))
+	for _, s := range program.Statements {
+		// continue outside of loops ends the top-level statement
+		c.emit(OpPos, ops.StatementOffset(s))
+		c.pushTarget(true)
+		c.statement(s)
+		c.popTarget()
+	}
+	c.emit(OpReturn, ReturnResult)
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.program, nil
+}
+
+// CompileFunction compiles function literal as a separate program.
+// Identifiers of the program containing it should be already resolved.
+func CompileFunction(lit *ast.FunctionLiteral) (*Function, error) {
+	c := newCompiler()
+	fn := c.program.Functions[c.function(lit)]
+	if c.err != nil {
+		return nil, c.err
+	}
+	return fn, nil
+}
+
+// emit appends instruction to the current function and returns its position.
+func (c *compiler) emit(op Opcode, operands ...int) int {
+	fn := c.scope.fn
+	pos := len(fn.Instructions)
+	fn.Instructions = append(fn.Instructions, Make(op, operands...)...)
+	return pos
+}
+
+// patch sets the target of jump instruction at pos to the current position.
+func (c *compiler) patch(pos int) {
+	ins := c.scope.fn.Instructions
+	def := definitions[Opcode(ins[pos])]
+	offset := pos + 1
+	for _, w := range def.operands[:len(def.operands)-1] {
+		offset += w
+	}
+	binary.BigEndian.PutUint32(ins[offset:], uint32(len(ins)))
+}
+
+// index checks that index n fits 2-byte operand.
+func (c *compiler) index(n int, what string) int {
+	if n > math.MaxUint16 && c.err == nil {
+		c.err = fmt.Errorf("program is too large: more than %d %s", math.MaxUint16+1, what)
+	}
+	return n
+}
+
+// constant adds a constant and returns its index.
+func (c *compiler) constant(obj objects.Object) int {
+	c.program.Constants = append(c.program.Constants, obj)
+	return c.index(len(c.program.Constants)-1, "constants")
+}
+
+// name returns the index of identifier name.
+func (c *compiler) name(name string) int {
+	n, ok := c.names[name]
+	if !ok {
+		n = c.index(len(c.program.Names), "names")
+		c.program.Names = append(c.program.Names, name)
+		c.names[name] = n
+	}
+	return n
+}
+
+// node returns the index of AST node.
+func (c *compiler) node(node ast.Node) int {
+	n, ok := c.nodes[node]
+	if !ok {
+		n = c.index(len(c.program.Nodes), "nodes")
+		c.program.Nodes = append(c.program.Nodes, node)
+		c.nodes[node] = n
+	}
+	return n
+}
+
+// depth returns the depth operand of resolved identifier id.
+func (c *compiler) depth(id *ast.Identifier) int {
+	return c.index(id.Depth, "nested blocks")
+}
+
+// slot returns the slot operand of resolved identifier id: its slot, or NoSlot for entities looked up by name.
+func (c *compiler) slot(id *ast.Identifier) int {
+	if !id.Local && !id.Global {
+		return NoSlot
+	}
+	return c.index(id.Slot, "variables in block")
+}
+
+// get emits instruction that pushes the value of resolved identifier id.
+func (c *compiler) get(id *ast.Identifier) {
+	if id.Local || id.Global {
+		c.emit(OpGetLocal, c.name(id.Value), c.depth(id), c.slot(id))
+		return
+	}
+	c.emit(OpGet, c.name(id.Value), c.depth(id))
+}
+
+// set emits instruction that pops the value and assigns it to the variable of resolved identifier id.
+func (c *compiler) set(id *ast.Identifier) {
+	if id.Local || id.Global {
+		c.emit(OpAssignLocal, c.name(id.Value), c.depth(id), c.slot(id))
+		return
+	}
+	c.emit(OpAssign, c.name(id.Value), c.depth(id))
+}
+
+// defaultValue returns the index of the constant with the value of constant expression exp of default type,
+// or of nil constant if that type can't represent it.
+func (c *compiler) defaultValue(exp ast.Expression) int {
+	res, _ := untyped(exp)
+	return c.constant(res)
+}
+
+// fail emits instruction that fails at the current statement with a formatted message.
+func (c *compiler) fail(format string, a ...interface{}) {
+	c.emit(OpFail, c.constant(&objects.String{Value: fmt.Sprintf(format, a...)}))
+}
+
+// pushTarget starts a new target of continue statements at the current block scope.
+func (c *compiler) pushTarget(result bool) {
+	c.scope.targets = append(c.scope.targets, &target{blocks: len(c.scope.blocks), result: result})
+}
+
+// popTarget sets the innermost target of continue statements to the current position.
+func (c *compiler) popTarget() {
+	targets := c.scope.targets
+	t := targets[len(targets)-1]
+	c.scope.targets = targets[:len(targets)-1]
+	for _, pos := range t.jumps {
+		c.patch(pos)
+	}
+}
+
+// enter emits instruction that enters a new block scope with the given number of slots.
+// Blocks without slots have no scopes.
+func (c *compiler) enter(slots int) {
+	if slots == 0 {
+		return
+	}
+	pos := c.emit(OpPushScope, c.index(slots, "variables in block"), 0)
+	c.scope.blocks = append(c.scope.blocks, block{pos: pos, functions: len(c.program.Functions)})
+}
+
+// leave emits instruction that leaves the current block scope entered with the given number of slots.
+func (c *compiler) leave(slots int) {
+	if slots == 0 {
+		return
+	}
+	blocks := c.scope.blocks
+	b := blocks[len(blocks)-1]
+	c.scope.blocks = blocks[:len(blocks)-1]
+
+	// scopes of blocks without function literals are not captured by closures, so they can be reused
+	if len(c.program.Functions) == b.functions {
+		c.scope.fn.Instructions[b.pos+3] = 1
+	}
+	c.emit(OpPopScope, 1)
+}
+
+// captured returns true if the current block scope can be captured by closures.
+func (c *compiler) captured() bool {
+	blocks := c.scope.blocks
+	return len(c.program.Functions) > blocks[len(blocks)-1].functions
+}
+
+// function compiles function literal and returns its index.
+func (c *compiler) function(lit *ast.FunctionLiteral) int {
+	fn := &Function{Program: c.program, Literal: lit}
+	idx := c.index(len(c.program.Functions), "functions")
+	c.program.Functions = append(c.program.Functions, fn)
+
+	outer := c.scope
+	defer func() { c.scope = outer }()
+
+	// types of parameters and named results are evaluated by the caller in the function's scope;
+	// grouped parameters share the type
+	for i, typ := range lit.Types {
+		if i > 0 && typ == lit.Types[i-1] {
+			fn.Types = append(fn.Types, fn.Types[i-1])
+			continue
+		}
+		t := &Function{Program: c.program}
+		c.scope = &scope{fn: t}
+		c.typeExpression(typ)
+		c.emit(OpReturn, ReturnValue)
+		fn.Types = append(fn.Types, t)
+	}
+	for _, r := range lit.Results {
+		if r.Type == nil {
+			fn.Results = append(fn.Results, nil)
+			continue
+		}
+		t := &Function{Program: c.program}
+		c.scope = &scope{fn: t}
+		c.typeExpression(r.Type)
+		c.emit(OpReturn, ReturnValue)
+		fn.Results = append(fn.Results, t)
+	}
+
+	// parameters, named results and function body are in the same block;
+	// continue outside of loops ends the function
+	c.scope = &scope{fn: fn, results: len(lit.Results) > 0}
+	c.pushTarget(true)
+	c.statements(lit.Body.Statements)
+	c.popTarget()
+	c.emit(OpReturn, ReturnResult)
+	return idx
+}
+
+// statements compiles statements of a block or a case clause.
+func (c *compiler) statements(statements []ast.Statement) {
+	for _, s := range statements {
+		c.emit(OpPos, ops.StatementOffset(s))
+		c.statement(s)
+	}
+}
+
+// block compiles block statement in a new scope.
+func (c *compiler) block(node *ast.BlockStatement) {
+	c.enter(node.Slots)
+	c.statements(node.Statements)
+	c.leave(node.Slots)
+}
+
+//nolint:gocyclo
+func (c *compiler) statement(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		c.block(node)
+
+	case *ast.ExpressionStatement:
+		if call, ok := node.Expression.(*ast.CallExpression); ok {
+			c.callExpression(call, CallStatement)
+			c.emit(OpResult)
+		} else if node.Expression != nil {
+			c.expression(node.Expression)
+			c.emit(OpResult)
+		}
+
+	case *ast.ReturnStatement:
+		c.returnStatement(node)
+
+	case *ast.DeferStatement:
+		c.expression(node.Call.Function)
+		c.emit(OpNotConversion, c.node(node))
+		c.expressions(node.Call.Arguments)
+		c.emit(OpDefer, c.node(node))
+
+	case *ast.GoStatement:
+		c.expression(node.Call.Function)
+		c.emit(OpNotConversion, c.node(node))
+		c.expressions(node.Call.Arguments)
+		c.emit(OpGo, c.node(node))
+
+	case *ast.SendStatement:
+		c.sendCase(node)
+		c.emit(OpSend, c.node(node))
+
+	case *ast.SelectStatement:
+		c.selectStatement(node)
+
+	case *ast.VarStatement:
+		switch {
+		case node.Type == nil:
+			c.expression(node.Value)
+		case node.Value == nil:
+			c.typeExpression(node.Type)
+			c.emit(OpZero)
+		default:
+			c.typeExpression(node.Type)
+			c.assigned(node.Value)
+		}
+		c.emit(OpDefine, c.name(node.Name.Value), c.slot(node.Name))
+
+	case *ast.TypeStatement:
+		c.typeExpression(node.Type)
+		c.emit(OpDefineType, c.node(node))
+
+	case *ast.ImportStatement:
+		c.emit(OpImport, c.node(node))
+
+	case *ast.AssignStatement:
+		c.assignStatement(node)
+
+	case *ast.ForStatement:
+		c.forStatement(node)
+
+	case *ast.RangeStatement:
+		c.rangeStatement(node)
+
+	case *ast.IfStatement:
+		c.expression(node.Cond)
+		end := c.emit(OpJumpIfFalse, 0)
+		c.block(node.Body)
+		c.patch(end)
+		c.emit(OpNilResult)
+
+	case *ast.IncrementDecrementStatement:
+		switch x := node.X.(type) {
+		case *ast.Identifier:
+			c.get(x)
+			c.emit(OpIncDecValue, c.node(node))
+			c.set(x)
+		case *ast.IndexExpression:
+			c.expression(x.Left)
+			c.emit(OpAddressable, c.node(x))
+			c.expression(x.Index)
+			c.emit(OpIncDecIndex, c.node(node))
+		case *ast.SelectorExpression:
+			c.expression(x.X)
+			c.emit(OpDup, 1)
+			c.emit(OpSelector, c.node(x))
+			c.emit(OpIncDecValue, c.node(node))
+			c.emit(OpAssignSelector, c.node(x))
+		default:
+			c.fail("cannot assign to %s (neither addressable nor a map index expression)", node.X)
+		}
+
+	case *ast.ContinueStatement:
+		t := c.scope.targets[len(c.scope.targets)-1]
+		if t.result {
+			c.emit(OpContinueResult)
+		}
+		if n := len(c.scope.blocks) - t.blocks; n > 0 {
+			c.emit(OpPopScope, n)
+		}
+		t.jumps = append(t.jumps, c.emit(OpJump, 0))
+
+	default:
+		c.fail("unexpected node %T:\n%#v", node, node)
+	}
+}
+
+// assigned compiles expression exp which value is assigned to a variable of type on the stack.
+func (c *compiler) assigned(exp ast.Expression) {
+	if ops.IsConstant(exp) {
+		c.emit(OpAssignedConst, c.node(exp), c.defaultValue(exp))
+		return
+	}
+	c.expression(exp)
+	c.emit(OpAssigned, c.node(exp))
+}
+
+func (c *compiler) assignStatement(node *ast.AssignStatement) {
+	if node.Rest != nil {
+		c.multiAssignStatement(node)
+		return
+	}
+
+	name, ok := node.Name.(*ast.Identifier)
+	if !ok {
+		c.assignAddressable(node)
+		return
+	}
+
+	if node.Token.Type == tokens.Define {
+		c.expression(node.Value)
+		c.emit(OpShortDefine, c.name(name.Value), c.slot(name))
+		return
+	}
+
+	exp := node.Value
+	if node.Token.Type != tokens.Assignment {
+		// x op= y is evaluated as x = x op y
+		var msg string
+		exp, msg = compoundExpression(node)
+		if msg != "" {
+			c.fail("%s", msg)
+			return
+		}
+	}
+
+	if name.Value == "_" {
+		c.expression(exp)
+		c.emit(OpPop)
+		return
+	}
+	c.emit(OpTypeOf, c.name(name.Value), c.depth(name), c.slot(name))
+	c.assigned(exp)
+	c.set(name)
+}
+
+// multiAssignStatement compiles assignment of received value and a flag, or of Go function call results.
+func (c *compiler) multiAssignStatement(node *ast.AssignStatement) {
+	switch value := node.Value.(type) {
+	case *ast.CallExpression:
+		// OpAssignValues checks the number of results
+		c.callExpression(value, CallResults)
+	case *ast.PrefixExpression:
+		if value.Token.Type != tokens.Arrow || len(node.Rest) != 1 {
+			c.fail("%s", ops.AssignmentMismatch(node, 1))
+			return
+		}
+		c.expression(value.Right)
+		c.emit(OpRecvOK, c.node(value))
+	default:
+		c.fail("%s", ops.AssignmentMismatch(node, 1))
+		return
+	}
+	c.emit(OpAssignValues, c.node(node))
+}
+
+// assignAddressable compiles assignment to slice element or module member.
+// Operands of the left side are evaluated once, before the right side;
+// they stay on the stack below the current value.
+func (c *compiler) assignAddressable(node *ast.AssignStatement) {
+	var store Opcode
+	switch x := node.Name.(type) {
+	case *ast.IndexExpression:
+		c.expression(x.Left)
+		c.emit(OpAddressable, c.node(x))
+		c.expression(x.Index)
+		c.emit(OpDup, 2)
+		c.emit(OpIndex, c.node(x))
+		store = OpAssignIndex
+	case *ast.SelectorExpression:
+		c.expression(x.X)
+		c.emit(OpDup, 1)
+		c.emit(OpSelector, c.node(x))
+		store = OpAssignSelector
+	default:
+		c.fail("cannot assign to %s (neither addressable nor a map index expression)", node.Name)
+		return
+	}
+
+	if node.Token.Type == tokens.Assignment {
+		c.emit(OpValueType)
+		c.assigned(node.Value)
+	} else {
+		// x op= y is evaluated as x = x op y with the current value of x
+		exp, msg := compoundExpression(node)
+		if msg != "" {
+			c.fail("%s", msg)
+			return
+		}
+		switch {
+		case exp.Token.Type == tokens.ShiftLeft || exp.Token.Type == tokens.ShiftRight:
+			c.expression(node.Value)
+			c.emit(OpShift, c.node(exp))
+		case ops.IsConstant(node.Value):
+			c.emit(OpConstOperand, c.node(exp), c.defaultValue(node.Value), 1)
+			c.emit(OpBinary, c.node(exp))
+		default:
+			c.expression(node.Value)
+			c.emit(OpBinary, c.node(exp))
+		}
+	}
+	c.emit(store, c.node(node.Name))
+}
+
+func (c *compiler) returnStatement(node *ast.ReturnStatement) {
+	switch {
+	case node.Value == nil:
+		c.emit(OpReturn, ReturnNamed)
+	case !c.scope.results:
+		c.expression(node.Value)
+		c.emit(OpReturn, ReturnValue)
+	default:
+		// assign value to named result, so deferred calls can modify it
+		c.emit(OpResultType)
+		c.assigned(node.Value)
+		c.emit(OpSetResult)
+		c.emit(OpReturn, ReturnValue)
+	}
+}
+
+func (c *compiler) forStatement(node *ast.ForStatement) {
+	// each iteration has its own copy of variables declared by init statement
+	c.enter(node.Slots)
+	if node.Init != nil {
+		c.statement(node.Init)
+	}
+
+	top := c.emit(OpCheckContext)
+	exit := -1
+	if node.Cond != nil {
+		c.expression(node.Cond)
+		exit = c.emit(OpJumpIfFalse, 0)
+	}
+
+	c.pushTarget(false)
+	c.block(node.Body)
+	c.popTarget()
+
+	if node.Slots > 0 && c.captured() {
+		c.emit(OpCopyScope)
+	}
+	if node.Post != nil {
+		c.statement(node.Post)
+	}
+	c.emit(OpJump, top)
+
+	if exit >= 0 {
+		c.patch(exit)
+	}
+	c.leave(node.Slots)
+	c.emit(OpNilResult)
+}
+
+func (c *compiler) rangeStatement(node *ast.RangeStatement) {
+	c.expression(node.X)
+	c.emit(OpRange, c.node(node))
+	next := c.emit(OpIterNext, 0)
+
+	// each iteration has its own variables declared with :=
+	if node.Define {
+		c.enter(node.Slots)
+	}
+	c.emit(OpRangeSet, c.node(node))
+	c.pushTarget(false)
+	c.block(node.Body)
+	c.popTarget()
+	if node.Define {
+		c.leave(node.Slots)
+	}
+	c.emit(OpCheckContext)
+	c.emit(OpJump, next)
+
+	c.patch(next)
+	c.emit(OpNilResult)
+}
+
+// sendCase compiles channel and value of send statement.
+func (c *compiler) sendCase(node *ast.SendStatement) {
+	c.expression(node.Channel)
+	c.emit(OpSendCheck, c.node(node))
+	c.assigned(node.Value)
+}
+
+func (c *compiler) selectStatement(node *ast.SelectStatement) {
+	// all channels and sent values are evaluated once, in source order
+	var def bool
+	for _, cc := range node.Cases {
+		if cc.Comm == nil {
+			if def {
+				c.fail("multiple defaults in select")
+				return
+			}
+			def = true
+			continue
+		}
+
+		if s, ok := cc.Comm.(*ast.SendStatement); ok {
+			c.sendCase(s)
+			continue
+		}
+		recv := ops.ReceiveExpression(cc.Comm)
+		if recv == nil {
+			c.fail("select case must be receive, send or assign recv")
+			return
+		}
+		c.expression(recv.Right)
+		c.emit(OpRecvCheck, c.node(recv))
+	}
+
+	fn := c.scope.fn
+	table := len(fn.Tables)
+	fn.Tables = append(fn.Tables, make([]int, len(node.Cases)))
+	c.emit(OpSelect, c.node(node), c.index(table, "select statements"))
+
+	// each clause is an implicit block
+	ends := make([]int, len(node.Cases))
+	for n, cc := range node.Cases {
+		fn.Tables[table][n] = len(fn.Instructions)
+		c.enter(cc.Slots)
+		if a, ok := cc.Comm.(*ast.AssignStatement); ok {
+			c.emit(OpAssignValues, c.node(a))
+		}
+		c.statements(cc.Body)
+		c.leave(cc.Slots)
+		ends[n] = c.emit(OpJump, 0)
+	}
+	for _, pos := range ends {
+		c.patch(pos)
+	}
+}
+
+func (c *compiler) expressions(exps []ast.Expression) {
+	for _, e := range exps {
+		c.expression(e)
+	}
+}
+
+//nolint:gocyclo
+func (c *compiler) expression(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		c.get(node)
+
+	case *ast.PrefixExpression:
+		switch {
+		case node.Token.Type == tokens.Arrow:
+			c.expression(node.Right)
+			c.emit(OpRecv, c.node(node))
+		case ops.IsConstant(node):
+			c.untyped(node)
+		default:
+			c.expression(node.Right)
+			c.emit(OpUnary, c.node(node))
+		}
+
+	case *ast.InfixExpression:
+		c.infixExpression(node)
+
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.ImaginaryLiteral, *ast.RuneLiteral, *ast.Constant:
+		c.untyped(node)
+
+	case *ast.BooleanLiteral:
+		c.emit(OpConstant, c.constant(objects.NewBoolean(node.Value)))
+
+	case *ast.StringLiteral:
+		c.emit(OpConstant, c.constant(&objects.String{Value: node.Value}))
+
+	case *ast.FunctionLiteral:
+		c.emit(OpClosure, c.function(node))
+
+	case *ast.CallExpression:
+		c.callExpression(node, CallValue)
+
+	case *ast.SelectorExpression:
+		c.expression(node.X)
+		c.emit(OpSelector, c.node(node))
+
+	case *ast.IndexExpression:
+		c.expression(node.Left)
+		c.emit(OpIndexable, c.node(node))
+		c.expression(node.Index)
+		c.emit(OpIndex, c.node(node))
+
+	case *ast.SliceExpression:
+		c.expression(node.Left)
+		c.emit(OpIndexable, c.node(node))
+		if node.Low != nil {
+			c.expression(node.Low)
+			c.emit(OpCheckIndex, c.node(node.Low))
+		}
+		if node.High != nil {
+			c.expression(node.High)
+			c.emit(OpCheckIndex, c.node(node.High))
+		}
+		c.emit(OpSlice, c.node(node))
+
+	case *ast.SliceType, *ast.MapType, *ast.PointerType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		c.typeExpression(node)
+
+	default:
+		c.fail("unexpected node %T:\n%#v", node, node)
+	}
+}
+
+// untyped compiles constant expression to its value of default type.
+// Constants that can't be represented by that type fail when they are reached.
+func (c *compiler) untyped(exp ast.Expression) {
+	res, msg := untyped(exp)
+	if res == nil {
+		c.fail("%s", msg)
+		return
+	}
+	c.emit(OpConstant, c.constant(res))
+}
+
+// untyped evaluates constant expression; it returns nil object and error message if that fails.
+func untyped(exp ast.Expression) (res objects.Object, msg string) {
+	defer func() {
+		if p := recover(); p != nil {
+			err, ok := p.(error)
+			if _, isRuntime := p.(runtime.Error); !ok || isRuntime {
+				panic(p)
+			}
+			res, msg = nil, err.Error()
+		}
+	}()
+
+	return ops.Untyped(exp), ""
+}
+
+// compoundExpression returns binary expression for compound assignment; it returns error message if that fails.
+func compoundExpression(node *ast.AssignStatement) (exp *ast.InfixExpression, msg string) {
+	defer func() {
+		if p := recover(); p != nil {
+			err, ok := p.(error)
+			if _, isRuntime := p.(runtime.Error); !ok || isRuntime {
+				panic(p)
+			}
+			exp, msg = nil, err.Error()
+		}
+	}()
+
+	return ops.CompoundExpression(node), ""
+}
+
+func (c *compiler) infixExpression(node *ast.InfixExpression) {
+	if ops.IsConstant(node) {
+		c.untyped(node)
+		return
+	}
+
+	switch node.Token.Type {
+	case tokens.LogicalAnd, tokens.LogicalOr:
+		// the right operand is evaluated only if the left operand does not determine the result
+		c.expression(node.Left)
+		end := c.emit(OpLogical, c.node(node), 0)
+		if ops.IsConstant(node.Right) {
+			c.emit(OpConstOperand, c.node(node), c.defaultValue(node.Right), 1)
+		} else {
+			c.expression(node.Right)
+			if ops.IsConstant(node.Left) {
+				c.emit(OpConstOperand, c.node(node), c.defaultValue(node.Left), 0)
+			}
+		}
+		c.emit(OpBinary, c.node(node))
+		c.patch(end)
+		return
+
+	case tokens.ShiftLeft, tokens.ShiftRight:
+		c.expression(node.Left)
+		c.expression(node.Right)
+		c.emit(OpShift, c.node(node))
+		return
+	}
+
+	// constant operand takes the type of other operand
+	switch {
+	case ops.IsConstant(node.Left):
+		c.emit(OpNil)
+		c.expression(node.Right)
+		c.emit(OpConstOperand, c.node(node), c.defaultValue(node.Left), 0)
+	case ops.IsConstant(node.Right):
+		c.expression(node.Left)
+		c.emit(OpConstOperand, c.node(node), c.defaultValue(node.Right), 1)
+	default:
+		c.expression(node.Left)
+		c.expression(node.Right)
+	}
+	c.emit(OpBinary, c.node(node))
+}
+
+// callExpression compiles call node; mode is an operand of OpCall.
+func (c *compiler) callExpression(node *ast.CallExpression, mode int) {
+	c.expression(node.Function)
+	if _, ok := node.Function.(*ast.FunctionLiteral); ok {
+		c.expressions(node.Arguments)
+		c.emit(OpCall, c.node(node), mode)
+		return
+	}
+
+	conversion := c.emit(OpJumpIfType, c.node(node), 0)
+	c.expressions(node.Arguments)
+	c.emit(OpCall, c.node(node), mode)
+	end := c.emit(OpJump, 0)
+
+	// OpJumpIfType checks the number of arguments in conversion
+	c.patch(conversion)
+	if len(node.Arguments) == 1 {
+		if exp := node.Arguments[0]; ops.IsConstant(exp) {
+			c.emit(OpConvertConst, c.node(node))
+		} else {
+			c.expression(exp)
+			c.emit(OpConvert, c.node(node))
+		}
+	}
+	c.patch(end)
+}
+
+// typeExpression compiles type expression.
+func (c *compiler) typeExpression(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.SliceType:
+		c.typeExpression(node.Elem)
+	case *ast.MapType:
+		c.typeExpression(node.Key)
+		c.typeExpression(node.Value)
+	case *ast.PointerType:
+		c.typeExpression(node.Elem)
+	case *ast.ChanType:
+		c.typeExpression(node.Elem)
+	case *ast.InterfaceType:
+		// nothing
+	case *ast.FuncType:
+		for _, p := range node.Params {
+			c.typeExpression(p)
+		}
+		for _, r := range node.Results {
+			c.typeExpression(r)
+		}
+	default:
+		c.expression(node)
+		c.emit(OpType, c.node(node))
+		return
+	}
+	c.emit(OpMakeType, c.node(node))
+}
@@ -0,0 +1,109 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+)
+
+func TestMake(t *testing.T) {
+	for _, tc := range []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpPop, nil, []byte{byte(OpPop)}},
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpJump, []int{258}, []byte{byte(OpJump), 0, 0, 1, 2}},
+		{OpConstOperand, []int{1, 2, 1}, []byte{byte(OpConstOperand), 0, 1, 0, 2, 1}},
+		{OpGetLocal, []int{3, 1, 2}, []byte{byte(OpGetLocal), 0, 3, 0, 1, 0, 2}},
+		{OpSelect, []int{2, 3}, []byte{byte(OpSelect), 0, 2, 0, 3}},
+	} {
+		t.Run(tc.op.String(), func(t *testing.T) {
+			ins := Make(tc.op, tc.operands...)
+			assert.Equal(t, tc.expected, ins)
+
+			operands, n := ReadOperands(tc.op, ins[1:])
+			assert.Equal(t, len(ins)-1, n)
+			if len(tc.operands) > 0 {
+				assert.Equal(t, tc.operands, operands)
+			}
+		})
+	}
+
+	assert.Panics(t, func() { Make(OpConstant) })
+}
+
+func TestCompile(t *testing.T) {
+	s, err := scanner.New(`var x = 1; x + 2`, nil)
+	require.NoError(t, err)
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	require.Nil(t, p.Errors())
+
+	code, err := Compile(program)
+	require.NoError(t, err)
+	assert.Equal(t, []objects.Object{
+		&objects.Integer{Value: 1},
+		&objects.Integer{Value: 2},
+	}, code.Constants)
+	assert.Equal(t, []string{"x"}, code.Names)
+
+	expected := `0000 OpCheck 0
+0003 OpPos 0
+0008 OpConstant 0
+0011 OpDefine 0 0
+0016 OpPos 11
+0021 OpGetLocal 0 0 0
+0028 OpConstOperand 1 1 1
+0034 OpBinary 1
+0037 OpResult
+0038 OpReturn 0
+`
+	assert.Equal(t, expected, code.Main.Instructions.String())
+}
+
+func TestCompileBlocks(t *testing.T) {
+	for input, expected := range map[string][]string{
+		// no closures: scopes are reused, and iterations share the loop variable
+		`for i := 0; i < 2; i++ { var j = i; j++ }`: {"OpPushScope 1 1", "OpPushScope 1 1"},
+
+		// closures capture scopes and each iteration's variable
+		`for i := 0; i < 2; i++ { var f = func() { println(i) }; f() }`: {"OpPushScope 1 0", "OpPushScope 1 0", "OpCopyScope"},
+
+		// only blocks containing the closure are captured
+		`if true { var j = 1; _ = j }; if true { var f = func() {}; f() }`: {"OpPushScope 1 1", "OpPushScope 1 0"},
+	} {
+		t.Run(input, func(t *testing.T) {
+			s, err := scanner.New(input, nil)
+			require.NoError(t, err)
+			p := parser.New(s, nil)
+			program := p.ParseProgram()
+			require.Nil(t, p.Errors())
+
+			code, err := Compile(program)
+			require.NoError(t, err)
+
+			var actual []string
+			for _, line := range strings.Split(code.Main.Instructions.String(), "\n") {
+				if i := strings.Index(line, " Op"); i >= 0 && strings.Contains(line, "Scope") && !strings.Contains(line, "OpPopScope") {
+					actual = append(actual, line[i+1:])
+				}
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package gosh embeds Gosh into Go programs.
+//
+// Runtime runs programs and evaluates expressions in its own global scope.
+// Its options configure standard streams, arguments, environment variables, filesystem, globals, limits and modules.
+// By default, programs have no access to the host: standard input is empty, and output is discarded.
+//
+// Programs can import modules: Gosh files relative to the importing file (import "./lib/util.gosh"),
+// Gosh modules found in WithModulePath directories, Go packages added with WithModules, and registered Go packages.
+// Modules are loaded once per runtime; see Runtime.Import.
+// Package fmt is built in: it prints to the runtime's standard output.
+// Package gosh-lang.org/gosh/stdlib registers bindings for other commonly used standard library packages.
+//
+// Go `package main` programs can be compiled with CompileGo: their init functions and main function are called
+// like `go run` does.
+//
+// Programs are compiled for the virtual machine. A compiled program can be run by many runtimes concurrently.
+//
+// Small example:
+//	rt := gosh.New(gosh.WithStdout(os.Stdout), gosh.WithGlobals(map[string]interface{}{
+//		"name": "world",
+//	}))
+//	if err := rt.Run(context.Background(), `println("Hello, " + name + "!")`); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Go values are converted with objects.FromGo. Programs call Go functions (assigning multiple results
+// like `n, err := atoi(s)`), index Go maps, and select exported fields and methods of Go structs:
+//	rt.Set("atoi", strconv.Atoi)
+//	rt.Set("builder", func() *strings.Builder { return new(strings.Builder) })
+//	err := rt.Run(ctx, `n, err := atoi("41"); b := builder(); b.WriteString("x"); println(n+1, err, b.Len())`)
+//
+// For more control use subpackages:
+//  * https://godoc.org/gosh-lang.org/gosh/tokens
+//  * https://godoc.org/gosh-lang.org/gosh/scanner
+//  * https://godoc.org/gosh-lang.org/gosh/ast
+//  * https://godoc.org/gosh-lang.org/gosh/parser
+//  * https://godoc.org/gosh-lang.org/gosh/objects
+//  * https://godoc.org/gosh-lang.org/gosh/resolver
+//  * https://godoc.org/gosh-lang.org/gosh/optimizer
+//  * https://godoc.org/gosh-lang.org/gosh/interpreter
+//  * https://godoc.org/gosh-lang.org/gosh/compiler
+//  * https://godoc.org/gosh-lang.org/gosh/vm
+//  * https://godoc.org/gosh-lang.org/gosh/bind
+//  * https://godoc.org/gosh-lang.org/gosh/stdlib
+//
+// Command gosh is https://godoc.org/gosh-lang.org/gosh/cmd/gosh.
+package gosh // import "gosh-lang.org/gosh"
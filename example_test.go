@@ -0,0 +1,98 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"gosh-lang.org/gosh"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+)
+
+func Example() {
+	rt := gosh.New(gosh.WithStdout(os.Stdout), gosh.WithGlobals(map[string]interface{}{
+		"name": "world",
+	}))
+	if err := rt.Run(context.Background(), `var greeting = "Hello, " + name + "!"; println(greeting)`); err != nil {
+		log.Fatal(err)
+	}
+
+	res, err := rt.Eval(context.Background(), `len(greeting)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Eval result:", res)
+	// Output:
+	// Hello, world!
+	// Eval result: 13
+}
+
+// This example passes Go functions and values to programs.
+func Example_goValues() {
+	rt := gosh.New(gosh.WithStdout(os.Stdout))
+	rt.Set("atoi", strconv.Atoi)
+	rt.Set("ages", map[string]int{"gopher": 9})
+	rt.Set("builder", func() *strings.Builder { return new(strings.Builder) })
+
+	code := `
+		n, err := atoi("41")
+		println(n+1, err)
+		_, err = atoi("x")
+		println(err)
+
+		ages["gopher"]++
+		println(ages["gopher"], ages["none"])
+
+		b := builder()
+		b.WriteString("gopher")
+		println(b.String(), b.Len())
+	`
+	if err := rt.Run(context.Background(), code); err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 42 <nil>
+	// strconv.Atoi: parsing "x": invalid syntax
+	// 10 0
+	// gopher 6
+}
+
+// This example uses subpackages directly.
+func Example_packages() {
+	code := `println("Hello, world!")`
+
+	s, err := scanner.New(code, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	if p.Errors() != nil {
+		log.Fatal(p.Errors())
+	}
+
+	scope := objects.NewScope(objects.Builtin(os.Stdout))
+	i := interpreter.New(nil)
+	res, err := i.Eval(context.Background(), program, scope)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Eval result:", res)
+	// Output:
+	// Hello, world!
+	// Eval result: <nil>
+}
@@ -0,0 +1,37 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"gosh-lang.org/gosh/objects"
+)
+
+// fmtPackage returns bindings for formatting and printing functions of Go package fmt.
+// Print functions write to w, like print and println builtins, and return nothing.
+func fmtPackage(w io.Writer) *objects.GoPackage {
+	return &objects.GoPackage{
+		Path: "fmt",
+		Name: "fmt",
+		Values: map[string]reflect.Value{
+			"Errorf":   reflect.ValueOf(fmt.Errorf),
+			"Print":    reflect.ValueOf(func(a ...interface{}) { fmt.Fprint(w, a...) }),
+			"Printf":   reflect.ValueOf(func(format string, a ...interface{}) { fmt.Fprintf(w, format, a...) }),
+			"Println":  reflect.ValueOf(func(a ...interface{}) { fmt.Fprintln(w, a...) }),
+			"Sprint":   reflect.ValueOf(fmt.Sprint),
+			"Sprintf":  reflect.ValueOf(fmt.Sprintf),
+			"Sprintln": reflect.ValueOf(fmt.Sprintln),
+		},
+		Types: map[string]reflect.Type{
+			"Stringer": reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+		},
+	}
+}
@@ -1,15 +1,21 @@
 module gosh-lang.org/gosh
 
+go 1.23
+
 require (
-	github.com/alecthomas/template v0.0.0-20160405071501-a0175ee3bccc // indirect
-	github.com/alecthomas/units v0.0.0-20151022065526-2efee857e7cf // indirect
 	github.com/davecgh/go-spew v1.1.1
 	github.com/dvyukov/go-fuzz v0.0.0-20180902053217-4aff8368ef19
-	github.com/elazarl/go-bindata-assetfs v1.0.0 // indirect
 	github.com/peterh/liner v1.1.0
 	github.com/pmezard/go-difflib v1.0.0
-	github.com/stephens2424/writerset v0.0.0-20150719204953-fe01f9c9e73f // indirect
 	github.com/stretchr/testify v1.2.2
 	golang.org/x/tools v0.0.0-20181102223251-96e9e165b75e
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
+
+require (
+	github.com/alecthomas/template v0.0.0-20160405071501-a0175ee3bccc // indirect
+	github.com/alecthomas/units v0.0.0-20151022065526-2efee857e7cf // indirect
+	github.com/elazarl/go-bindata-assetfs v1.0.0 // indirect
+	github.com/mattn/go-runewidth v0.0.3 // indirect
+	github.com/stephens2424/writerset v0.0.0-20150719204953-fe01f9c9e73f // indirect
+)
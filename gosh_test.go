@@ -0,0 +1,399 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	_ "gosh-lang.org/gosh/stdlib"
+)
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	rt := New(WithStdout(&buf), WithGlobals(map[string]interface{}{
+		"x":      20,
+		"repeat": strings.Repeat,
+		"y":      &objects.Int8{Value: 1},
+	}))
+	ctx := context.Background()
+
+	require.NoError(t, rt.Run(ctx, `var z = x + 1; println(repeat("ab", 2), z, y)`))
+	assert.Equal(t, "abab 21 1\n", buf.String())
+
+	// globals are kept between runs, and can be changed by the host
+	z, ok := rt.Get("z")
+	require.True(t, ok)
+	assert.Equal(t, &objects.Integer{Value: 21}, z)
+	rt.Set("z", 41)
+	rt.Set("println", "shadowed")
+	res, err := rt.Eval(ctx, `z + 1`)
+	require.NoError(t, err)
+	assert.Equal(t, &objects.Integer{Value: 42}, res)
+	res, err = rt.Eval(ctx, `println`)
+	require.NoError(t, err)
+	assert.Equal(t, &objects.String{Value: "shadowed"}, res)
+
+	_, ok = rt.Get("w")
+	assert.False(t, ok)
+
+	// runtimes are isolated
+	_, ok = New().Get("z")
+	assert.False(t, ok)
+	err = New().Run(ctx, `println(x)`)
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, "undefined: x", err.(*interpreter.RuntimeError).Msg)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	rt := New()
+
+	err := rt.Run(ctx, `var x = ; var y = )`)
+	require.IsType(t, (*SyntaxError)(nil), err)
+	assert.Len(t, err.(*SyntaxError).Errors, 2)
+	assert.Contains(t, err.Error(), "(and 1 more errors)")
+
+	_, err = Compile("println(\x00)")
+	require.IsType(t, (*SyntaxError)(nil), err)
+	assert.Equal(t, "input contains NUL character (U+0000)", err.Error())
+
+	err = rt.Run(ctx, `panic("boom")`)
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, &objects.String{Value: "boom"}, err.(*interpreter.RuntimeError).Value)
+
+	_, err = rt.Eval(ctx, `1; 2`)
+	assert.EqualError(t, err, "expected single expression")
+	_, err = rt.Eval(ctx, `var x = 1`)
+	assert.EqualError(t, err, "var x = 1 is not an expression")
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, rt.Run(ctx, `for { }`))
+}
+
+func TestLimits(t *testing.T) {
+	rt := New(WithLimits(&interpreter.Config{MaxSteps: 1000}))
+	err := rt.Run(context.Background(), `for { }`)
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, interpreter.ErrMaxSteps, err.(*interpreter.RuntimeError).Err)
+}
+
+func TestHost(t *testing.T) {
+	// Go functions access the runtime from the context
+	readLine := &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+		s := bufio.NewScanner(FromContext(ctx).Stdin())
+		s.Scan()
+		return &objects.String{Value: s.Text()}
+	}}
+	arg := &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+		return &objects.String{Value: FromContext(ctx).Args()[args[0].(*objects.Integer).Value]}
+	}}
+	getenv := &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+		v, _ := FromContext(ctx).LookupEnv(args[0].(*objects.String).Value)
+		return &objects.String{Value: v}
+	}}
+	readFile := &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+		b, err := fs.ReadFile(FromContext(ctx).FS(), args[0].(*objects.String).Value)
+		if err != nil {
+			panic(err)
+		}
+		return &objects.String{Value: string(b)}
+	}}
+	warn := &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+		fmt.Fprintln(FromContext(ctx).Stderr(), args[0])
+		return nil
+	}}
+
+	var stdout, stderr bytes.Buffer
+	rt := New(
+		WithStdin(strings.NewReader("gopher\nignored\n")),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithArgs("prog", "-v"),
+		WithEnv([]string{"HOME=/root", "LANG=C", "HOME=/home/gopher"}),
+		WithFS(fstest.MapFS{"hello.txt": {Data: []byte("hello")}}),
+		WithGlobals(map[string]interface{}{
+			"readLine": readLine,
+			"arg":      arg,
+			"getenv":   getenv,
+			"readFile": readFile,
+			"warn":     warn,
+		}),
+	)
+	err := rt.Run(context.Background(), `println(readLine(), arg(1), getenv("HOME"), getenv("PATH") == "", readFile("hello.txt")); warn("done")`)
+	require.NoError(t, err)
+	assert.Equal(t, "gopher -v /home/gopher true hello\n", stdout.String())
+	assert.Equal(t, "done\n", stderr.String())
+	assert.Nil(t, FromContext(context.Background()))
+
+	// defaults
+	rt = New()
+	assert.Equal(t, []string(nil), rt.Args())
+	assert.Nil(t, rt.FS())
+	n, _ := rt.Stdin().Read(make([]byte, 1))
+	assert.Zero(t, n)
+}
+
+func TestModules(t *testing.T) {
+	m := &objects.GoPackage{
+		Path:   "example.com/greet",
+		Name:   "greet",
+		Values: map[string]reflect.Value{"Hello": reflect.ValueOf("hello")},
+	}
+	rt := New(WithModules(m))
+	assert.True(t, rt.Module("example.com/greet") == m)
+	assert.Nil(t, New().Module("example.com/greet"))
+	assert.Nil(t, rt.Module("example.com/other"))
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"app/main.gosh":     {Data: []byte("import (\"./lib/util\"; \"text\"; \"example.com/greet\")\nprintln(util.Twice(text.Upper(greet.Hello)))\n")},
+		"app/lib/util.gosh": {Data: []byte("package util\nimport \"../counter.gosh\"\nvar Twice = func(s) { counter.Inc(); return s + s }\n")},
+		"app/counter.gosh":  {Data: []byte("package counter\nprintln(\"init counter\")\nvar N = 0\nvar Inc = func() { N++ }\n")},
+		"path/text.gosh":    {Data: []byte("package text\nvar Upper = func(s) { return s + \"!\" }\nvar lower = 1\n")},
+		"app/a.gosh":        {Data: []byte("import \"./b\"\n")},
+		"app/b.gosh":        {Data: []byte("import \"./a\"\n")},
+		"app/prog.gosh":     {Data: []byte("package main\n")},
+		"app/fail.gosh":     {Data: []byte("var x = 0\nvar y = 1 / x\n")},
+		"app/bad.gosh":      {Data: []byte("var = 1\n")},
+	}
+	greet := &objects.GoPackage{
+		Path:   "example.com/greet",
+		Name:   "greet",
+		Values: map[string]reflect.Value{"Hello": reflect.ValueOf("hello")},
+	}
+
+	var buf bytes.Buffer
+	rt := New(WithStdout(&buf), WithFS(fsys), WithModulePath("missing", "path"), WithModules(greet))
+	require.NoError(t, rt.RunFile(ctx, "app/main.gosh"))
+	assert.Equal(t, "init counter\nhello!hello!\n", buf.String())
+
+	// modules are loaded once
+	buf.Reset()
+	require.NoError(t, rt.Run(ctx, `import ("./app/counter"; "./app/lib/util"); util.Twice(""); println(counter.N)`))
+	assert.Equal(t, "2\n", buf.String())
+
+	for src, expected := range map[string]string{
+		`import "./app/a"`:          "app/b.gosh:1:8: could not import ./a (import cycle not allowed: app/a.gosh -> app/b.gosh -> app/a.gosh)",
+		`import "./app/prog"`:       `1:8: could not import ./app/prog (import "./app/prog" is a program, not an importable package)`,
+		`import "./app/bad"`:        "1:8: could not import ./app/bad (app/bad.gosh: expected next token to be IDENTIFIER, got [ 4: ASSIGNMENT = ] instead (and 1 more errors))",
+		`import "../x"`:             "1:8: could not import ../x (invalid import path)",
+		`import "other"`:            "1:8: could not import other (module not found)",
+		`import "text"; text.lower`: "1:16: cannot refer to unexported name text.lower",
+	} {
+		t.Run(src, func(t *testing.T) {
+			err := rt.Run(ctx, src)
+			require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+			assert.Equal(t, expected, err.Error())
+		})
+	}
+
+	err := rt.Run(ctx, `import "./app/fail"`)
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, "app/fail.gosh:2:1: runtime error: integer divide by zero", err.Error())
+	assert.Equal(t, []interpreter.Frame{{Function: "main", File: "app/fail.gosh", Offset: 10}}, err.(*interpreter.RuntimeError).Stack)
+
+	err = New().Run(ctx, `import "./util"`)
+	assert.EqualError(t, err, "1:8: could not import ./util (no filesystem)")
+	assert.EqualError(t, New().RunFile(ctx, "main.gosh"), "no filesystem")
+}
+
+func TestPackages(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"tool/a.gosh":        {Data: []byte("package main\nvar name = \"gopher\"\nfunc greeting() string { return prefix + name }\n")},
+		"tool/b.gosh":        {Data: []byte("package main\nfunc init() { println(\"init b\") }\nfunc main() { println(greeting(), count) }\n")},
+		"tool/c.gosh":        {Data: []byte("package main\nvar prefix = \"hello, \"\nvar count = 1\nfunc init() { println(\"init c1\"); count++ }\nfunc init() { println(\"init c2\") }\n")},
+		"tool/c_test.gosh":   {Data: []byte("this is not Gosh\n")},
+		"tool/notes.txt":     {Data: []byte("not Gosh either\n")},
+		"tool/sub/x.gosh":    {Data: []byte("package sub\n")},
+		"lib/strutil/a.gosh": {Data: []byte("package strutil\nfunc Twice(s string) string { return join(s, s) }\n")},
+		"lib/strutil/b.gosh": {Data: []byte("package strutil\nfunc join(a, b string) string { return a + b }\n")},
+		"order/a.gosh":       {Data: []byte("package main\nvar total = base * count\nfunc main() { println(total, base, count) }\n")},
+		"order/b.gosh":       {Data: []byte("package main\nvar base = double(count)\n")},
+		"order/c.gosh":       {Data: []byte("package main\nvar count = 3\nfunc double(n int) int { return n * 2 }\n")},
+		"mixed/a.gosh":       {Data: []byte("package a\n")},
+		"mixed/b.gosh":       {Data: []byte("package b\n")},
+		"fail/a.gosh":        {Data: []byte("func main() { fail() }\n")},
+		"fail/b.gosh":        {Data: []byte("func fail() { panic(\"failed\") }\nmain()\n")},
+		"tests/a_test.gosh":  {Data: []byte("println(1)\n")},
+	}
+
+	var buf bytes.Buffer
+	rt := New(WithStdout(&buf), WithFS(fsys))
+	require.NoError(t, rt.RunFile(ctx, "tool"))
+	assert.Equal(t, "init b\ninit c1\ninit c2\nhello, gopher 2\n", buf.String())
+
+	// variables are initialized in dependency order across files
+	buf.Reset()
+	require.NoError(t, New(WithStdout(&buf), WithFS(fsys)).RunFile(ctx, "order"))
+	assert.Equal(t, "18 6 3\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, rt.Run(ctx, `import "./lib/strutil"; println(strutil.Twice("ab"))`))
+	assert.Equal(t, "abab\n", buf.String())
+
+	// packages are run in the runtime's global scope
+	err := New(WithFS(fsys)).RunFile(ctx, "fail")
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, "fail/b.gosh:1:20: failed", err.Error())
+	assert.Equal(t, []interpreter.Frame{
+		{Function: "fail", File: "fail/b.gosh", Offset: 19},
+		{Function: "main", File: "fail/a.gosh", Offset: 18},
+		{Function: "main", File: "fail/b.gosh", Offset: 36},
+	}, err.(*interpreter.RuntimeError).Stack)
+
+	assert.EqualError(t, rt.RunFile(ctx, "mixed"), "found packages a (a.gosh) and b (b.gosh)")
+	assert.EqualError(t, rt.RunFile(ctx, "tests"), "no Gosh files in tests")
+}
+
+func TestGoPrograms(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "go", "*.go"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		file := file
+		name := filepath.Base(file)
+		t.Run(name, func(t *testing.T) {
+			expected, err := ioutil.ReadFile(strings.TrimSuffix(file, ".go") + ".out")
+			require.NoError(t, err)
+
+			// programs with .err file are expected to panic with that message
+			expectedPanic, err := ioutil.ReadFile(strings.TrimSuffix(file, ".go") + ".err")
+			if !os.IsNotExist(err) {
+				require.NoError(t, err)
+			}
+
+			var buf bytes.Buffer
+			rt := New(WithStdout(&buf), WithFS(os.DirFS(filepath.Dir(file))))
+			err = rt.RunFile(context.Background(), name)
+			if expectedPanic == nil {
+				require.NoError(t, err)
+			} else {
+				require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+				assert.Equal(t, string(expectedPanic), "panic: "+err.(*interpreter.RuntimeError).Msg+"\n")
+			}
+			assert.Equal(t, string(expected), buf.String())
+
+			// check that expected output is still the one of the real Go
+			if testing.Short() {
+				return
+			}
+			if _, err = exec.LookPath("go"); err != nil {
+				t.Skip(err)
+			}
+			b, err := exec.Command("go", "run", file).Output() //nolint:gosec
+			if expectedPanic == nil {
+				require.NoError(t, err)
+			} else {
+				require.IsType(t, (*exec.ExitError)(nil), err)
+				stderr := string(err.(*exec.ExitError).Stderr)
+				assert.Equal(t, string(expectedPanic), stderr[:strings.Index(stderr, "\n")+1])
+			}
+			assert.Equal(t, string(expected), string(b))
+		})
+	}
+}
+
+func TestGoProgramsErrors(t *testing.T) {
+	for src, expected := range map[string]string{
+		`func main() {}`:                                             "missing package clause",
+		`package util; func main() {}`:                               "package util is not a main package",
+		`package main; func f() {}`:                                  "function main is undeclared in the main package",
+		`package main; func main() {}; println("x")`:                 `non-declaration statement outside function body: println("x")`,
+		`package main; func main(args) {}`:                           "func main must have no arguments and no return values",
+		`package main; func init() int { return 0 }; func main() {}`: "func init must have no arguments and no return values",
+	} {
+		t.Run(src, func(t *testing.T) {
+			_, err := CompileGo(src)
+			require.IsType(t, (*SyntaxError)(nil), err)
+			assert.EqualError(t, err, expected)
+		})
+	}
+
+	// init functions are not declared
+	p, err := CompileGo(`package main; func init() {}; func main() { init() }`)
+	require.NoError(t, err)
+	err = New().RunProgram(context.Background(), p)
+	require.IsType(t, (*interpreter.RuntimeError)(nil), err)
+	assert.Equal(t, "undefined: init", err.(*interpreter.RuntimeError).Msg)
+}
+
+func TestShadowedPredeclared(t *testing.T) {
+	p, err := Compile(`println(len("ab"))`)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// the program is resolved once, but the result depends on the scope it is run in
+	for _, shadow := range []bool{false, true, false} {
+		var buf bytes.Buffer
+		rt := New(WithStdout(&buf))
+		expected := "2\n"
+		if shadow {
+			rt.Set("len", strings.ToUpper)
+			expected = "AB\n"
+		}
+		require.NoError(t, rt.RunProgram(ctx, p))
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	p, err := Compile(`
+var sum = 0
+var f = func(n) { return n * k }
+for i := 0; i < 100; i++ {
+	sum += f(i)
+}
+var ch = make(chan int)
+go func() { ch <- sum }()
+println(<-ch)
+`)
+	require.NoError(t, err)
+
+	const n = 10
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rt := New(WithStdout(&outputs[i]), WithGlobals(map[string]interface{}{"k": i}))
+			errs[i] = rt.RunProgram(context.Background(), p)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, fmt.Sprintf("%d\n", 4950*i), outputs[i].String())
+	}
+}
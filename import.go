@@ -0,0 +1,246 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/pkgdir"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/vm"
+)
+
+// moduleExt is the file name extension of Gosh modules.
+const moduleExt = pkgdir.Ext
+
+// WithModulePath sets directories of runtime's filesystem searched for Gosh modules, like GOPATH.
+// Import path "util/strings" is resolved to the first existing file "<dir>/util/strings.gosh",
+// or package directory "<dir>/util/strings"; see RunFile.
+func WithModulePath(dirs ...string) Option {
+	return func(rt *Runtime) { rt.modulePath = dirs }
+}
+
+// RunFile reads Gosh program from the named file of runtime's filesystem and runs it like Run does.
+// Relative imports of that program are resolved relative to that file's directory.
+// Go programs (files with .go extension) are run in compatibility mode; see CompileGo.
+//
+// If name is a directory, all Gosh files in it (except _test.gosh files) are run as one package
+// in the runtime's global scope. Files are run in file name order in phases: first imports, types
+// and functions of all files are declared, then other global statements are run, then init functions
+// of each file are called, then main function of package main is called if it is declared.
+// Functions can use names declared by any file of the package.
+func (rt *Runtime) RunFile(ctx context.Context, name string) error {
+	if rt.fsys == nil {
+		return errors.New("no filesystem")
+	}
+	if fi, err := fs.Stat(rt.fsys, name); err == nil && fi.IsDir() {
+		programs, err := rt.parseDir(name)
+		if err != nil {
+			return err
+		}
+		for _, program := range programs {
+			p, err := compile(program)
+			if err != nil {
+				return err
+			}
+			if err = rt.RunProgram(ctx, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	b, err := fs.ReadFile(rt.fsys, name)
+	if err != nil {
+		return err
+	}
+	p, err := compileFile(string(b), name, path.Ext(name) == ".go")
+	if err != nil {
+		return err
+	}
+	return rt.RunProgram(ctx, p)
+}
+
+// parseFile reads and parses Gosh file name of runtime's filesystem.
+func (rt *Runtime) parseFile(name string) (*ast.Program, error) {
+	b, err := fs.ReadFile(rt.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	program, err := parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	program.File = name
+	return program, nil
+}
+
+// parseDir reads and parses Gosh package in directory dir of runtime's filesystem,
+// and returns programs that run it in order; see pkgdir.Programs.
+func (rt *Runtime) parseDir(dir string) ([]*ast.Program, error) {
+	names, err := pkgdir.Files(rt.fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*ast.Program, len(names))
+	for n, name := range names {
+		if files[n], err = rt.parseFile(name); err != nil {
+			return nil, err
+		}
+	}
+	programs, err := pkgdir.Programs(files)
+	if err != nil {
+		return nil, &SyntaxError{Errors: []error{err}}
+	}
+	return programs, nil
+}
+
+// Import implements interpreter.Importer, so runtime can also import modules for interpreters.
+//
+// Import paths starting with "./" or "../" are Gosh module files relative to the importing file's directory
+// (or to the root of runtime's filesystem for programs without files); ".gosh" extension may be omitted.
+// If there is no such file, a directory with that name is imported as multi-file package; see RunFile.
+// Other import paths are modules added with WithModules, built-in package fmt, registered Go packages,
+// and Gosh modules found in WithModulePath directories, in that order.
+//
+// Each module is loaded once per runtime: its global statements are run in its own global scope at the first import.
+// Import cycles are not allowed.
+func (rt *Runtime) Import(ctx context.Context, importPath, file string) (*objects.Module, error) {
+	if !strings.HasPrefix(importPath, "./") && !strings.HasPrefix(importPath, "../") {
+		if p := rt.Module(importPath); p != nil {
+			if m := rt.imported[importPath]; m != nil {
+				return m, nil
+			}
+			m := objects.NewGoModule(p)
+			rt.imported[importPath] = m
+			return m, nil
+		}
+	}
+
+	name, err := rt.findModule(importPath, file)
+	if err != nil {
+		return nil, err
+	}
+	if m := rt.imported[name]; m != nil {
+		return m, nil
+	}
+
+	for n, l := range rt.loading {
+		if l == name {
+			cycle := append(rt.loading[n:len(rt.loading):len(rt.loading)], name)
+			return nil, fmt.Errorf("import cycle not allowed: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	rt.loading = append(rt.loading, name)
+	defer func() { rt.loading = rt.loading[:len(rt.loading)-1] }()
+
+	m, err := rt.loadModule(ctx, importPath, name)
+	if err != nil {
+		return nil, err
+	}
+	rt.imported[name] = m
+	return m, nil
+}
+
+// findModule returns the name of Gosh module file or package directory in runtime's filesystem
+// for import path imported from file.
+func (rt *Runtime) findModule(importPath, file string) (string, error) {
+	if rt.fsys == nil {
+		return "", errors.New("no filesystem")
+	}
+
+	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		name := path.Join(path.Dir(file), importPath)
+		if !fs.ValidPath(name) {
+			return "", errors.New("invalid import path")
+		}
+		name, _ = rt.statModule(name)
+		return name, nil
+	}
+
+	if !fs.ValidPath(importPath) {
+		return "", errors.New("invalid import path")
+	}
+	for _, dir := range rt.modulePath {
+		if name, ok := rt.statModule(path.Join(dir, importPath)); ok {
+			return name, nil
+		}
+	}
+	return "", errors.New("module not found")
+}
+
+// statModule returns the name of Gosh module file for name with optional ".gosh" extension,
+// or the name of package directory if there is no such file, and true if it exists.
+func (rt *Runtime) statModule(name string) (string, bool) {
+	if strings.HasSuffix(name, moduleExt) {
+		_, err := fs.Stat(rt.fsys, name)
+		return name, err == nil
+	}
+
+	if _, err := fs.Stat(rt.fsys, name+moduleExt); err == nil {
+		return name + moduleExt, true
+	}
+	if fi, err := fs.Stat(rt.fsys, name); err == nil && fi.IsDir() {
+		return name, true
+	}
+	return name + moduleExt, false
+}
+
+// loadModule reads, compiles and runs Gosh module file or package directory name imported with given path.
+// Modules have limits of their own, and goroutines started by module's global statements are stopped when they end.
+func (rt *Runtime) loadModule(ctx context.Context, importPath, name string) (*objects.Module, error) {
+	var programs []*ast.Program
+	if strings.HasSuffix(name, moduleExt) {
+		program, err := rt.parseFile(name)
+		if err != nil {
+			return nil, err
+		}
+		programs = []*ast.Program{program}
+	} else {
+		var err error
+		if programs, err = rt.parseDir(name); err != nil {
+			return nil, err
+		}
+	}
+
+	pkg := strings.TrimSuffix(path.Base(name), moduleExt)
+	for _, program := range programs {
+		if program.Package != nil {
+			pkg = program.Package.Value
+			break
+		}
+	}
+	if pkg == "main" {
+		return nil, fmt.Errorf("import %q is a program, not an importable package", importPath)
+	}
+
+	scope := objects.NewScope(objects.Builtin(rt.stdout))
+	ctx = context.WithValue(ctx, runtimeKey{}, rt)
+	for _, program := range programs {
+		p, err := compile(program)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = vm.New(rt.config).Run(ctx, p.code, scope); err != nil {
+			return nil, err
+		}
+	}
+	return &objects.Module{Path: importPath, Name: pkg, Scope: scope}, nil
+}
+
+// check interfaces
+var (
+	_ interpreter.Importer = (*Runtime)(nil)
+)
@@ -0,0 +1,80 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package compat implements Go compatibility mode: it turns Go `package main` programs into Gosh programs
+// that run like `go run` does.
+package compat // import "gosh-lang.org/gosh/internal/compat"
+
+import (
+	"errors"
+	"fmt"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/pkgdir"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Program checks that parsed program is a Go `package main` program with only declarations at the top level,
+// and reorders its statements to run like a Go program: imports, types and functions are declared first,
+// then global variables are initialized in dependency order (see pkgdir.Programs); then calls of init functions
+// (in order of appearance) and main function are added at the end.
+// Like in Go, init functions are not declared, and there may be several of them.
+func Program(program *ast.Program) error {
+	switch {
+	case program.Package == nil:
+		return errors.New("missing package clause")
+	case program.Package.Value != "main":
+		return fmt.Errorf("package %s is not a main package", program.Package.Value)
+	}
+
+	statements := make([]ast.Statement, 0, len(program.Statements)+2)
+	var others, inits []ast.Statement
+	var main *ast.VarStatement
+	functions := make(map[string]*ast.FunctionLiteral)
+	for _, s := range program.Statements {
+		switch s := s.(type) {
+		case *ast.ImportStatement, *ast.TypeStatement:
+			statements = append(statements, s)
+			continue
+
+		case *ast.VarStatement:
+			if s.Token.Type != tokens.Func {
+				others = append(others, s)
+				continue
+			}
+
+			lit := s.Value.(*ast.FunctionLiteral)
+			switch s.Name.Value {
+			case "init":
+				if err := pkgdir.CheckFunction(s); err != nil {
+					return err
+				}
+				inits = append(inits, pkgdir.Call(lit.Token, lit))
+				continue
+			case "main":
+				if err := pkgdir.CheckFunction(s); err != nil {
+					return err
+				}
+				main = s
+			}
+			functions[s.Name.Value] = lit
+			statements = append(statements, s)
+			continue
+		}
+
+		return fmt.Errorf("non-declaration statement outside function body: %s", s)
+	}
+	if main == nil {
+		return errors.New("function main is undeclared in the main package")
+	}
+
+	statements = append(statements, pkgdir.InitOrder(others, functions)...)
+	statements = append(statements, inits...)
+	statements = append(statements, pkgdir.Call(main.Token, main.Name))
+	program.Statements = statements
+	return nil
+}
@@ -0,0 +1,254 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"io/ioutil"
+	"runtime"
+	"sync/atomic"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/resolver"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// universe is the scope of predeclared entities used to resolve programs once.
+var universe = objects.Builtin(ioutil.Discard)
+
+// resolution is the result of resolving a program once.
+type resolution struct {
+	names       []string // names of top-level entities by slot
+	predeclared []string // names of predeclared entities used by the program
+	offset      int      // offset and message of the first error, if used entities are not shadowed
+	msg         string
+	exact       bool        // true if the result depends only on predeclared entities
+	shadowed    atomic.Bool // true if identifiers were resolved again in a scope shadowing predeclared entities
+}
+
+// resolve resolves identifiers of program and checks its constants once.
+func resolve(program *ast.Program) *resolution {
+	return program.Resolved(func() interface{} {
+		r := &resolution{
+			names: resolver.Globals(program),
+			// names declared by other files of the package are looked up in the scope
+			exact: program.Globals == nil,
+		}
+		r.offset, r.msg = check(program, universe)
+
+		seen := make(map[string]bool)
+		ast.Inspect(program, func(node ast.Node) bool {
+			if id, ok := node.(*ast.Identifier); ok && id.Predeclared && !seen[id.Value] {
+				seen[id.Value] = true
+				r.predeclared = append(r.predeclared, id.Value)
+			}
+			return true
+		})
+		return r
+	}).(*resolution)
+}
+
+// shadows returns true if scope declares any of given names of predeclared entities.
+func shadows(scope *objects.Scope, names []string) bool {
+	for s := scope; s != nil && s.Outer() != nil; s = s.Outer() {
+		for _, name := range names {
+			if _, ok := s.LookupLocal(name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resolve resolves identifiers of program once. Errors are reported by Check.
+func Resolve(program *ast.Program) {
+	resolve(program)
+}
+
+// Check resolves identifiers of program and checks its constants before it is executed in scope.
+// It returns the byte offset and the message of the first error, or an empty message.
+//
+// The program is resolved and checked once, and the result is reused if the program uses only predeclared entities
+// that are not shadowed in scope (that is checked on each call). Otherwise, it is resolved and checked again;
+// that doesn't modify it unless scope shadows predeclared entities, so that can be done concurrently.
+func Check(program *ast.Program, scope *objects.Scope) (offset int, msg string) {
+	r := resolve(program)
+	shadowed := shadows(scope, r.predeclared)
+	if r.exact && r.msg == "" && !shadowed && !r.shadowed.Load() {
+		return r.offset, r.msg
+	}
+
+	offset, msg = check(program, scope)
+	r.shadowed.Store(shadowed)
+	return
+}
+
+// check resolves identifiers of program in scope and checks its constants.
+func check(program *ast.Program, scope *objects.Scope) (offset int, msg string) {
+	errs, uses := resolver.ResolveUses(program, scope)
+	offset, msg = CheckConstants(program, scope, uses)
+	if len(errs) > 0 && (msg == "" || errs[0].Offset <= offset) {
+		return errs[0].Offset, errs[0].Msg
+	}
+	return
+}
+
+// ProgramScope returns the block scope of top-level entities of checked program nested in scope.
+func ProgramScope(program *ast.Program, scope *objects.Scope) *objects.Scope {
+	if program.Slots == 0 {
+		return scope
+	}
+	return objects.NewProgramScope(scope, resolve(program).names)
+}
+
+// CheckConstants checks constant expressions and constants used with typed variables, in conversions
+// to predeclared types, and as values of their default types before program is executed.
+// Variables are typed if they are declared with predeclared types; uses maps identifiers to declarations
+// as returned by resolver.ResolveUses. Types are looked up in scope.
+// It returns the byte offset and the message of the first error, or an empty message.
+//nolint:gocyclo
+func CheckConstants(program *ast.Program, scope *objects.Scope, uses map[*ast.Identifier]*ast.Identifier) (offset int, msg string) {
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(runtime.Error); ok {
+				panic(p)
+			}
+			err, ok := p.(error)
+			if !ok {
+				panic(p)
+			}
+			msg = err.Error()
+		}
+	}()
+
+	// typeOf returns predeclared basic type denoted by typeExp, or nil
+	typeOf := func(typeExp ast.Expression) *objects.TypeObject {
+		// local types are not known before execution
+		id, ok := typeExp.(*ast.Identifier)
+		if !ok || id.Local || id.Global {
+			return nil
+		}
+		obj, _ := scope.Lookup(id.Value)
+		t, ok := obj.(*objects.TypeObject)
+		if !ok || !t.Kind.IsBasic() {
+			return nil
+		}
+		return t
+	}
+
+	// declared types of variables by identifiers of declarations
+	types := make(map[*ast.Identifier]*objects.TypeObject)
+	ast.Inspect(program, func(node ast.Node) bool {
+		if v, ok := node.(*ast.VarStatement); ok && v.Type != nil {
+			if t := typeOf(v.Type); t != nil {
+				types[v.Name] = t
+			}
+		}
+		return true
+	})
+
+	// variableType returns declared numeric type of variable x, or nil
+	variableType := func(x ast.Expression) *objects.TypeObject {
+		id, ok := x.(*ast.Identifier)
+		if !ok {
+			return nil
+		}
+		t := types[uses[id]]
+		if t == nil || !t.Kind.IsNumeric() {
+			return nil
+		}
+		return t
+	}
+
+	// constant checks that constant exp can be used as a value of type t
+	constant := func(exp ast.Expression, t *objects.TypeObject) {
+		if t == nil || !IsConstant(exp) {
+			return
+		}
+		if _, msg := ConstantTo(exp, t); msg != "" {
+			crash("%s", msg)
+		}
+	}
+
+	// untyped checks that constant exp can be used as a value of its default type
+	untyped := func(exp ast.Expression) {
+		if IsConstant(exp) {
+			Untyped(exp)
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		// report errors at the statement being checked
+		if s, ok := node.(ast.Statement); ok {
+			offset = StatementOffset(s)
+		}
+
+		switch node := node.(type) {
+		case *ast.VarStatement:
+			switch {
+			case node.Value == nil:
+				// nothing
+			case node.Type == nil:
+				untyped(node.Value)
+			default:
+				if t := typeOf(node.Type); t != nil && t.Kind.IsNumeric() {
+					constant(node.Value, t)
+				}
+			}
+
+		case *ast.AssignStatement:
+			switch {
+			case node.Rest != nil:
+				// nothing
+			case node.Token.Type == tokens.Define:
+				untyped(node.Value)
+			case node.Token.Type == tokens.Assignment || !isShift(CompoundExpression(node).Token.Type):
+				constant(node.Value, variableType(node.Name))
+			}
+
+		case *ast.InfixExpression:
+			if IsConstant(node) {
+				EvalConstant(node)
+				return false
+			}
+			if !isShift(node.Token.Type) {
+				constant(node.Right, variableType(node.Left))
+				constant(node.Left, variableType(node.Right))
+			}
+
+		case *ast.PrefixExpression:
+			if IsConstant(node) {
+				EvalConstant(node)
+				return false
+			}
+
+		case *ast.CallExpression:
+			if id, ok := node.Function.(*ast.Identifier); ok && id.Predeclared && (id.Value == "print" || id.Value == "println") {
+				for _, a := range node.Arguments {
+					untyped(a)
+				}
+				break
+			}
+			if len(node.Arguments) == 1 {
+				if t := typeOf(node.Function); t != nil && IsConstantConversion(node.Arguments[0], t) {
+					if _, msg := ConvertConstant(node.Arguments[0], t); msg != "" {
+						offset = node.Token.Offset
+						crash("%s", msg)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return
+}
+
+// isShift returns true for shift operators.
+func isShift(op tokens.Type) bool {
+	return op == tokens.ShiftLeft || op == tokens.ShiftRight
+}
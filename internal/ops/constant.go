@@ -0,0 +1,410 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+	"go/constant"
+	"go/token"
+	"math"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// IsConstant returns true if expression is a constant expression:
+// a literal, an unary or binary operation on constant expressions,
+// or a call of predeclared complex function with constant arguments.
+func IsConstant(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.CallExpression:
+		return isComplexConstant(exp)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.ImaginaryLiteral, *ast.RuneLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.Constant:
+		return true
+	case *ast.PrefixExpression:
+		return IsConstant(exp.Right)
+	case *ast.InfixExpression:
+		return IsConstant(exp.Left) && IsConstant(exp.Right)
+	default:
+		return false
+	}
+}
+
+// constantKinds orders kinds of untyped numeric constants: a binary operation on constants of different kinds
+// uses the kind that appears later in this list.
+var constantKinds = map[objects.Type]int{
+	objects.IntegerType: 1,
+	objects.Int32Type:   2, // rune
+	objects.FloatType:   3,
+	objects.ComplexType: 4,
+}
+
+// UntypedName returns the name of untyped constant kind as it is written in Go compiler messages.
+func UntypedName(k objects.Type) string {
+	switch k {
+	case objects.Int32Type:
+		return "untyped rune"
+	case objects.FloatType:
+		return "untyped float"
+	case objects.ComplexType:
+		return "untyped complex"
+	default:
+		return "untyped " + k.Name()
+	}
+}
+
+// EvalConstant evaluates constant expression exactly, as Go compiler does.
+// It returns the value and its kind, which is also the default type of the constant:
+// IntegerType, Int32Type (for runes), FloatType, ComplexType, StringType or BooleanType.
+//nolint:gocyclo
+func EvalConstant(exp ast.Expression) (constant.Value, objects.Type) {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return literal(exp.Exact, exp.Token.Literal, token.INT), objects.IntegerType
+	case *ast.FloatLiteral:
+		return literal(exp.Exact, exp.Token.Literal, token.FLOAT), objects.FloatType
+	case *ast.ImaginaryLiteral:
+		return literal(exp.Exact, exp.Token.Literal, token.IMAG), objects.ComplexType
+	case *ast.RuneLiteral:
+		if exp.Exact != nil {
+			return exp.Exact, objects.Int32Type
+		}
+		return constant.MakeInt64(int64(exp.Value)), objects.Int32Type
+	case *ast.StringLiteral:
+		return constant.MakeString(exp.Value), objects.StringType
+	case *ast.BooleanLiteral:
+		return constant.MakeBool(exp.Value), objects.BooleanType
+	case *ast.Constant:
+		return exp.Value, constantKind(exp)
+
+	case *ast.PrefixExpression:
+		v, k := EvalConstant(exp.Right)
+		switch {
+		case exp.Token.Literal == "+" && k.IsNumeric():
+			return constant.UnaryOp(token.ADD, v, 0), k
+		case exp.Token.Literal == "-" && k.IsNumeric():
+			return constant.UnaryOp(token.SUB, v, 0), k
+		case exp.Token.Literal == "^" && k.IsInteger():
+			return constant.UnaryOp(token.XOR, v, 0), k
+		case exp.Token.Literal == "!" && k == objects.BooleanType:
+			return constant.UnaryOp(token.NOT, v, 0), k
+		}
+		crash("invalid operation: operator %s not defined on %s (%s constant)", exp.Token.Literal, exp.Right, UntypedName(k))
+
+	case *ast.InfixExpression:
+		return constantInfix(exp)
+
+	case *ast.CallExpression:
+		if isComplexConstant(exp) {
+			return constantComplex(exp), objects.ComplexType
+		}
+	}
+
+	crash("%s is not a constant", exp)
+	panic("not reached")
+}
+
+// literal returns exact value of numeric literal: the value stored by parser,
+// or the parsed literal lit of kind tok for nodes constructed without it.
+func literal(exact constant.Value, lit string, tok token.Token) constant.Value {
+	if exact != nil {
+		return exact
+	}
+	return constant.MakeFromLiteral(lit, tok, 0)
+}
+
+// isComplexConstant returns true if exp is a call of predeclared complex function with constant arguments.
+func isComplexConstant(exp *ast.CallExpression) bool {
+	id, ok := exp.Function.(*ast.Identifier)
+	if !ok || !id.Predeclared || id.Value != "complex" || len(exp.Arguments) != 2 {
+		return false
+	}
+	return IsConstant(exp.Arguments[0]) && IsConstant(exp.Arguments[1])
+}
+
+// constantComplex evaluates call of predeclared complex function with constant arguments.
+// The result is an untyped complex constant.
+func constantComplex(exp *ast.CallExpression) constant.Value {
+	var parts [2]constant.Value
+	for i, arg := range exp.Arguments {
+		v, k := EvalConstant(arg)
+		if !k.IsNumeric() {
+			crash("invalid operation: %s (arguments have type %s, expected floating-point)", exp, UntypedName(k))
+		}
+		if parts[i] = constant.ToFloat(v); parts[i].Kind() != constant.Float {
+			crash("%s (%s constant) truncated to float64", arg, UntypedName(k))
+		}
+	}
+	return constant.BinaryOp(parts[0], token.ADD, constant.MakeImag(parts[1]))
+}
+
+// constantKind returns the kind of constant node.
+func constantKind(exp *ast.Constant) objects.Type {
+	switch exp.Value.Kind() {
+	case constant.Bool:
+		return objects.BooleanType
+	case constant.String:
+		return objects.StringType
+	case constant.Int:
+		if exp.Rune {
+			return objects.Int32Type
+		}
+		return objects.IntegerType
+	case constant.Float:
+		return objects.FloatType
+	case constant.Complex:
+		return objects.ComplexType
+	}
+	crash("%s is not a constant", exp)
+	panic("not reached")
+}
+
+// MakeConstant evaluates constant expression exp exactly and returns a constant node with its value.
+func MakeConstant(exp ast.Expression) *ast.Constant {
+	v, k := EvalConstant(exp)
+	switch k {
+	case objects.FloatType:
+		v = constant.ToFloat(v)
+	case objects.ComplexType:
+		v = constant.ToComplex(v)
+	}
+	return &ast.Constant{X: exp, Value: v, Rune: k == objects.Int32Type}
+}
+
+// constantOperators maps Gosh binary operators to Go tokens for constant arithmetic and comparisons.
+var constantOperators = map[string]token.Token{
+	"+":  token.ADD,
+	"-":  token.SUB,
+	"*":  token.MUL,
+	"/":  token.QUO,
+	"%":  token.REM,
+	"&":  token.AND,
+	"|":  token.OR,
+	"^":  token.XOR,
+	"&^": token.AND_NOT,
+	"<<": token.SHL,
+	">>": token.SHR,
+	"&&": token.LAND,
+	"||": token.LOR,
+	"==": token.EQL,
+	"!=": token.NEQ,
+	"<":  token.LSS,
+	"<=": token.LEQ,
+	">":  token.GTR,
+	">=": token.GEQ,
+}
+
+// constantInfix evaluates binary operation on untyped constants exactly.
+func constantInfix(exp *ast.InfixExpression) (constant.Value, objects.Type) {
+	l, lk := EvalConstant(exp.Left)
+	r, rk := EvalConstant(exp.Right)
+	operator := exp.Token.Literal
+	op, ok := constantOperators[operator]
+	if !ok {
+		crash("unhandled infix expression operator %s", operator)
+	}
+
+	if op == token.SHL || op == token.SHR {
+		return constantShift(exp, op, l, lk, r, rk), lk
+	}
+
+	// numeric constants of different kinds use the "larger" kind
+	k := lk
+	if lk != rk {
+		if !lk.IsNumeric() || !rk.IsNumeric() {
+			crash("invalid operation: %s (mismatched types %s and %s)", exp, UntypedName(lk), UntypedName(rk))
+		}
+		if constantKinds[rk] > constantKinds[lk] {
+			k = rk
+		}
+	}
+
+	switch op {
+	case token.EQL, token.NEQ:
+		return constant.MakeBool(constant.Compare(l, op, r)), objects.BooleanType
+
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if k.IsNumeric() && !k.IsComplex() || k == objects.StringType {
+			return constant.MakeBool(constant.Compare(l, op, r)), objects.BooleanType
+		}
+
+	case token.LAND, token.LOR:
+		if k == objects.BooleanType {
+			return constant.BinaryOp(l, op, r), k
+		}
+
+	case token.QUO, token.REM:
+		if !k.IsNumeric() || op == token.REM && !k.IsInteger() {
+			break
+		}
+		if constant.Sign(r) == 0 {
+			crash("invalid operation: division by zero")
+		}
+		if k.IsInteger() && op == token.QUO {
+			// integer division
+			op = token.QUO_ASSIGN
+		}
+		return constant.BinaryOp(l, op, r), k
+
+	case token.AND, token.OR, token.XOR, token.AND_NOT:
+		if k.IsInteger() {
+			return constant.BinaryOp(l, op, r), k
+		}
+
+	case token.ADD:
+		if k.IsNumeric() || k == objects.StringType {
+			return constant.BinaryOp(l, op, r), k
+		}
+
+	default:
+		if k.IsNumeric() {
+			return constant.BinaryOp(l, op, r), k
+		}
+	}
+
+	crash("invalid operation: operator %s not defined on %s (%s constant)", operator, exp.Left, UntypedName(k))
+	panic("not reached")
+}
+
+// maxConstantShift is the largest shift count of constant shift expressions.
+const maxConstantShift = 10000
+
+// constantShift evaluates shift of untyped integer constant l by untyped constant count r.
+func constantShift(exp *ast.InfixExpression, op token.Token, l constant.Value, lk objects.Type, r constant.Value, rk objects.Type) constant.Value {
+	if !lk.IsInteger() {
+		crash("invalid operation: shifted operand %s (%s constant) must be integer", exp.Left, UntypedName(lk))
+	}
+	count := constant.ToInt(r)
+	if count.Kind() != constant.Int {
+		crash("invalid operation: shift count %s (%s constant) must be integer", exp.Right, UntypedName(rk))
+	}
+	n, exact := constant.Uint64Val(count)
+	if !exact || n > maxConstantShift {
+		crash("invalid shift count %s", exp.Right)
+	}
+	return constant.Shift(l, op, uint(n))
+}
+
+// ConstantToType converts untyped constant value v to basic type t.
+// It returns nil object and empty message if constant of that kind can't be converted to t at all,
+// and nil object and Go compiler message if value can't be represented by t.
+//nolint:gocyclo
+func ConstantToType(v constant.Value, t objects.Type) (objects.Object, string) {
+	numeric := v.Kind() == constant.Int || v.Kind() == constant.Float || v.Kind() == constant.Complex
+	// messages are formatted only on failure
+	overflows := func() string { return fmt.Sprintf("constant %s overflows %s", v, t.Name()) }
+	truncatedToReal := func() string { return fmt.Sprintf("constant %s truncated to real", v) }
+
+	switch {
+	case t.IsInteger() && numeric:
+		x := constant.ToInt(v)
+		if x.Kind() != constant.Int {
+			if v.Kind() == constant.Complex && constant.Sign(constant.Imag(v)) != 0 {
+				return nil, truncatedToReal()
+			}
+			return nil, fmt.Sprintf("constant %s truncated to integer", v)
+		}
+		// value is representable if it is not truncated to the type's size
+		if t.IsSigned() {
+			n, exact := constant.Int64Val(x)
+			res := objects.NewInteger(t, uint64(n))
+			if !exact || objects.Int64Value(res) != n {
+				return nil, overflows()
+			}
+			return res, ""
+		}
+		n, exact := constant.Uint64Val(x)
+		res := objects.NewInteger(t, n)
+		if !exact || objects.Uint64Value(res) != n {
+			return nil, overflows()
+		}
+		return res, ""
+
+	case t.IsFloat() && numeric:
+		x := constant.ToFloat(v)
+		if x.Kind() != constant.Float {
+			return nil, truncatedToReal()
+		}
+		f := constantFloat(x, t)
+		if math.IsInf(f, 0) {
+			return nil, overflows()
+		}
+		return objects.NewFloat(t, f), ""
+
+	case t.IsComplex() && numeric:
+		x := constant.ToComplex(v)
+		ft := objects.FloatType
+		if t == objects.Complex64Type {
+			ft = objects.Float32Type
+		}
+		re, im := constantFloat(constant.Real(x), ft), constantFloat(constant.Imag(x), ft)
+		if math.IsInf(re, 0) || math.IsInf(im, 0) {
+			return nil, overflows()
+		}
+		return objects.NewComplex(t, complex(re, im)), ""
+
+	case t == objects.StringType && v.Kind() == constant.String:
+		return &objects.String{Value: constant.StringVal(v)}, ""
+
+	case t == objects.BooleanType && v.Kind() == constant.Bool:
+		return objects.NewBoolean(constant.BoolVal(v)), ""
+	}
+
+	return nil, ""
+}
+
+// constantFloat returns the nearest value of floating-point type t for real constant v.
+func constantFloat(v constant.Value, t objects.Type) float64 {
+	if t == objects.Float32Type {
+		f, _ := constant.Float32Val(v)
+		return float64(f)
+	}
+	f, _ := constant.Float64Val(v)
+	return f
+}
+
+// Untyped evaluates constant expression exactly and returns a value of its default type.
+func Untyped(exp ast.Expression) objects.Object {
+	v, k := EvalConstant(exp)
+	res, msg := ConstantToType(v, k)
+	if res == nil {
+		crash("%s", msg)
+	}
+	return res
+}
+
+// ConstantTo evaluates constant expression exactly and converts it to basic or defined basic type t.
+// It returns nil object and empty message if constant of that kind can't be converted to t at all,
+// and nil object and Go compiler message if value can't be represented by t.
+func ConstantTo(exp ast.Expression, t *objects.TypeObject) (objects.Object, string) {
+	v, _ := EvalConstant(exp)
+	res, msg := ConstantToType(v, t.Kind)
+	if res != nil && t.IsDefinedBasic() {
+		res = &objects.Named{T: t, Value: res}
+	}
+	return res, msg
+}
+
+// ConstantOperand evaluates constant operand exp of binary operation node.
+// It takes the type of other operand if it can be represented by that type.
+func ConstantOperand(node *ast.InfixExpression, exp ast.Expression, other objects.Object) objects.Object {
+	t := objects.TypeOf(other)
+	if t == nil || !t.Kind.IsBasic() {
+		return Untyped(exp)
+	}
+
+	res, msg := ConstantTo(exp, t)
+	if res == nil {
+		if msg != "" {
+			crash("%s", msg)
+		}
+		_, k := EvalConstant(exp)
+		crash("invalid operation: %s (mismatched types %s and %s)", node, TypeString(other), UntypedName(k))
+	}
+	return res
+}
@@ -0,0 +1,13 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package ops implements operations on Gosh runtime objects shared by the interpreter and the virtual machine:
+// operators, untyped constants, assignability and conversions.
+//
+// Like Go functions of builtins, operations report runtime errors by panicking with error values;
+// both engines convert them to runtime errors at the current statement, or at the offset of *Error.
+package ops // import "gosh-lang.org/gosh/internal/ops"
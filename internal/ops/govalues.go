@@ -0,0 +1,116 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"go/token"
+	"reflect"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// GoMap returns Go map value of object x, if it is one.
+func GoMap(x objects.Object) (reflect.Value, bool) {
+	if v, ok := x.(*objects.GoValue); ok && v.Value.Kind() == reflect.Map {
+		return v.Value, true
+	}
+	return reflect.Value{}, false
+}
+
+// MapIndex returns the element of Go map m for key of index expression node.
+// Missing keys give the zero value of the element type.
+func MapIndex(node *ast.IndexExpression, m reflect.Value, key objects.Object) objects.Object {
+	v := m.MapIndex(mapKey(node, m, key))
+	if !v.IsValid() {
+		v = reflect.Zero(m.Type().Elem())
+	}
+	return objects.FromGo(v)
+}
+
+// SetMapIndex sets the element of Go map m for key of index expression node to val.
+func SetMapIndex(node *ast.IndexExpression, m reflect.Value, key, val objects.Object) {
+	k := mapKey(node, m, key)
+	if m.IsNil() {
+		crash("assignment to entry in nil map")
+	}
+	v, err := objects.ToGo(val, m.Type().Elem())
+	if err != nil {
+		crash("cannot use %s in assignment to %s: %s", val, node, err)
+	}
+	m.SetMapIndex(k, v)
+}
+
+// mapKey returns Go value of key of index expression node for Go map m.
+func mapKey(node *ast.IndexExpression, m reflect.Value, key objects.Object) reflect.Value {
+	k, err := objects.ToGo(key, m.Type().Key())
+	if err != nil {
+		crash("invalid map index %s: %s", node.Index, err)
+	}
+	return k
+}
+
+// selectGo returns exported field or method of Go value v selected by node.
+// Fields and methods of structs are selected through pointers.
+func selectGo(node *ast.SelectorExpression, v reflect.Value) objects.Object {
+	name := node.Sel.Value
+	if m := v.MethodByName(name); m.IsValid() {
+		return objects.FromGo(m)
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		if m := v.Addr().MethodByName(name); m.IsValid() {
+			return objects.FromGo(m)
+		}
+	}
+	if f, ok := goField(node, v); ok {
+		return objects.FromGo(f)
+	}
+	if !token.IsExported(name) {
+		crash("%s undefined (cannot refer to unexported field or method %s)", node, name)
+	}
+	crash("%s undefined (type %s has no field or method %s)", node, v.Type(), name)
+	return nil
+}
+
+// goField returns exported field of Go struct or pointer to struct v selected by node.
+func goField(node *ast.SelectorExpression, v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			crash("runtime error: invalid memory address or nil pointer dereference")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	sf, ok := v.Type().FieldByName(node.Sel.Value)
+	if !ok || sf.PkgPath != "" {
+		return reflect.Value{}, false
+	}
+	f, err := v.FieldByIndexErr(sf.Index)
+	if err != nil {
+		crash("runtime error: invalid memory address or nil pointer dereference")
+	}
+	return f, true
+}
+
+// assignGo sets exported field of Go value v selected by node to val.
+// Only fields of structs referenced by pointers can be set.
+func assignGo(node *ast.SelectorExpression, v reflect.Value, val objects.Object) {
+	selectGo(node, v)
+	f, ok := goField(node, v)
+	if !ok || !f.CanSet() {
+		crash("cannot assign to %s (neither addressable nor a map index expression)", node)
+	}
+	gv, err := objects.ToGo(val, f.Type())
+	if err != nil {
+		crash("cannot use %s as %s value in assignment: %s", val, f.Type(), err)
+	}
+	f.Set(gv)
+}
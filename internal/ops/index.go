@@ -0,0 +1,99 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// Index checks that the value of index expression exp is an integer, and returns it.
+func Index(exp ast.Expression, idx objects.Object) int {
+	t := idx.Type()
+	if !t.IsInteger() {
+		crash("invalid argument: index %s (type %s) must be integer", exp, TypeString(idx))
+	}
+	if t.IsSigned() {
+		return int(objects.Int64Value(idx))
+	}
+	return int(objects.Uint64Value(idx))
+}
+
+// Length returns the length of string, slice or nil slice value x of indexed expression exp.
+func Length(exp ast.Expression, x objects.Object) int {
+	switch x := x.(type) {
+	case *objects.String:
+		return len(x.Value)
+	case *objects.Slice:
+		return len(x.Values)
+	case *objects.Nil:
+		if x.T != nil && x.T.Kind == objects.SliceType {
+			return 0
+		}
+	}
+	crash("invalid operation: %s (type %s does not support indexing)", exp, TypeString(x))
+	panic("not reached")
+}
+
+// CheckBounds checks that index idx is in range for the given length.
+func CheckBounds(idx, length int) {
+	if idx < 0 || idx >= length {
+		crash("runtime error: index out of range [%d] with length %d", idx, length)
+	}
+}
+
+// Element returns the element of string, slice or nil slice value x with the given index.
+func Element(x objects.Object, idx int) objects.Object {
+	switch x := x.(type) {
+	case *objects.String:
+		CheckBounds(idx, len(x.Value))
+		return &objects.Uint8{Value: x.Value[idx]}
+	case *objects.Slice:
+		CheckBounds(idx, len(x.Values))
+		return x.Values[idx]
+	default:
+		CheckBounds(idx, 0)
+		panic("not reached")
+	}
+}
+
+// Slice returns the result of slice expression node for the value val and indexes; nil indexes are omitted.
+func Slice(node *ast.SliceExpression, val, lowVal, highVal objects.Object) objects.Object {
+	x := Underlying(val)
+	l := Length(node, x)
+
+	low, high := 0, l
+	if lowVal != nil {
+		low = Index(node.Low, lowVal)
+	}
+	if highVal != nil {
+		high = Index(node.High, highVal)
+	}
+	switch {
+	case high < 0 || high > l:
+		crash("runtime error: slice bounds out of range [:%d] with length %d", high, l)
+	case low < 0 || low > high:
+		crash("runtime error: slice bounds out of range [%d:%d]", low, high)
+	}
+
+	var res objects.Object
+	switch x := x.(type) {
+	case *objects.String:
+		res = &objects.String{Value: x.Value[low:high]}
+	case *objects.Slice:
+		res = &objects.Slice{T: x.T, Values: x.Values[low:high]}
+	case *objects.Nil:
+		res = x
+	}
+
+	// result has the same defined type
+	if n, ok := val.(*objects.Named); ok {
+		res = &objects.Named{T: n.T, Value: res}
+	}
+	return res
+}
@@ -0,0 +1,99 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+	"go/token"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// DefineModule declares module m imported by spec in the global scope.
+// Importing the same module under the same name again (for example, by the next program run in that scope) is allowed.
+// It returns error message if that fails.
+func DefineModule(scope *objects.Scope, spec *ast.ImportSpec, m *objects.Module) string {
+	name := spec.LocalName()
+	if spec.Name == nil && name != m.Name {
+		return fmt.Sprintf("package %s does not match import path %s; use named import", m.Name, spec.Path)
+	}
+	if name == "_" {
+		return ""
+	}
+
+	if !scope.Define(name, m) {
+		if old, _ := scope.LookupLocal(name); old != m {
+			return fmt.Sprintf("%s redeclared in this block", name)
+		}
+	}
+	return ""
+}
+
+// Select returns the value of selector expression node for evaluated operand x:
+// exported member of module, or exported field or method of Go value.
+func Select(node *ast.SelectorExpression, x objects.Object) objects.Object {
+	if i, ok := x.(*objects.Interface); ok {
+		if i.Value == nil {
+			crash("runtime error: invalid memory address or nil pointer dereference")
+		}
+		x = i.Value
+	}
+	if v, ok := x.(*objects.GoValue); ok {
+		return selectGo(node, v.Value)
+	}
+
+	m, ok := x.(*objects.Module)
+	if !ok {
+		crash("%s undefined (type %s has no field or method %s)", node, TypeString(x), node.Sel.Value)
+	}
+
+	name := node.Sel.Value
+	if !token.IsExported(name) {
+		crash("cannot refer to unexported name %s", node)
+	}
+	res, ok := m.Member(name)
+	if !ok {
+		crash("undefined: %s", node)
+	}
+	return res
+}
+
+// AssignMember assigns val to selector expression node for evaluated operand x:
+// exported variable of module, or exported field of Go struct referenced by pointer.
+// Gosh module variables are assigned in the module's global scope, Go package variables are set with reflection.
+func AssignMember(node *ast.SelectorExpression, x, val objects.Object) {
+	if i, ok := x.(*objects.Interface); ok && i.Value != nil {
+		x = i.Value
+	}
+	if v, ok := x.(*objects.GoValue); ok {
+		assignGo(node, v.Value, val)
+		return
+	}
+
+	old := Select(node, x)
+	m := x.(*objects.Module)
+	name := node.Sel.Value
+	switch {
+	case m.Go == nil:
+		if _, ok := old.(*objects.TypeObject); !ok {
+			m.Scope.Assign(name, val)
+			return
+		}
+	default:
+		if v, ok := m.Go.Values[name]; ok && v.CanSet() {
+			gv, err := objects.ToGo(val, v.Type())
+			if err != nil {
+				crash("cannot use %s as %s value in assignment: %s", val, v.Type(), err)
+			}
+			v.Set(gv)
+			return
+		}
+	}
+	crash("cannot assign to %s (neither addressable nor a map index expression)", node)
+}
@@ -0,0 +1,473 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Unary evaluates unary operation.
+func Unary(operator string, right objects.Object) objects.Object {
+	if n, ok := right.(*objects.Named); ok {
+		return &objects.Named{T: n.T, Value: Unary(operator, n.Value)}
+	}
+
+	switch operator {
+	case "!":
+		if b, ok := right.(*objects.Boolean); ok {
+			return objects.NewBoolean(!b.Value)
+		}
+		crash("prefix expression operator ! on %T:\n%#v", right, right)
+
+	case "+":
+		if right.Type().IsNumeric() {
+			return right
+		}
+		crash("prefix expression operator + on %T:\n%#v", right, right)
+
+	case "-":
+		switch t := right.Type(); {
+		case t.IsInteger():
+			return objects.NewInteger(t, -objects.Uint64Value(right))
+		case t.IsFloat():
+			return objects.NewFloat(t, -objects.Float64Value(right))
+		case t.IsComplex():
+			return objects.NewComplex(t, -objects.Complex128Value(right))
+		}
+		crash("prefix expression operator - on %T:\n%#v", right, right)
+
+	case "^":
+		if t := right.Type(); t.IsInteger() {
+			return objects.NewInteger(t, ^objects.Uint64Value(right))
+		}
+		crash("prefix expression operator ^ on %T:\n%#v", right, right)
+
+	default:
+		crash("unhandled prefix expression operator %s", operator)
+	}
+	panic("not reached")
+}
+
+func binarySigned(operator string, t objects.Type, left, right int64) objects.Object {
+	switch operator {
+	case "+":
+		return objects.NewInteger(t, uint64(left+right))
+	case "-":
+		return objects.NewInteger(t, uint64(left-right))
+	case "*":
+		return objects.NewInteger(t, uint64(left*right))
+	case "/":
+		if right == 0 {
+			crash("runtime error: integer divide by zero")
+		}
+		return objects.NewInteger(t, uint64(left/right))
+	case "%":
+		if right == 0 {
+			crash("runtime error: integer divide by zero")
+		}
+		return objects.NewInteger(t, uint64(left%right))
+
+	case "&":
+		return objects.NewInteger(t, uint64(left&right))
+	case "|":
+		return objects.NewInteger(t, uint64(left|right))
+	case "^":
+		return objects.NewInteger(t, uint64(left^right))
+	case "&^":
+		return objects.NewInteger(t, uint64(left&^right))
+
+	case "<":
+		return objects.NewBoolean(left < right)
+	case "<=":
+		return objects.NewBoolean(left <= right)
+	case ">":
+		return objects.NewBoolean(left > right)
+	case ">=":
+		return objects.NewBoolean(left >= right)
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+
+	default:
+		crash("unhandled infix expression operator %s for two %s", operator, t.Name())
+		panic("not reached")
+	}
+}
+
+func binaryUnsigned(operator string, t objects.Type, left, right uint64) objects.Object {
+	switch operator {
+	case "+":
+		return objects.NewInteger(t, left+right)
+	case "-":
+		return objects.NewInteger(t, left-right)
+	case "*":
+		return objects.NewInteger(t, left*right)
+	case "/":
+		if right == 0 {
+			crash("runtime error: integer divide by zero")
+		}
+		return objects.NewInteger(t, left/right)
+	case "%":
+		if right == 0 {
+			crash("runtime error: integer divide by zero")
+		}
+		return objects.NewInteger(t, left%right)
+
+	case "&":
+		return objects.NewInteger(t, left&right)
+	case "|":
+		return objects.NewInteger(t, left|right)
+	case "^":
+		return objects.NewInteger(t, left^right)
+	case "&^":
+		return objects.NewInteger(t, left&^right)
+
+	case "<":
+		return objects.NewBoolean(left < right)
+	case "<=":
+		return objects.NewBoolean(left <= right)
+	case ">":
+		return objects.NewBoolean(left > right)
+	case ">=":
+		return objects.NewBoolean(left >= right)
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+
+	default:
+		crash("unhandled infix expression operator %s for two %s", operator, t.Name())
+		panic("not reached")
+	}
+}
+
+func binaryFloat(operator string, t objects.Type, left, right float64) objects.Object {
+	switch operator {
+	case "+":
+		return objects.NewFloat(t, left+right)
+	case "-":
+		return objects.NewFloat(t, left-right)
+	case "*":
+		return objects.NewFloat(t, left*right)
+	case "/":
+		return objects.NewFloat(t, left/right)
+
+	case "<":
+		return objects.NewBoolean(left < right)
+	case "<=":
+		return objects.NewBoolean(left <= right)
+	case ">":
+		return objects.NewBoolean(left > right)
+	case ">=":
+		return objects.NewBoolean(left >= right)
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+
+	default:
+		crash("unhandled infix expression operator %s for two %s", operator, t.Name())
+		panic("not reached")
+	}
+}
+
+func binaryComplex(operator string, t objects.Type, left, right complex128) objects.Object {
+	switch operator {
+	case "+":
+		return objects.NewComplex(t, left+right)
+	case "-":
+		return objects.NewComplex(t, left-right)
+	case "*":
+		return objects.NewComplex(t, left*right)
+	case "/":
+		return objects.NewComplex(t, left/right)
+
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+
+	default:
+		crash("unhandled infix expression operator %s for two %s", operator, t.Name())
+		panic("not reached")
+	}
+}
+
+func binaryBoolean(operator string, left, right bool) objects.Object {
+	switch operator {
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+	case "&&":
+		return objects.NewBoolean(left && right)
+	case "||":
+		return objects.NewBoolean(left || right)
+	default:
+		crash("unhandled infix expression operator %s for two Booleans", operator)
+		panic("not reached")
+	}
+}
+
+func binaryString(operator string, left, right string) objects.Object {
+	switch operator {
+	case "+":
+		return &objects.String{Value: left + right}
+
+	case "<":
+		return objects.NewBoolean(left < right)
+	case "<=":
+		return objects.NewBoolean(left <= right)
+	case ">":
+		return objects.NewBoolean(left > right)
+	case ">=":
+		return objects.NewBoolean(left >= right)
+	case "==":
+		return objects.NewBoolean(left == right)
+	case "!=":
+		return objects.NewBoolean(left != right)
+
+	default:
+		crash("unhandled infix expression operator %s for two strings", operator)
+		panic("not reached")
+	}
+}
+
+// Binary evaluates binary operation other than shift on operand values.
+// Callers evaluate the right operand of && and || only when needed.
+// Error messages contain the source text of expression node, or operand values if it is nil.
+func Binary(node ast.Expression, operator string, left, right objects.Object) objects.Object {
+	// fast path for the most common operands
+	if l, ok := left.(*objects.Integer); ok {
+		if r, ok := right.(*objects.Integer); ok {
+			return binarySigned(operator, objects.IntegerType, int64(l.Value), int64(r.Value))
+		}
+	}
+
+	lt, rt := left.Type(), right.Type()
+	if lt == objects.NilType || rt == objects.NilType || lt == objects.InterfaceType || rt == objects.InterfaceType {
+		return binaryNil(operator, left, right)
+	}
+	if lt == objects.NamedType || rt == objects.NamedType {
+		return binaryNamed(node, operator, left, right)
+	}
+	if lt != rt && (lt.IsBasic() || rt.IsBasic()) {
+		crash("invalid operation: %s (mismatched types %s and %s)", operation(node, operator, left, right), lt.Name(), rt.Name())
+	}
+
+	switch {
+	case lt.IsSigned():
+		l := objects.Int64Value(left)
+		r := objects.Int64Value(right)
+		return binarySigned(operator, lt, l, r)
+
+	case lt.IsUnsigned():
+		l := objects.Uint64Value(left)
+		r := objects.Uint64Value(right)
+		return binaryUnsigned(operator, lt, l, r)
+
+	case lt.IsFloat():
+		l := objects.Float64Value(left)
+		r := objects.Float64Value(right)
+		return binaryFloat(operator, lt, l, r)
+
+	case lt.IsComplex():
+		l := objects.Complex128Value(left)
+		r := objects.Complex128Value(right)
+		return binaryComplex(operator, lt, l, r)
+
+	case lt == objects.BooleanType && rt == objects.BooleanType:
+		l := left.(*objects.Boolean).Value
+		r := right.(*objects.Boolean).Value
+		return binaryBoolean(operator, l, r)
+
+	case lt == objects.StringType && rt == objects.StringType:
+		l := left.(*objects.String).Value
+		r := right.(*objects.String).Value
+		return binaryString(operator, l, r)
+
+	case lt == objects.ChannelType && rt == objects.ChannelType:
+		// channel values are equal if they were created by the same call to make
+		same := left.(*objects.Channel).State == right.(*objects.Channel).State
+		switch operator {
+		case "==":
+			return objects.NewBoolean(same)
+		case "!=":
+			return objects.NewBoolean(!same)
+		}
+	}
+
+	crash("unhandled combination: %T %s %T", left, operator, right)
+	panic("not reached")
+}
+
+// Shift evaluates shift operation of node with given operand values.
+// The result has the type of the left operand.
+func Shift(node *ast.InfixExpression, left, count objects.Object) objects.Object {
+	operator := node.Token.Literal
+	if n, ok := left.(*objects.Named); ok {
+		return &objects.Named{T: n.T, Value: Shift(node, n.Value, count)}
+	}
+
+	lt := left.Type()
+	if !lt.IsInteger() {
+		crash("invalid operation: shifted operand %s (type %s) must be integer", node.Left, TypeString(left))
+	}
+	c := Underlying(count)
+	if !c.Type().IsInteger() {
+		crash("invalid operation: shift count %s (type %s) must be integer", node.Right, TypeString(count))
+	}
+	n := objects.Uint64Value(c)
+	if c.Type().IsSigned() && objects.Int64Value(c) < 0 {
+		crash("runtime error: negative shift amount")
+	}
+
+	if lt.IsSigned() {
+		l := objects.Int64Value(left)
+		if operator == "<<" {
+			return objects.NewInteger(lt, uint64(l<<n))
+		}
+		return objects.NewInteger(lt, uint64(l>>n))
+	}
+	l := objects.Uint64Value(left)
+	if operator == "<<" {
+		return objects.NewInteger(lt, l<<n)
+	}
+	return objects.NewInteger(lt, l>>n)
+}
+
+// IncDec returns the result of increment or decrement statement node for the current value val.
+func IncDec(node *ast.IncrementDecrementStatement, val objects.Object) objects.Object {
+	delta := 1
+	switch node.Token.Type {
+	case tokens.Increment:
+	case tokens.Decrement:
+		delta = -1
+	default:
+		crash("unexpected token")
+	}
+
+	// fast path for loop counters
+	if x, ok := val.(*objects.Integer); ok {
+		return objects.NewInt(x.Value + delta)
+	}
+
+	t := objects.TypeOf(val)
+	if t == nil || !t.Kind.IsNumeric() {
+		crash("invalid operation: %s%s (non-numeric type %s)", node.X, node.Token.Literal, TypeString(val))
+	}
+
+	one := objects.Convert(objects.NewInt(1), t.Kind)
+	if t.IsDefinedBasic() {
+		one = &objects.Named{T: t, Value: one}
+	}
+	if delta < 0 {
+		return Binary(nil, "-", val, one)
+	}
+	return Binary(nil, "+", val, one)
+}
+
+// binaryNil evaluates comparison with nil or interface values.
+func binaryNil(operator string, left, right objects.Object) objects.Object {
+	switch operator {
+	case "==":
+		return objects.NewBoolean(Equal(left, right))
+	case "!=":
+		return objects.NewBoolean(!Equal(left, right))
+	default:
+		crash("invalid operation: %s %s %s (operator %s not defined on nil)", left, operator, right, operator)
+		panic("not reached")
+	}
+}
+
+// Equal compares two values, at least one of which is nil or interface value, with Go rules.
+func Equal(left, right objects.Object) bool {
+	// make interface value the left one
+	if _, ok := right.(*objects.Interface); ok {
+		left, right = right, left
+	}
+
+	if l, ok := left.(*objects.Interface); ok {
+		var rv objects.Object
+		switch r := right.(type) {
+		case *objects.Interface:
+			rv = r.Value
+		case *objects.Nil:
+			if r.T != nil {
+				// typed nil is converted to interface type
+				rv = r
+			}
+		default:
+			rv = right
+		}
+
+		switch {
+		case l.Value == nil || rv == nil:
+			return l.Value == nil && rv == nil
+		case !objects.TypeOf(l.Value).Identical(objects.TypeOf(rv)):
+			return false
+		case l.Value.Type() == objects.NilType:
+			return true
+		case !l.Value.Type().IsBasic():
+			crash("comparing uncomparable type %s", objects.TypeOf(l.Value))
+		}
+		return Binary(nil, "==", l.Value, rv).(*objects.Boolean).Value
+	}
+
+	// make nil value the left one
+	if _, ok := left.(*objects.Nil); !ok {
+		left, right = right, left
+	}
+	l := left.(*objects.Nil)
+
+	switch r := right.(type) {
+	case *objects.Nil:
+		if l.T != nil && r.T != nil && !l.T.Identical(r.T) {
+			crash("invalid operation: mismatched types %s and %s", l.T, r.T)
+		}
+		return true
+
+	default:
+		if l.T == nil && right.Type().IsNillable() {
+			return false
+		}
+		crash("invalid operation: mismatched types %s and nil", right.Type().Name())
+		panic("not reached")
+	}
+}
+
+// operation returns binary operation for error messages: the source text of expression node,
+// or operand values if it is nil.
+func operation(node ast.Expression, operator string, left, right objects.Object) string {
+	if node != nil {
+		return node.String()
+	}
+	return fmt.Sprintf("%s %s %s", left, operator, right)
+}
+
+// binaryNamed evaluates binary operation on values of defined types.
+func binaryNamed(node ast.Expression, operator string, left, right objects.Object) objects.Object {
+	l, lok := left.(*objects.Named)
+	r, rok := right.(*objects.Named)
+	if !lok || !rok || l.T != r.T {
+		crash("invalid operation: %s (mismatched types %s and %s)", operation(node, operator, left, right), TypeString(left), TypeString(right))
+	}
+
+	res := Binary(node, operator, l.Value, r.Value)
+	switch operator {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return res
+	default:
+		return &objects.Named{T: l.T, Value: res}
+	}
+}
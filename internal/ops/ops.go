@@ -0,0 +1,50 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+
+	"gosh-lang.org/gosh/objects"
+)
+
+// crash reports runtime error with a formatted message.
+func crash(format string, a ...interface{}) {
+	panic(fmt.Errorf(format, a...))
+}
+
+// Error is a runtime error at the given byte offset rather than at the current statement.
+type Error struct {
+	Offset int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// crashAt reports runtime error with a formatted message at the given byte offset.
+func crashAt(offset int, format string, a ...interface{}) {
+	panic(&Error{Offset: offset, Msg: fmt.Sprintf(format, a...)})
+}
+
+// TypeString returns the type of val as it is written in Go compiler messages.
+func TypeString(val objects.Object) string {
+	if t := objects.TypeOf(val); t != nil {
+		return t.String()
+	}
+	return val.Type().Name()
+}
+
+// Underlying returns the value of underlying type for value of defined type, or val itself.
+func Underlying(val objects.Object) objects.Object {
+	if n, ok := val.(*objects.Named); ok {
+		return n.Value
+	}
+	return val
+}
@@ -0,0 +1,85 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// SingleValue returns the value of result res of call node used as an expression.
+// The only Go result of error type is the value of the call; non-nil error panics if the call is discarded.
+// Non-nil error of the last Go result panics, and a value with an error is unwrapped.
+// Other multiple results are allowed only if discard is true (the call is an expression statement).
+func SingleValue(node *ast.CallExpression, res objects.Object, discard bool) objects.Object {
+	t, ok := res.(*objects.Tuple)
+	if !ok {
+		return res
+	}
+
+	if t.HasError {
+		if len(t.Values) == 1 && !discard {
+			return t.Values[0]
+		}
+		if t.Err != nil {
+			panic(t.Err)
+		}
+		switch len(t.Values) {
+		case 1:
+			return objects.UntypedNil
+		case 2:
+			return t.Values[0]
+		}
+	}
+	if !discard {
+		crash("multiple-value %s in single-value context", node)
+	}
+	return t
+}
+
+// CallValues returns values of result res of call on the right side of multi-value assignment node.
+func CallValues(node *ast.AssignStatement, res objects.Object) []objects.Object {
+	values := []objects.Object{res}
+	if t, ok := res.(*objects.Tuple); ok {
+		values = t.Values
+	}
+	if len(values) != 1+len(node.Rest) {
+		crash("%s", AssignmentMismatch(node, len(values)))
+	}
+	return values
+}
+
+// ReceivedValues returns received value val and, for comma-ok form, a flag,
+// assigned by receive assignment node (e.g. `v, ok := <-ch`).
+func ReceivedValues(node *ast.AssignStatement, val objects.Object, received bool) []objects.Object {
+	switch len(node.Rest) {
+	case 0:
+		return []objects.Object{val}
+	case 1:
+		return []objects.Object{val, objects.NewBoolean(received)}
+	default:
+		crash("%s", AssignmentMismatch(node, 1))
+		return nil
+	}
+}
+
+// AssignmentMismatch returns the error message for multi-value assignment node
+// with the given number of values on its right side.
+func AssignmentMismatch(node *ast.AssignStatement, values int) string {
+	vars := 1 + len(node.Rest)
+	s := "values"
+	if values == 1 {
+		s = "value"
+	}
+	if call, ok := node.Value.(*ast.CallExpression); ok {
+		return fmt.Sprintf("assignment mismatch: %d variables but %s returns %d %s", vars, call, values, s)
+	}
+	return fmt.Sprintf("assignment mismatch: %d variables but %d %s", vars, values, s)
+}
@@ -0,0 +1,56 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// BlockScope returns a new block scope for the given number of slots, or scope itself if there are none.
+func BlockScope(scope *objects.Scope, slots int) *objects.Scope {
+	if slots == 0 {
+		return scope
+	}
+	return objects.NewBlockScope(scope, slots)
+}
+
+// Lookup returns the entity of identifier id: from the slot bound by resolver, or by name.
+func Lookup(scope *objects.Scope, id *ast.Identifier) (objects.Object, bool) {
+	if id.Local || id.Global {
+		obj := scope.Get(id.Depth, id.Slot)
+		return obj, obj != nil
+	}
+	return scope.LookupFrom(id.Depth, id.Value)
+}
+
+// Define declares entity id in the current scope. Blank identifier is not declared.
+// It returns false if it is already declared in that scope.
+func Define(scope *objects.Scope, id *ast.Identifier, obj objects.Object) bool {
+	switch {
+	case id.Value == "_":
+		return true
+	case id.Local || id.Global:
+		return scope.DefineSlot(id.Slot, obj)
+	default:
+		return scope.Define(id.Value, obj)
+	}
+}
+
+// Assign assigns a new value to the declared variable id. Assignment to blank identifier discards the value.
+// It returns false if the variable is not declared.
+func Assign(scope *objects.Scope, id *ast.Identifier, obj objects.Object) bool {
+	switch {
+	case id.Value == "_":
+		return true
+	case id.Local || id.Global:
+		return scope.SetSlot(id.Depth, id.Slot, obj)
+	default:
+		return scope.AssignFrom(id.Depth, id.Value, obj)
+	}
+}
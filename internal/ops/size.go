@@ -0,0 +1,80 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"gosh-lang.org/gosh/objects"
+)
+
+// SliceElemSize is an approximate size of slice element in bytes: interface value and the object itself.
+const SliceElemSize = 32
+
+// MakeSize returns the approximate size of a slice made by make builtin with given arguments.
+// Invalid arguments are reported by the builtin itself; their size is zero,
+// so they can't be used to increase the remaining allocation budget.
+func MakeSize(args []objects.Object) int64 {
+	if len(args) < 2 {
+		return 0
+	}
+	if t, ok := args[0].(*objects.TypeObject); !ok || t.Kind != objects.SliceType {
+		return 0
+	}
+
+	var n int64
+	size := Underlying(args[len(args)-1])
+	switch {
+	case size.Type().IsSigned():
+		n = objects.Int64Value(size)
+	case size.Type().IsUnsigned():
+		n = int64(objects.Uint64Value(size))
+	}
+	switch {
+	case n <= 0:
+		return 0
+	case n > math.MaxInt64/SliceElemSize:
+		return math.MaxInt64
+	default:
+		return n * SliceElemSize
+	}
+}
+
+// ConversionSize returns the approximate size of a string or a slice created by conversion of val to type t.
+func ConversionSize(val objects.Object, t *objects.TypeObject) int64 {
+	u := t.UnderlyingType()
+	switch x := Underlying(val).(type) {
+	case *objects.String:
+		if u.Kind == objects.SliceType {
+			return int64(len(x.Value)) * SliceElemSize
+		}
+	case *objects.Slice:
+		if u.Kind == objects.StringType {
+			return int64(len(x.Values)) * utf8.UTFMax
+		}
+	}
+	return 0
+}
+
+// ConcatSize returns the size of a string created by concatenation of left and right operands of + operator.
+func ConcatSize(left, right objects.Object) int64 {
+	if l, ok := left.(*objects.Named); ok {
+		r, ok := right.(*objects.Named)
+		if !ok || l.T != r.T {
+			return 0
+		}
+		left, right = l.Value, r.Value
+	}
+	l, lok := left.(*objects.String)
+	r, rok := right.(*objects.String)
+	if !lok || !rok {
+		return 0
+	}
+	return int64(len(l.Value) + len(r.Value))
+}
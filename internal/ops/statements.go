@@ -0,0 +1,103 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"strings"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// compoundOperators maps compound assignment tokens to binary operation tokens.
+var compoundOperators = map[tokens.Type]tokens.Type{
+	tokens.SumAssignment:        tokens.Sum,
+	tokens.DifferenceAssignment: tokens.Difference,
+	tokens.ProductAssignment:    tokens.Product,
+	tokens.QuotientAssignment:   tokens.Quotient,
+	tokens.RemainderAssignment:  tokens.Remainder,
+
+	tokens.BitwiseAndAssignment:    tokens.BitwiseAnd,
+	tokens.BitwiseOrAssignment:     tokens.BitwiseOr,
+	tokens.BitwiseXorAssignment:    tokens.BitwiseXor,
+	tokens.BitwiseAndNotAssignment: tokens.BitwiseAndNot,
+
+	tokens.ShiftLeftAssignment:  tokens.ShiftLeft,
+	tokens.ShiftRightAssignment: tokens.ShiftRight,
+}
+
+// CompoundExpression returns binary expression x op y for compound assignment statement x op= y.
+func CompoundExpression(node *ast.AssignStatement) *ast.InfixExpression {
+	op, ok := compoundOperators[node.Token.Type]
+	if !ok {
+		crash("unhandled token %s", node.Token)
+	}
+	return &ast.InfixExpression{
+		Token: tokens.Token{Offset: node.Token.Offset, Type: op, Literal: strings.TrimSuffix(node.Token.Literal, "=")},
+		Left:  node.Name,
+		Right: node.Value,
+	}
+}
+
+// ReceiveExpression returns receive expression of the comm clause statement, or nil.
+func ReceiveExpression(s ast.Statement) *ast.PrefixExpression {
+	var exp ast.Expression
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		exp = s.Expression
+	case *ast.AssignStatement:
+		if s.Token.Type != tokens.Define && s.Token.Type != tokens.Assignment {
+			return nil
+		}
+		exp = s.Value
+	}
+	if p, ok := exp.(*ast.PrefixExpression); ok && p.Token.Type == tokens.Arrow {
+		return p
+	}
+	return nil
+}
+
+// StatementOffset returns the byte offset of the statement's token.
+func StatementOffset(s ast.Statement) int {
+	switch s := s.(type) {
+	case *ast.ImportStatement:
+		return s.Token.Offset
+	case *ast.ExpressionStatement:
+		return s.Token.Offset
+	case *ast.AssignStatement:
+		return s.Token.Offset
+	case *ast.IncrementDecrementStatement:
+		return s.Token.Offset
+	case *ast.VarStatement:
+		return s.Token.Offset
+	case *ast.TypeStatement:
+		return s.Token.Offset
+	case *ast.ReturnStatement:
+		return s.Token.Offset
+	case *ast.DeferStatement:
+		return s.Token.Offset
+	case *ast.GoStatement:
+		return s.Token.Offset
+	case *ast.SendStatement:
+		return s.Token.Offset
+	case *ast.SelectStatement:
+		return s.Token.Offset
+	case *ast.ContinueStatement:
+		return s.Token.Offset
+	case *ast.IfStatement:
+		return s.Token.Offset
+	case *ast.ForStatement:
+		return s.Token.Offset
+	case *ast.RangeStatement:
+		return s.Token.Offset
+	case *ast.BlockStatement:
+		return s.Token.Offset
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,292 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ops
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+)
+
+// AssignableTypes returns true if a value of type vt can be assigned to a variable of type t:
+// types are identical, or at least one of them is not a defined type and underlying types are identical.
+func AssignableTypes(vt, t *objects.TypeObject) bool {
+	if vt.Identical(t) {
+		return true
+	}
+	if vt.Underlying != nil && t.Underlying != nil {
+		return false
+	}
+
+	// bidirectional channel can be assigned to directional channel
+	if vt.Kind == objects.ChannelType && t.Kind == objects.ChannelType && vt.Dir == ast.SendRecv {
+		return vt.Elem.Identical(t.Elem)
+	}
+	return vt.UnderlyingType().Identical(t.UnderlyingType())
+}
+
+// Assigned evaluates constant expression exp which value is assigned to a variable of basic type t.
+// The constant takes that type.
+func Assigned(exp ast.Expression, t *objects.TypeObject) objects.Object {
+	res, msg := ConstantTo(exp, t)
+	if res == nil {
+		if msg == "" {
+			_, k := EvalConstant(exp)
+			msg = fmt.Sprintf("cannot use %s (type %s) as type %s in assignment", exp, UntypedName(k), t)
+		}
+		crash("%s", msg)
+	}
+	return res
+}
+
+// ConvertAssigned checks that value val of non-constant expression exp can be assigned to a variable of type t.
+// Nil takes that type, and values assigned to interface variables are wrapped.
+// If t is nil, val is returned as is.
+//nolint:gocyclo
+func ConvertAssigned(exp ast.Expression, val objects.Object, t *objects.TypeObject) objects.Object {
+	if t == nil {
+		return val
+	}
+
+	vt := objects.TypeOf(val)
+	switch val := val.(type) {
+	case *objects.Interface:
+		if t.Kind == objects.InterfaceType {
+			return &objects.Interface{T: t, Value: val.Value}
+		}
+		crash("cannot use %s (type %s) as type %s in assignment: need type assertion", exp, vt, t)
+
+	case *objects.Nil:
+		switch {
+		case t.Kind == objects.InterfaceType && vt == nil:
+			return &objects.Interface{T: t}
+		case t.Kind == objects.InterfaceType:
+			return &objects.Interface{T: t, Value: val}
+		case t.Kind.IsNillable() && vt == nil:
+			return &objects.Nil{T: t}
+		case vt == nil:
+			crash("cannot use nil as type %s in assignment", t)
+		case !AssignableTypes(vt, t):
+			crash("cannot use %s (type %s) as type %s in assignment", exp, vt, t)
+		}
+		return &objects.Nil{T: t}
+
+	case *objects.Slice:
+		switch {
+		case t.Kind == objects.InterfaceType:
+			return &objects.Interface{T: t, Value: val}
+		case !AssignableTypes(vt, t):
+			crash("cannot use %s (type %s) as type %s in assignment", exp, vt, t)
+		}
+		return &objects.Slice{T: t, Values: val.Values}
+
+	case *objects.Channel:
+		switch {
+		case t.Kind == objects.InterfaceType:
+			return &objects.Interface{T: t, Value: val}
+		case !AssignableTypes(vt, t):
+			crash("cannot use %s (type %s) as type %s in assignment", exp, vt, t)
+		}
+		return &objects.Channel{T: t, State: val.State}
+
+	case *objects.Named:
+		switch {
+		case t.Kind == objects.InterfaceType:
+			return &objects.Interface{T: t, Value: val}
+		case vt != t:
+			crash("cannot use %s (type %s) as type %s in assignment", exp, vt, t)
+		}
+		return val
+	}
+
+	if t.Kind == objects.InterfaceType {
+		return &objects.Interface{T: t, Value: val}
+	}
+
+	k := val.Type()
+	if k == t.Kind && !t.IsDefinedBasic() {
+		return val
+	}
+
+	if k.IsBasic() || t.Kind.IsBasic() || t.Kind.IsNillable() && t.Kind != objects.FunctionType {
+		crash("cannot use %s (type %s) as type %s in assignment", exp, TypeString(val), t)
+	}
+	return val
+}
+
+// ConvertArgument checks that argument value val can be assigned to parameter param of type t,
+// and returns the assigned value. Untyped constant arguments take their default types before the call,
+// so numeric values of other basic types are converted like the constants would be.
+func ConvertArgument(param *ast.Identifier, val objects.Object, t *objects.TypeObject) objects.Object {
+	if k := val.Type(); k.IsNumeric() && t.UnderlyingType().Kind.IsNumeric() && k != t.Kind {
+		if res := convert(param, val, t); res != nil {
+			return res
+		}
+	}
+	return ConvertAssigned(param, val, t)
+}
+
+// IsConstantConversion returns true if conversion T(exp) to type t is a constant conversion.
+// Conversion of integer constant to string type is not.
+func IsConstantConversion(exp ast.Expression, t *objects.TypeObject) bool {
+	if !IsConstant(exp) || !t.Kind.IsBasic() {
+		return false
+	}
+	_, k := EvalConstant(exp)
+	return !k.IsInteger() || t.Kind != objects.StringType
+}
+
+// ConvertConstant evaluates constant conversion T(exp) to type t.
+// It returns nil object and Go compiler message if constant can't be converted.
+func ConvertConstant(exp ast.Expression, t *objects.TypeObject) (objects.Object, string) {
+	res, msg := ConstantTo(exp, t)
+	if res == nil && msg == "" {
+		_, k := EvalConstant(exp)
+		msg = fmt.Sprintf("cannot convert %s (type %s) to type %s", exp, UntypedName(k), t)
+	}
+	return res, msg
+}
+
+// Convert implements conversion expression node T(x) for value val of non-constant x.
+func Convert(node *ast.CallExpression, val objects.Object, t *objects.TypeObject) objects.Object {
+	exp := node.Arguments[0]
+	res := convert(exp, val, t)
+	if res == nil {
+		crashAt(node.Token.Offset, "cannot convert %s (type %s) to type %s", exp, TypeString(val), t)
+	}
+	return res
+}
+
+// convert implements conversion expression T(exp) for value val of non-constant expression exp.
+// It returns nil if conversion is not allowed.
+func convert(exp ast.Expression, val objects.Object, t *objects.TypeObject) objects.Object {
+	switch val := val.(type) {
+	case *objects.Interface:
+		return ConvertAssigned(exp, val, t)
+	case *objects.Nil:
+		if val.T == nil {
+			return ConvertAssigned(exp, val, t)
+		}
+	}
+	if t.Kind == objects.InterfaceType {
+		return ConvertAssigned(exp, val, t)
+	}
+
+	// conversion operates on values of underlying types
+	res := convertUnderlying(Underlying(val), t.UnderlyingType())
+	if res == nil {
+		return nil
+	}
+
+	switch res := res.(type) {
+	case *objects.Nil:
+		return &objects.Nil{T: t}
+	case *objects.Slice:
+		return &objects.Slice{T: t, Values: res.Values}
+	case *objects.Channel:
+		return &objects.Channel{T: t, State: res.State}
+	}
+	if t.IsDefinedBasic() {
+		return &objects.Named{T: t, Value: res}
+	}
+	return res
+}
+
+// convertUnderlying converts value x (that is not an interface value, untyped nil or a value of defined type)
+// to type u (that is not an interface type or defined type) with Go rules.
+// It returns nil if conversion is not allowed.
+//nolint:gocyclo
+func convertUnderlying(x objects.Object, u *objects.TypeObject) objects.Object {
+	xt := x.Type()
+	switch {
+	case xt == u.Kind && xt.IsBasic():
+		return x
+
+	case xt.IsNumeric() && u.Kind.IsNumeric():
+		if xt.IsComplex() != u.Kind.IsComplex() {
+			return nil
+		}
+		return objects.Convert(x, u.Kind)
+
+	case xt.IsInteger() && u.Kind == objects.StringType:
+		// invalid code points are converted to "\uFFFD"
+		r := utf8.RuneError
+		if xt.IsSigned() {
+			if v := objects.Int64Value(x); v >= 0 && v <= utf8.MaxRune {
+				r = rune(v)
+			}
+		} else {
+			if v := objects.Uint64Value(x); v <= utf8.MaxRune {
+				r = rune(v)
+			}
+		}
+		return &objects.String{Value: string(r)}
+
+	case xt == objects.StringType && u.Kind == objects.SliceType:
+		s := x.(*objects.String).Value
+		var values []objects.Object
+		switch u.Elem.Kind {
+		case objects.Uint8Type:
+			values = make([]objects.Object, len(s))
+			for n := 0; n < len(s); n++ {
+				values[n] = &objects.Uint8{Value: s[n]}
+			}
+		case objects.Int32Type:
+			values = make([]objects.Object, 0, utf8.RuneCountInString(s))
+			for _, r := range s {
+				values = append(values, &objects.Int32{Value: r})
+			}
+		default:
+			return nil
+		}
+		if u.Elem.IsDefinedBasic() {
+			for n, v := range values {
+				values[n] = &objects.Named{T: u.Elem, Value: v}
+			}
+		}
+		return &objects.Slice{T: u, Values: values}
+
+	case (xt == objects.SliceType || xt == objects.NilType) && u.Kind == objects.StringType:
+		st := objects.TypeOf(x)
+		var values []objects.Object
+		if s, ok := x.(*objects.Slice); ok {
+			values = s.Values
+		}
+		var res strings.Builder
+		switch st.Elem.Kind {
+		case objects.Uint8Type:
+			for _, v := range values {
+				res.WriteByte(byte(objects.Uint64Value(Underlying(v))))
+			}
+		case objects.Int32Type:
+			for _, v := range values {
+				res.WriteRune(rune(objects.Int64Value(Underlying(v))))
+			}
+		default:
+			return nil
+		}
+		return &objects.String{Value: res.String()}
+
+	case xt == objects.ChannelType || xt == objects.NilType && u.Kind == objects.ChannelType:
+		if !AssignableTypes(objects.TypeOf(x).UnderlyingType(), u) {
+			return nil
+		}
+		return x
+
+	case xt == objects.SliceType || xt == objects.NilType:
+		if !objects.TypeOf(x).UnderlyingType().Identical(u) {
+			return nil
+		}
+		return x
+	}
+
+	return nil
+}
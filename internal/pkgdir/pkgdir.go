@@ -0,0 +1,279 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package pkgdir implements multi-file Gosh packages: all Gosh source files of a directory
+// are run as one package in a shared global scope.
+package pkgdir // import "gosh-lang.org/gosh/internal/pkgdir"
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/resolver"
+	"gosh-lang.org/gosh/tokens"
+)
+
+const (
+	// Ext is the file name extension of Gosh source files.
+	Ext = ".gosh"
+
+	// TestSuffix is the file name suffix of Gosh test files; they are not parts of packages.
+	TestSuffix = "_test" + Ext
+)
+
+// Files returns names of Gosh source files of the package in directory dir of fsys, sorted by file name.
+// Test files are excluded.
+func Files(fsys fs.FS, dir string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || path.Ext(name) != Ext || strings.HasSuffix(name, TestSuffix) {
+			continue
+		}
+		res = append(res, path.Join(dir, name))
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no Gosh files in %s", dir)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// Programs returns programs that run parsed files of one package, given in file name order,
+// when they are run in order in a shared global scope.
+//
+// Files are run in phases. First, imports, types and functions of all files are declared;
+// then other global statements of all files are run in initialization order; then init functions of each file
+// are called in order of appearance; then, for package main, main function is called if it is declared.
+// Like in Go, init functions are not declared, and there may be several of them.
+//
+// Like in Go, global statements are run in order of appearance, except that a statement that uses
+// global variables declared by other statements, directly or through declared functions,
+// is run after them, whatever files declare them. Names are not resolved, so local names shadowing
+// global variables are dependencies too; statements with cyclic dependencies are run in order of appearance.
+// Functions can use names declared by any file.
+//
+// Programs share nodes with files, so files should not be used after that.
+func Programs(files []*ast.Program) ([]*ast.Program, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no files")
+	}
+
+	var pkg *ast.Program // the first file with package clause
+	var globals []string
+	for _, f := range files {
+		if f.Package != nil {
+			switch {
+			case pkg == nil:
+				pkg = f
+			case pkg.Package.Value != f.Package.Value:
+				return nil, fmt.Errorf("found packages %s (%s) and %s (%s)",
+					pkg.Package.Value, path.Base(pkg.File), f.Package.Value, path.Base(f.File))
+			}
+		}
+		globals = append(globals, resolver.Globals(f)...)
+	}
+
+	program := func(f *ast.Program, statements []ast.Statement) *ast.Program {
+		return &ast.Program{File: f.File, Package: f.Package, Statements: statements, Globals: globals, Lines: f.Lines}
+	}
+
+	var declarations, others, inits []*ast.Program
+	var main *ast.Program
+	var statements []*statement
+	functions := make(map[string]*ast.FunctionLiteral)
+	for _, f := range files {
+		var d, i []ast.Statement
+		for _, s := range f.Statements {
+			switch s := s.(type) {
+			case *ast.ImportStatement, *ast.TypeStatement:
+				d = append(d, s)
+				continue
+
+			case *ast.VarStatement:
+				if s.Token.Type != tokens.Func {
+					break
+				}
+
+				lit := s.Value.(*ast.FunctionLiteral)
+				switch s.Name.Value {
+				case "init":
+					if err := CheckFunction(s); err != nil {
+						return nil, fmt.Errorf("%s: %s", f.File, err)
+					}
+					i = append(i, Call(lit.Token, lit))
+					continue
+				case "main":
+					if pkg == nil || pkg.Package.Value != "main" {
+						break
+					}
+					if err := CheckFunction(s); err != nil {
+						return nil, fmt.Errorf("%s: %s", f.File, err)
+					}
+					main = program(f, []ast.Statement{Call(s.Token, s.Name)})
+				}
+				functions[s.Name.Value] = lit
+				d = append(d, s)
+				continue
+			}
+
+			statements = append(statements, &statement{file: f, s: s})
+		}
+
+		if len(d) > 0 {
+			declarations = append(declarations, program(f, d))
+		}
+		if len(i) > 0 {
+			inits = append(inits, program(f, i))
+		}
+	}
+
+	// consecutive statements of the same file are run by one program
+	for _, s := range initOrder(statements, functions) {
+		if n := len(others) - 1; n >= 0 && others[n].File == s.file.File {
+			others[n].Statements = append(others[n].Statements, s.s)
+			continue
+		}
+		others = append(others, program(s.file, []ast.Statement{s.s}))
+	}
+
+	res := make([]*ast.Program, 0, len(declarations)+len(others)+len(inits)+1)
+	res = append(res, declarations...)
+	res = append(res, others...)
+	res = append(res, inits...)
+	if main != nil {
+		res = append(res, main)
+	}
+	return res, nil
+}
+
+// statement is a global statement of file other than declaration of import, type or function.
+type statement struct {
+	file *ast.Program
+	s    ast.Statement
+}
+
+// InitOrder returns global statements of one file, other than declarations of imports, types and functions,
+// in initialization order; see Programs. Functions are declared functions of the package by name.
+func InitOrder(statements []ast.Statement, functions map[string]*ast.FunctionLiteral) []ast.Statement {
+	in := make([]*statement, len(statements))
+	for n, s := range statements {
+		in[n] = &statement{s: s}
+	}
+
+	res := make([]ast.Statement, len(statements))
+	for n, s := range initOrder(in, functions) {
+		res[n] = s.s
+	}
+	return res
+}
+
+// initOrder returns statements in initialization order; see Programs.
+// Functions are declared functions of the package by name.
+func initOrder(statements []*statement, functions map[string]*ast.FunctionLiteral) []*statement {
+	// the number of statements which are not run yet for each declared name
+	pending := make(map[string]int)
+	declared := make([][]string, len(statements))
+	for n, s := range statements {
+		declared[n] = resolver.Globals(&ast.Program{Statements: []ast.Statement{s.s}})
+		for _, name := range declared[n] {
+			pending[name]++
+		}
+	}
+
+	deps := make([]map[string]bool, len(statements))
+	for n, s := range statements {
+		deps[n] = make(map[string]bool)
+		uses(s.s, functions, deps[n], make(map[string]bool))
+		for _, name := range declared[n] {
+			delete(deps[n], name)
+		}
+	}
+
+	ready := func(n int) bool {
+		for name := range deps[n] {
+			if pending[name] > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	res := make([]*statement, 0, len(statements))
+	done := make([]bool, len(statements))
+	for len(res) < len(statements) {
+		// the first statement which dependencies are run, or the first one if there are cycles
+		next := -1
+		for n := range statements {
+			if done[n] {
+				continue
+			}
+			if next < 0 {
+				next = n
+			}
+			if ready(n) {
+				next = n
+				break
+			}
+		}
+
+		done[next] = true
+		for _, name := range declared[next] {
+			pending[name]--
+		}
+		res = append(res, statements[next])
+	}
+	return res
+}
+
+// uses adds names used by node to res, including names used by declared functions it calls.
+// Visited contains names of already inspected functions.
+func uses(node ast.Node, functions map[string]*ast.FunctionLiteral, res, visited map[string]bool) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.SelectorExpression:
+			// selected field, method or module member is not a global name
+			uses(node.X, functions, res, visited)
+			return false
+
+		case *ast.Identifier:
+			res[node.Value] = true
+			if lit := functions[node.Value]; lit != nil && !visited[node.Value] {
+				visited[node.Value] = true
+				uses(lit, functions, res, visited)
+			}
+		}
+		return true
+	})
+}
+
+// CheckFunction checks that declared function init or main has no parameters and no results.
+func CheckFunction(s *ast.VarStatement) error {
+	lit := s.Value.(*ast.FunctionLiteral)
+	if len(lit.Parameters) != 0 || len(lit.Results) != 0 {
+		return fmt.Errorf("func %s must have no arguments and no return values", s.Name.Value)
+	}
+	return nil
+}
+
+// Call returns statement that calls function f without arguments at the given token.
+func Call(tok tokens.Token, f ast.Expression) ast.Statement {
+	return &ast.ExpressionStatement{
+		Token:      tok,
+		Expression: &ast.CallExpression{Token: tok, Function: f},
+	}
+}
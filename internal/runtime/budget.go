@@ -0,0 +1,40 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"sync/atomic"
+)
+
+// budget tracks resources used by the program for Config limits.
+type budget struct {
+	steps int64 // number of evaluated nodes or executed instructions
+	alloc int64 // number of allocated bytes
+}
+
+// Step accounts one evaluation step: AST node or instruction.
+func (g *Goroutine) Step() {
+	if max := g.Config.MaxSteps; max > 0 && atomic.AddInt64(&g.budget.steps, 1) > max {
+		g.Fatal(ErrMaxSteps)
+	}
+}
+
+// Alloc accounts size bytes of allocated memory. It should be called before allocation.
+func (g *Goroutine) Alloc(size int64) {
+	if max := g.Config.MaxAlloc; max > 0 && (size > max || atomic.AddInt64(&g.budget.alloc, size) > max) {
+		g.Fatal(ErrMaxAlloc)
+	}
+}
+
+// Fatal stops the program with a fatal runtime error caused by err.
+func (g *Goroutine) Fatal(err error) {
+	e := g.RuntimeError(g.Current().Offset, err.Error())
+	e.Fatal = true
+	e.Err = err
+	panic(e)
+}
@@ -0,0 +1,290 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// CallFrame is an active Gosh function call.
+type CallFrame struct {
+	Frame
+	Lines       tokens.Lines   // line offsets of Frame.File; or nil
+	Scope       *objects.Scope // function scope with parameters and named results
+	Results     []*ast.Result  // named results
+	defers      []*deferredCall
+	panic       *RuntimeError // current panic, nil if not panicking
+	recoverFrom *CallFrame    // for deferred calls: frame which panic can be recovered
+}
+
+// deferredCall is a function call deferred by defer statement.
+type deferredCall struct {
+	node *ast.CallExpression
+	f    objects.Object
+	args []objects.Object
+}
+
+// functionName returns the function name for call stack.
+func functionName(exp ast.Expression) string {
+	if _, ok := exp.(*ast.FunctionLiteral); ok {
+		return "func"
+	}
+	return exp.String()
+}
+
+// Call calls function f with evaluated arguments at the call site node.
+func (g *Goroutine) Call(ctx context.Context, node *ast.CallExpression, f objects.Object, args []objects.Object) objects.Object {
+	return g.call(ctx, node, f, args, nil)
+}
+
+// call calls function f with evaluated arguments at the call site node.
+// For deferred calls, recoverFrom is a frame which panic can be recovered by f.
+func (g *Goroutine) call(ctx context.Context, node *ast.CallExpression, f objects.Object, args []objects.Object, recoverFrom *CallFrame) objects.Object {
+	g.Current().Offset = node.Token.Offset
+	return g.callFunction(ctx, functionName(node.Function), f, args, recoverFrom)
+}
+
+// callFunction calls function f with evaluated arguments; name is used for call stack and messages.
+func (g *Goroutine) callFunction(ctx context.Context, name string, f objects.Object, args []objects.Object, recoverFrom *CallFrame) objects.Object {
+	caller := g.Current()
+	g.CheckContext(ctx)
+
+	switch f := f.(type) {
+	case *objects.Function:
+		if len(g.Frames) >= g.Config.MaxCallDepth {
+			g.Fatal(ErrMaxCallDepth)
+		}
+		if len(f.Results) > 1 {
+			g.Crash("multiple function results are not supported")
+		}
+
+		// parameters, named results and function body are in the same block
+		scope := ops.BlockScope(f.Scope, f.Body.Slots)
+		var t *objects.TypeObject
+		for n, name := range f.Parameters {
+			arg := args[n]
+			if f.Types != nil {
+				if n == 0 || f.Types[n] != f.Types[n-1] {
+					t = g.engine.ParamType(ctx, caller, f, n)
+				}
+				arg = ops.ConvertArgument(name, arg, t)
+			}
+			g.Define(scope, name, arg)
+		}
+		for n, r := range f.Results {
+			var zero objects.Object = objects.UntypedNil
+			if r.Type != nil {
+				zero = g.engine.ResultType(ctx, caller, f, n).Zero()
+			}
+			g.Define(scope, r.Name, zero)
+		}
+
+		fr := &CallFrame{
+			Frame:       Frame{Function: name, File: f.File, Offset: f.Body.Token.Offset},
+			Lines:       f.Lines,
+			Scope:       scope,
+			Results:     f.Results,
+			recoverFrom: recoverFrom,
+		}
+		g.Frames = append(g.Frames, fr)
+		res := g.Run(ctx, fr, func() objects.Object {
+			return g.engine.Body(ctx, fr, f, scope)
+		})
+		g.Frames = g.Frames[:len(g.Frames)-1]
+		return res
+
+	case *objects.GoFunction:
+		switch f {
+		case objects.Recover:
+			return g.recover(ctx, args)
+		case objects.After:
+			d := objects.Int64Value(f.Func(ctx, args...))
+			return g.Sched.After(time.Duration(d))
+		case objects.Make:
+			g.Alloc(ops.MakeSize(args))
+		}
+		res := f.Func(ctx, args...)
+
+		// the function may return early because the context is done
+		g.CheckContext(ctx)
+		if res == nil {
+			res = objects.UntypedNil
+		}
+		return res
+
+	case *objects.Nil:
+		g.Crash("invalid memory address or nil pointer dereference")
+		panic("not reached")
+
+	default:
+		g.Crash("cannot call non-function %s (type %s)", name, ops.TypeString(f))
+		panic("not reached")
+	}
+}
+
+// caller calls functions for Go functions called by the goroutine; see objects.Call.
+type caller struct {
+	g *Goroutine
+}
+
+// Call implements objects.Caller.
+func (c caller) Call(ctx context.Context, f objects.Object, args []objects.Object) (res objects.Object, err error) {
+	g := c.g
+	depth := len(g.Frames)
+	sp := len(g.Stack)
+	defer func() {
+		if p := recover(); p != nil {
+			e := g.PanicError(p)
+			if e.Fatal {
+				panic(e)
+			}
+			g.Frames = g.Frames[:depth]
+			g.Truncate(sp)
+			res, err = nil, e
+		}
+	}()
+
+	name := "func"
+	if _, ok := f.(*objects.Function); !ok {
+		name = f.String()
+	}
+	return g.callFunction(ctx, name, f, args, nil), nil
+}
+
+// Run evaluates function body in the frame fr which is on the top of the call stack,
+// then runs deferred calls in LIFO order, even if body panics.
+// It returns the body result; for functions with named results, their values after deferred calls.
+func (g *Goroutine) Run(ctx context.Context, fr *CallFrame, body func() objects.Object) (res objects.Object) {
+	depth := len(g.Frames)
+	sp := len(g.Stack)
+	var returned bool
+
+	defer func() {
+		if !returned {
+			if len(fr.defers) == 0 {
+				// panic propagates with the call stack intact
+				return
+			}
+			p := g.PanicError(recover())
+			if p.Fatal {
+				panic(p)
+			}
+			fr.panic = p
+			g.Frames = g.Frames[:depth]
+			g.Truncate(sp)
+		}
+
+		for len(fr.defers) > 0 {
+			d := fr.defers[len(fr.defers)-1]
+			fr.defers = fr.defers[:len(fr.defers)-1]
+			g.callDeferred(ctx, fr, d)
+		}
+
+		if fr.panic != nil {
+			panic(fr.panic)
+		}
+		if len(fr.Results) > 0 || !returned {
+			res = g.NamedResult(fr)
+		}
+	}()
+
+	res = body()
+	returned = true
+	return
+}
+
+// Defer adds the call of function f with arguments evaluated at defer time to frame fr.
+func (g *Goroutine) Defer(fr *CallFrame, node *ast.CallExpression, f objects.Object, args []objects.Object) {
+	fr.defers = append(fr.defers, &deferredCall{node: node, f: f, args: args})
+}
+
+// callDeferred calls deferred function of frame fr.
+// A panic in that call replaces the current panic of fr, if any.
+func (g *Goroutine) callDeferred(ctx context.Context, fr *CallFrame, d *deferredCall) {
+	depth := len(g.Frames)
+	sp := len(g.Stack)
+	defer func() {
+		if p := recover(); p != nil {
+			err := g.PanicError(p)
+			if err.Fatal {
+				panic(err)
+			}
+			fr.panic = err
+			g.Frames = g.Frames[:depth]
+			g.Truncate(sp)
+		}
+	}()
+
+	g.call(ctx, d.node, d.f, d.args, fr)
+}
+
+// NamedResult returns the value of named result of function in frame fr, or nil if there is none.
+func (g *Goroutine) NamedResult(fr *CallFrame) objects.Object {
+	if len(fr.Results) == 0 {
+		return objects.UntypedNil
+	}
+	res, _ := ops.Lookup(fr.Scope, fr.Results[0].Name)
+	return res
+}
+
+// recover implements recover builtin: it stops panicking if called directly by a deferred function.
+func (g *Goroutine) recover(ctx context.Context, args []objects.Object) objects.Object {
+	res := objects.Recover.Func(ctx, args...)
+
+	fr := g.Current().recoverFrom
+	if fr == nil || fr.panic == nil {
+		return res
+	}
+
+	// like in Go, recovered value is interface{} value
+	p := fr.panic
+	fr.panic = nil
+	res.(*objects.Interface).Value = p.Value
+	if p.Value == nil {
+		res.(*objects.Interface).Value = &objects.String{Value: p.Msg}
+	}
+	return res
+}
+
+// PanicError converts recovered Go panic value to runtime error.
+// Other panics (including exit) are re-raised.
+func (g *Goroutine) PanicError(p interface{}) *RuntimeError {
+	offset := g.Current().Offset
+	switch p := p.(type) {
+	case exit:
+		panic(p)
+	case *RuntimeError:
+		return p
+	case *objects.PanicError:
+		err := g.RuntimeError(offset, p.Error())
+		err.Value = p.Value
+		return err
+	case runtime.Error:
+		// interpreter or virtual machine bug
+		panic(p)
+	case *ops.Error:
+		return g.RuntimeError(p.Offset, p.Msg)
+	case error:
+		// Go function failed
+		return g.RuntimeError(offset, p.Error())
+	default:
+		panic(p)
+	}
+}
+
+// check interfaces
+var (
+	_ objects.Caller = caller{}
+)
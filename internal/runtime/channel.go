@@ -0,0 +1,147 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+// exit is a Go panic value used to unwind the goroutine's call stack when the program is stopped:
+// the main goroutine returned, other goroutine failed, or context is canceled.
+// Deferred calls are not run.
+type exit struct{}
+
+// Error implements error, so exit passes through Go functions that call Gosh functions.
+func (exit) Error() string { return "program stopped" }
+
+// CheckContext stops the current goroutine if ctx is done.
+// It is called on function calls and loop iterations, so scripts can't run forever.
+func (g *Goroutine) CheckContext(ctx context.Context) {
+	if ctx.Err() != nil {
+		panic(exit{})
+	}
+}
+
+// stop stops the current goroutine after blocking channel operation returned error.
+// Deadlock is reported by the main goroutine.
+func (g *Goroutine) stop(err error) {
+	if err == objects.ErrDeadlock && g.ID == 1 {
+		g.Fatal(err)
+	}
+	panic(exit{})
+}
+
+// Go starts a goroutine calling function f with arguments evaluated in the current goroutine.
+func (g *Goroutine) Go(ctx context.Context, node *ast.GoStatement, f objects.Object, args []objects.Object) {
+	// the outermost frame of a new goroutine is the go statement in the current function
+	fr := g.Current()
+	created := Frame{Function: fr.Function, File: fr.File, Offset: node.Token.Offset}
+	n := &Goroutine{
+		Config:  g.Config,
+		Sched:   g.Sched,
+		ID:      g.Sched.Go(),
+		Created: &created,
+		Frames:  []*CallFrame{{Frame: created, Lines: fr.Lines}},
+		budget:  g.budget,
+	}
+	n.engine = g.engine.Go(n)
+	go n.run(objects.WithCaller(ctx, caller{n}), node.Call, f, args)
+}
+
+// run calls function f in a new goroutine.
+// Unrecovered panic stops the whole program.
+func (g *Goroutine) run(ctx context.Context, node *ast.CallExpression, f objects.Object, args []objects.Object) {
+	defer g.Sched.Exit()
+
+	defer func() {
+		p := recover()
+		if p == nil || ctx.Err() != nil {
+			return
+		}
+		if _, ok := p.(exit); ok {
+			return
+		}
+		g.Sched.Fail(g.PanicError(p))
+	}()
+
+	g.Call(ctx, node, f, args)
+}
+
+// channelType returns the type of channel value ch, or crashes if it is not a channel.
+func (g *Goroutine) channelType(ch objects.Object, op string, exp ast.Expression) *objects.TypeObject {
+	t := objects.TypeOf(ch)
+	if t == nil || t.Kind != objects.ChannelType {
+		g.Crash("invalid operation: cannot %s non-channel %s (type %s)", op, exp, ops.TypeString(ch))
+	}
+	return t
+}
+
+// SendType checks the channel of send statement and returns its element type.
+func (g *Goroutine) SendType(node *ast.SendStatement, ch objects.Object) *objects.TypeObject {
+	t := g.channelType(ch, "send to", node.Channel)
+	if t.Dir == ast.RecvOnly {
+		g.Crash("invalid operation: cannot send to receive-only channel %s (type %s)", node.Channel, t)
+	}
+	return t.Elem
+}
+
+// ReceiveChannel checks the channel of receive expression; the returned channel is nil for nil channel.
+func (g *Goroutine) ReceiveChannel(node *ast.PrefixExpression, ch objects.Object) *objects.Channel {
+	t := g.channelType(ch, "receive from", node.Right)
+	if t.Dir == ast.SendOnly {
+		g.Crash("invalid operation: cannot receive from send-only channel %s (type %s)", node.Right, t)
+	}
+
+	c, _ := ch.(*objects.Channel)
+	return c
+}
+
+// RangeChannel checks the channel of range statement; the returned channel is nil for nil channel.
+func (g *Goroutine) RangeChannel(node *ast.RangeStatement, ch objects.Object) *objects.Channel {
+	t := g.channelType(ch, "range over", node.X)
+	if t.Dir == ast.SendOnly {
+		g.Crash("invalid operation: range %s receive from send-only channel %s", node.X, t)
+	}
+	if node.Value != nil {
+		g.Crash("range over %s permits only one iteration variable", node.X)
+	}
+
+	c, _ := ch.(*objects.Channel)
+	return c
+}
+
+// Send sends value to channel c; nil channel blocks forever.
+func (g *Goroutine) Send(ctx context.Context, c *objects.Channel, val objects.Object) {
+	if err := g.Sched.Send(ctx, c, val); err != nil {
+		g.stop(err)
+	}
+}
+
+// Receive receives a value from channel c; nil channel blocks forever.
+// It returns received value and false if it is a zero value received because the channel is closed.
+func (g *Goroutine) Receive(ctx context.Context, c *objects.Channel) (objects.Object, bool) {
+	val, ok, err := g.Sched.Recv(ctx, c)
+	if err != nil {
+		g.stop(err)
+	}
+	return val, ok
+}
+
+// Select performs select statement with the given cases; see objects.Scheduler.Select.
+// It returns the index of the chosen case, or -1 for default case, and received value with a flag.
+func (g *Goroutine) Select(ctx context.Context, cases []objects.SelectCase, block bool) (int, objects.Object, bool) {
+	n, val, ok, err := g.Sched.Select(ctx, cases, block)
+	if err != nil {
+		g.stop(err)
+	}
+	return n, val, ok
+}
@@ -0,0 +1,40 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/objects"
+)
+
+// DefaultMaxCallDepth is the default value of Config.MaxCallDepth.
+const DefaultMaxCallDepth = 10000
+
+// Config configures the interpreter and the virtual machine.
+//
+// Limits are checked for the whole program, including all goroutines, except MaxCallDepth which is per goroutine.
+// Exceeded limit stops the program with fatal *RuntimeError wrapping ErrMaxSteps, ErrMaxCallDepth or ErrMaxAlloc.
+type Config struct {
+	MaxSteps     int64 // maximum number of evaluated AST nodes or executed instructions; zero means no limit
+	MaxCallDepth int   // maximum depth of Gosh call stack; zero means DefaultMaxCallDepth
+	MaxAlloc     int64 // maximum number of bytes allocated for strings and slices (approximately); zero means no limit
+
+	Importer Importer // imports modules for import statements; if nil, they fail
+}
+
+// Importer imports modules for import statements of the interpreter and the virtual machine.
+type Importer interface {
+	// Import returns module with given import path, imported by the program from the given file
+	// (empty for programs without files). Relative paths like "./lib/util.gosh" are relative to that file's directory.
+	// The same module should be returned for repeated imports.
+	//
+	// Runtime error of module initialization should be returned as *RuntimeError;
+	// it is reported as is. Other errors are reported at the import spec.
+	Import(ctx context.Context, path, file string) (*objects.Module, error)
+}
@@ -0,0 +1,13 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package runtime implements Gosh goroutines shared by the interpreter and the virtual machine:
+// call stacks, function calls, deferred calls, panics, goroutines, channel operations, imports and limits.
+//
+// Each engine implements Engine for evaluating function bodies and types;
+// everything else is done by Goroutine. Exported types are re-exported by package interpreter.
+package runtime // import "gosh-lang.org/gosh/internal/runtime"
@@ -0,0 +1,63 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Frame is a Gosh call stack frame.
+type Frame struct {
+	Function string // function name; "main" for the program itself
+	File     string // source file of that function; empty for programs without files
+	Offset   int    // byte offset of the current statement or call site in that function
+}
+
+// Errors of fatal runtime errors caused by exceeded Config limits.
+var (
+	ErrMaxSteps     = errors.New("step limit exceeded")
+	ErrMaxCallDepth = errors.New("stack overflow")
+	ErrMaxAlloc     = errors.New("memory limit exceeded")
+)
+
+// RuntimeError is a Gosh runtime error.
+// It is also used for Gosh panics: Value is a value passed to panic builtin, and nil for runtime errors.
+// Fatal errors (like deadlock or exceeded limits) can't be recovered, and deferred calls are not run for them.
+// Static errors (like undefined names or constant overflows) are reported before the program is executed.
+type RuntimeError struct {
+	Offset    int             // byte offset of the statement or expression where error occurred
+	Position  tokens.Position // position of Offset in the source file; Line is 0 if unknown
+	Msg       string          // error message
+	Stack     []Frame         // Gosh call stack, innermost call first
+	Goroutine int             // goroutine ID; 1 for the main goroutine
+	Value     objects.Object  // panic value
+	Fatal     bool            // true for fatal errors
+	Static    bool            // true for errors reported before execution, like undefined names
+	Err       error           // cause of fatal error (objects.ErrDeadlock, ErrMaxSteps, etc.), or nil
+}
+
+func (e *RuntimeError) Error() string {
+	if e.Position.Line == 0 {
+		return fmt.Sprintf("%d: %s", e.Offset, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Position, e.Msg)
+}
+
+// Unwrap returns the cause of fatal error, so it can be checked with errors.Is.
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// check interfaces
+var (
+	_ error = (*RuntimeError)(nil)
+)
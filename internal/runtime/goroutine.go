@@ -0,0 +1,154 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Engine evaluates Gosh code of a goroutine: the interpreter or the virtual machine.
+type Engine interface {
+	// ParamType returns the type of parameter n of function f with typed parameters.
+	// It is evaluated in the scope of f with caller's frame on the top of the call stack.
+	ParamType(ctx context.Context, caller *CallFrame, f *objects.Function, n int) *objects.TypeObject
+
+	// ResultType returns the type of named result n of function f; it is called only for results with types.
+	ResultType(ctx context.Context, caller *CallFrame, f *objects.Function, n int) *objects.TypeObject
+
+	// Body evaluates the body of function f in frame fr and function scope with parameters and named results,
+	// and returns its result.
+	Body(ctx context.Context, fr *CallFrame, f *objects.Function, scope *objects.Scope) objects.Object
+
+	// Go returns a new engine for goroutine g started by go statement.
+	Go(g *Goroutine) Engine
+}
+
+// Goroutine is a Gosh goroutine.
+type Goroutine struct {
+	Config  *Config
+	Sched   *objects.Scheduler // shared by all goroutines of the program
+	ID      int                // goroutine ID; 1 for the main goroutine
+	Created *Frame             // go statement which started this goroutine; nil for the main goroutine
+	Frames  []*CallFrame       // Gosh call stack, outermost call first
+	Stack   []objects.Object   // values of active calls: arguments and, for the virtual machine, operands
+	budget  *budget            // shared by all goroutines of the program
+	engine  Engine
+}
+
+// New creates a new main goroutine evaluated by engine.
+func New(config *Config, engine Engine) *Goroutine {
+	if config == nil {
+		config = new(Config)
+	}
+	if config.MaxCallDepth == 0 {
+		c := *config
+		c.MaxCallDepth = DefaultMaxCallDepth
+		config = &c
+	}
+
+	return &Goroutine{
+		Config: config,
+		engine: engine,
+	}
+}
+
+// Main evaluates body of the program's main function from the given file in the main goroutine.
+// Goroutines started by it are stopped when it returns.
+// Runtime errors, including unrecovered panics in other goroutines, are returned as *RuntimeError.
+//
+// Evaluation is stopped when ctx is done; in that case ctx.Err() is returned
+// (context.Canceled or context.DeadlineExceeded), and deferred calls are not run.
+func (g *Goroutine) Main(ctx context.Context, file string, lines tokens.Lines, body func(ctx context.Context) objects.Object) (res objects.Object, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = objects.WithCaller(ctx, caller{g})
+
+	g.Sched = objects.NewScheduler(cancel)
+	g.budget = new(budget)
+	g.ID = 1
+	g.Frames = []*CallFrame{{Frame: Frame{Function: "main", File: file}, Lines: lines}}
+	g.Truncate(0)
+
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(exit); ok {
+				if err = g.Sched.Err(); err == nil {
+					err = ctx.Err()
+				}
+				res = nil
+				return
+			}
+			res, err = nil, g.PanicError(p)
+			return
+		}
+
+		if e := g.Sched.Err(); e != nil {
+			res, err = nil, e
+		}
+	}()
+
+	res = body(ctx)
+	return
+}
+
+// Current returns the frame of the current function call.
+func (g *Goroutine) Current() *CallFrame {
+	return g.Frames[len(g.Frames)-1]
+}
+
+// Crash stops execution with a runtime error at the current statement.
+func (g *Goroutine) Crash(format string, a ...interface{}) {
+	var offset int
+	if len(g.Frames) > 0 {
+		offset = g.Current().Offset
+	}
+	g.CrashAt(offset, format, a...)
+}
+
+// CrashAt stops execution with a runtime error at the given byte offset.
+func (g *Goroutine) CrashAt(offset int, format string, a ...interface{}) {
+	panic(g.RuntimeError(offset, fmt.Sprintf(format, a...)))
+}
+
+// RuntimeError returns a runtime error with a copy of the current call stack.
+func (g *Goroutine) RuntimeError(offset int, msg string) *RuntimeError {
+	stack := make([]Frame, len(g.Frames))
+	for n, f := range g.Frames {
+		stack[len(stack)-1-n] = f.Frame
+	}
+	if g.Created != nil {
+		stack[len(stack)-1] = *g.Created
+	}
+	if len(stack) > 0 {
+		stack[0].Offset = offset
+	}
+	fr := g.Current()
+	return &RuntimeError{
+		Offset:    offset,
+		Position:  fr.Lines.Position(fr.File, offset),
+		Msg:       msg,
+		Stack:     stack,
+		Goroutine: g.ID,
+	}
+}
+
+// Truncate removes values from the stack above sp.
+func (g *Goroutine) Truncate(sp int) {
+	for n := sp; n < len(g.Stack); n++ {
+		g.Stack[n] = nil
+	}
+	g.Stack = g.Stack[:sp]
+}
@@ -0,0 +1,45 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+// Import imports modules of import statement node into the given scope.
+func (g *Goroutine) Import(ctx context.Context, node *ast.ImportStatement, scope *objects.Scope) {
+	fr := g.Current()
+	for _, spec := range node.Specs {
+		fr.Offset = spec.Path.Token.Offset
+		m := g.importModule(ctx, spec.Path.Value, fr.File)
+		if msg := ops.DefineModule(scope, spec, m); msg != "" {
+			g.Crash("%s", msg)
+		}
+	}
+}
+
+// importModule imports module with given path from file with the configured importer.
+func (g *Goroutine) importModule(ctx context.Context, path, file string) *objects.Module {
+	if g.Config.Importer == nil {
+		g.Crash("could not import %s (no importer)", path)
+	}
+
+	m, err := g.Config.Importer.Import(ctx, path, file)
+	if err != nil {
+		g.CheckContext(ctx)
+		if e, ok := err.(*RuntimeError); ok {
+			panic(e)
+		}
+		g.Crash("could not import %s (%s)", path, err)
+	}
+	return m
+}
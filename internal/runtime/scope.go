@@ -0,0 +1,57 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package runtime
+
+import (
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Define declares a named entity in the given scope. Blank identifier is not declared.
+func (g *Goroutine) Define(scope *objects.Scope, id *ast.Identifier, obj objects.Object) {
+	if !ops.Define(scope, id, obj) {
+		g.Crash("%s redeclared in this block", id.Value)
+	}
+}
+
+// Assign assigns a new value to the declared variable. Assignment to blank identifier discards the value.
+func (g *Goroutine) Assign(scope *objects.Scope, id *ast.Identifier, obj objects.Object) {
+	if !ops.Assign(scope, id, obj) {
+		g.Crash("undefined: %s", id.Value)
+	}
+}
+
+// AssignValues assigns values to variables on the left side of assignment statement node.
+func (g *Goroutine) AssignValues(node *ast.AssignStatement, values []objects.Object, scope *objects.Scope) {
+	names := append([]*ast.Identifier{node.Name.(*ast.Identifier)}, node.Rest...)
+
+	var declared bool
+	for n, name := range names {
+		if name.Value == "_" {
+			continue
+		}
+
+		// := redeclares variables declared in the same scope
+		if node.Token.Type == tokens.Define && ops.Define(scope, name, values[n]) {
+			declared = true
+			continue
+		}
+
+		old, ok := ops.Lookup(scope, name)
+		if !ok {
+			g.Crash("undefined: %s", name.Value)
+		}
+		g.Assign(scope, name, ops.ConvertAssigned(node.Value, values[n], objects.TypeOf(old)))
+	}
+
+	if node.Token.Type == tokens.Define && !declared {
+		g.Crash("no new variables on left side of :=")
+	}
+}
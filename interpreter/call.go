@@ -0,0 +1,81 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/internal/runtime"
+	"gosh-lang.org/gosh/objects"
+)
+
+// engine evaluates function bodies and types for the goroutine of the interpreter.
+type engine struct {
+	i *Interpreter
+}
+
+// ParamType implements runtime.Engine.
+func (e engine) ParamType(ctx context.Context, caller *runtime.CallFrame, f *objects.Function, n int) *objects.TypeObject {
+	return e.i.evalType(ctx, f.Types[n], f.Scope)
+}
+
+// ResultType implements runtime.Engine.
+func (e engine) ResultType(ctx context.Context, caller *runtime.CallFrame, f *objects.Function, n int) *objects.TypeObject {
+	return e.i.evalType(ctx, f.Results[n].Type, f.Scope)
+}
+
+// Body implements runtime.Engine.
+func (e engine) Body(ctx context.Context, fr *runtime.CallFrame, f *objects.Function, scope *objects.Scope) objects.Object {
+	res := e.i.evalBlockStatement(ctx, f.Body, scope)
+	if r, ok := res.(*objects.Return); ok {
+		return r.Value
+	}
+	return res
+}
+
+// Go implements runtime.Engine.
+func (e engine) Go(g *runtime.Goroutine) runtime.Engine {
+	return engine{&Interpreter{g: g}}
+}
+
+func (i *Interpreter) evalReturnStatement(ctx context.Context, node *ast.ReturnStatement, scope *objects.Scope) objects.Object {
+	fr := i.g.Current()
+	if node.Value == nil {
+		return &objects.Return{Value: i.g.NamedResult(fr)}
+	}
+
+	if len(fr.Results) == 0 {
+		return &objects.Return{Value: i.eval(ctx, node.Value, scope)}
+	}
+
+	// assign value to named result, so deferred calls can modify it
+	name := fr.Results[0].Name
+	old, _ := ops.Lookup(fr.Scope, name)
+	val := i.evalAssigned(ctx, node.Value, objects.TypeOf(old), scope)
+	ops.Assign(fr.Scope, name, val)
+	return &objects.Return{Value: val}
+}
+
+func (i *Interpreter) evalDeferStatement(ctx context.Context, node *ast.DeferStatement, scope *objects.Scope) objects.Object {
+	// function value and arguments are evaluated at defer time
+	f := i.eval(ctx, node.Call.Function, scope)
+	if _, ok := f.(*objects.TypeObject); ok {
+		i.g.Crash("defer requires function call, not conversion")
+	}
+	args := i.evalExpressions(ctx, node.Call.Arguments, scope)
+
+	i.g.Defer(i.g.Current(), node.Call, f, args)
+	return objects.UntypedNil
+}
+
+// check interfaces
+var (
+	_ runtime.Engine = engine{}
+)
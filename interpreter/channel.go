@@ -0,0 +1,113 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+func (i *Interpreter) evalGoStatement(ctx context.Context, node *ast.GoStatement, scope *objects.Scope) objects.Object {
+	// function value and arguments are evaluated in the current goroutine
+	f := i.eval(ctx, node.Call.Function, scope)
+	if _, ok := f.(*objects.TypeObject); ok {
+		i.g.Crash("go requires function call, not conversion")
+	}
+	args := i.evalExpressions(ctx, node.Call.Arguments, scope)
+
+	i.g.Go(ctx, node, f, args)
+	return objects.UntypedNil
+}
+
+// evalSendCase evaluates channel and value of send statement; the returned channel is nil for nil channel.
+func (i *Interpreter) evalSendCase(ctx context.Context, node *ast.SendStatement, scope *objects.Scope) (*objects.Channel, objects.Object) {
+	ch := i.eval(ctx, node.Channel, scope)
+	t := i.g.SendType(node, ch)
+	val := i.evalAssigned(ctx, node.Value, t, scope)
+
+	c, _ := ch.(*objects.Channel)
+	return c, val
+}
+
+// evalReceiveCase evaluates channel of receive expression; the returned channel is nil for nil channel.
+func (i *Interpreter) evalReceiveCase(ctx context.Context, node *ast.PrefixExpression, scope *objects.Scope) *objects.Channel {
+	return i.g.ReceiveChannel(node, i.eval(ctx, node.Right, scope))
+}
+
+func (i *Interpreter) evalSendStatement(ctx context.Context, node *ast.SendStatement, scope *objects.Scope) objects.Object {
+	// nil channel blocks forever
+	c, val := i.evalSendCase(ctx, node, scope)
+	i.g.Send(ctx, c, val)
+	return objects.UntypedNil
+}
+
+// evalReceive evaluates receive expression `<-ch`.
+// It returns received value and false if it is a zero value received because the channel is closed.
+func (i *Interpreter) evalReceive(ctx context.Context, node *ast.PrefixExpression, scope *objects.Scope) (objects.Object, bool) {
+	// nil channel blocks forever
+	c := i.evalReceiveCase(ctx, node, scope)
+	return i.g.Receive(ctx, c)
+}
+
+func (i *Interpreter) evalSelectStatement(ctx context.Context, node *ast.SelectStatement, scope *objects.Scope) objects.Object {
+	// all channels and sent values are evaluated once, in source order
+	var def *ast.CommClause
+	clauses := make([]*ast.CommClause, 0, len(node.Cases))
+	cases := make([]objects.SelectCase, 0, len(node.Cases))
+	for _, c := range node.Cases {
+		if c.Comm == nil {
+			if def != nil {
+				i.g.Crash("multiple defaults in select")
+			}
+			def = c
+			continue
+		}
+
+		var sc objects.SelectCase
+		if s, ok := c.Comm.(*ast.SendStatement); ok {
+			sc.Chan, sc.Value = i.evalSendCase(ctx, s, scope)
+			sc.Send = true
+		} else {
+			recv := ops.ReceiveExpression(c.Comm)
+			if recv == nil {
+				i.g.Crash("select case must be receive, send or assign recv")
+			}
+			sc.Chan = i.evalReceiveCase(ctx, recv, scope)
+		}
+		clauses = append(clauses, c)
+		cases = append(cases, sc)
+	}
+
+	n, val, ok := i.g.Select(ctx, cases, def == nil)
+
+	clause := def
+	if n >= 0 {
+		clause = clauses[n]
+	}
+
+	// each clause is an implicit block
+	s := ops.BlockScope(scope, clause.Slots)
+	if a, isAssign := clause.Comm.(*ast.AssignStatement); isAssign {
+		i.g.AssignValues(a, ops.ReceivedValues(a, val, ok), s)
+	}
+	return i.evalStatements(ctx, clause.Body, s)
+}
+
+// rangeChannel receives values from channel ch until it is closed, and calls iterate for each of them.
+func (i *Interpreter) rangeChannel(ctx context.Context, node *ast.RangeStatement, ch objects.Object, iterate func(key, value objects.Object) bool) {
+	c := i.g.RangeChannel(node, ch)
+	for {
+		val, ok := i.g.Receive(ctx, c)
+		if !ok || !iterate(val, nil) {
+			return
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+// evalInfixOperands evaluates operands of binary operation on at most one constant.
+// Constant operand takes the type of other operand if it can be represented by that type.
+func (i *Interpreter) evalInfixOperands(ctx context.Context, node *ast.InfixExpression, scope *objects.Scope) (objects.Object, objects.Object) {
+	lc, rc := ops.IsConstant(node.Left), ops.IsConstant(node.Right)
+	if !lc && !rc {
+		return i.eval(ctx, node.Left, scope), i.eval(ctx, node.Right, scope)
+	}
+
+	if lc {
+		right := i.eval(ctx, node.Right, scope)
+		return ops.ConstantOperand(node, node.Left, right), right
+	}
+	left := i.eval(ctx, node.Left, scope)
+	return left, ops.ConstantOperand(node, node.Right, left)
+}
@@ -0,0 +1,37 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter_test
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/optimizer"
+	"gosh-lang.org/gosh/vm"
+)
+
+func init() {
+	interpreter.Engines["vm"] = func(ctx context.Context, program *ast.Program, scope *objects.Scope, config *interpreter.Config) (objects.Object, error) {
+		code, err := compiler.Compile(program)
+		if err != nil {
+			return nil, err
+		}
+		return vm.New(config).Run(ctx, code, scope)
+	}
+
+	// optimized programs should give the same results with both engines
+	interpreter.Engines["optimizer"] = func(ctx context.Context, program *ast.Program, scope *objects.Scope, config *interpreter.Config) (objects.Object, error) {
+		return interpreter.New(config).Eval(ctx, optimizer.Optimize(program, scope), scope)
+	}
+	interpreter.Engines["optimizer+vm"] = func(ctx context.Context, program *ast.Program, scope *objects.Scope, config *interpreter.Config) (objects.Object, error) {
+		return interpreter.Engines["vm"](ctx, optimizer.Optimize(program, scope), scope, config)
+	}
+}
@@ -0,0 +1,37 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter
+
+import (
+	"gosh-lang.org/gosh/internal/runtime"
+	"gosh-lang.org/gosh/objects"
+)
+
+// Frame is a Gosh call stack frame: function name, its source file, and byte offset
+// of the current statement or call site in that file.
+type Frame = runtime.Frame
+
+// Errors of fatal runtime errors caused by exceeded Config limits.
+var (
+	ErrMaxSteps     = runtime.ErrMaxSteps
+	ErrMaxCallDepth = runtime.ErrMaxCallDepth
+	ErrMaxAlloc     = runtime.ErrMaxAlloc
+)
+
+// RuntimeError is a Gosh runtime error, also used for Gosh panics.
+// Fatal errors (like deadlock or exceeded limits) can't be recovered, and deferred calls are not run for them.
+// Static errors (like undefined names or constant overflows) are reported before the program is executed.
+type RuntimeError = runtime.RuntimeError
+
+// Must is a helper that wraps a call to Eval and panics if the error is non-nil.
+func Must(res objects.Object, err error) objects.Object {
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
@@ -9,76 +9,112 @@ package interpreter
 
 import (
 	"context"
-	"fmt"
 
 	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/internal/runtime"
 	"gosh-lang.org/gosh/objects"
 	"gosh-lang.org/gosh/tokens"
 )
 
 // Interpreter evaluates Gosh AST nodes.
 type Interpreter struct {
-	config *Config
+	g *runtime.Goroutine // the goroutine evaluated by the interpreter
 }
 
+// DefaultMaxCallDepth is the default value of Config.MaxCallDepth.
+const DefaultMaxCallDepth = runtime.DefaultMaxCallDepth
+
 // Config configures interpreter.
-type Config struct {
-}
+//
+// Limits are checked for the whole program, including all goroutines, except MaxCallDepth which is per goroutine.
+// Exceeded limit stops the program with fatal *RuntimeError wrapping ErrMaxSteps, ErrMaxCallDepth or ErrMaxAlloc.
+// For the interpreter, MaxSteps is the maximum number of evaluated AST nodes.
+type Config = runtime.Config
+
+// Importer imports modules for import statements of the interpreter and the virtual machine.
+type Importer = runtime.Importer
 
 // New creates a new interpreter.
 func New(config *Config) *Interpreter {
-	if config == nil {
-		config = new(Config)
-	}
-
-	return &Interpreter{
-		config: config,
-	}
+	i := new(Interpreter)
+	i.g = runtime.New(config, engine{i})
+	return i
 }
 
-func (i *Interpreter) crash(format string, a ...interface{}) {
-	msg := fmt.Sprintf(format, a...)
-	panic(msg)
+// Eval evaluates given node in the given scope in the main goroutine.
+// Goroutines started by it are stopped when it returns.
+// Runtime errors, including unrecovered panics in other goroutines, are returned as *RuntimeError.
+//
+// Evaluation is stopped when ctx is done; in that case ctx.Err() is returned
+// (context.Canceled or context.DeadlineExceeded), and deferred calls are not run.
+func (i *Interpreter) Eval(ctx context.Context, node ast.Node, scope *objects.Scope) (objects.Object, error) {
+	return i.g.Main(ctx, "", nil, func(ctx context.Context) objects.Object {
+		return i.eval(ctx, node, scope)
+	})
 }
 
-// Eval evaluates given node in the given scope.
-func (i *Interpreter) Eval(ctx context.Context, node ast.Node, scope *objects.Scope) objects.Object {
-	if ctx.Err() != nil {
-		// FIXME return error
-		return nil
-	}
+// eval evaluates given node in the given scope.
+func (i *Interpreter) eval(ctx context.Context, node ast.Node, scope *objects.Scope) objects.Object {
+	i.g.Step()
 
 	switch node := node.(type) {
 	case *ast.Program:
-		var res objects.Object
-		for _, s := range node.Statements {
-			res = i.Eval(ctx, s, scope)
+		// the program is the body of main function
+		fr := i.g.Current()
+		fr.File, fr.Lines = node.File, node.Lines
+
+		if offset, msg := ops.Check(node, scope); msg != "" {
+			e := i.g.RuntimeError(offset, msg)
+			e.Static = true
+			panic(e)
 		}
-		return res
-
-	case *ast.BlockStatement:
-		var res objects.Object
-		for _, s := range node.Statements {
-			res = i.Eval(ctx, s, scope)
-			if res != nil {
-				switch res.Type() {
-				case objects.ContinueType:
-					return res
+		scope = ops.ProgramScope(node, scope)
+		return i.g.Run(ctx, fr, func() objects.Object {
+			var res objects.Object = objects.UntypedNil
+			for _, s := range node.Statements {
+				fr.Offset = ops.StatementOffset(s)
+				res = i.eval(ctx, s, scope)
+				if r, ok := res.(*objects.Return); ok {
+					return r.Value
 				}
 			}
-		}
-		return res
+			return res
+		})
+
+	case *ast.BlockStatement:
+		return i.evalBlockStatement(ctx, node, ops.BlockScope(scope, node.Slots))
 
 	case *ast.ExpressionStatement:
-		return i.Eval(ctx, node.Expression, scope)
+		if node.Expression == nil {
+			return objects.UntypedNil
+		}
+		if call, ok := node.Expression.(*ast.CallExpression); ok {
+			return ops.SingleValue(call, i.evalCallExpression(ctx, call, scope), true)
+		}
+		return i.eval(ctx, node.Expression, scope)
 
 	case *ast.ReturnStatement:
-		return i.Eval(ctx, node.Value, scope)
+		return i.evalReturnStatement(ctx, node, scope)
+
+	case *ast.DeferStatement:
+		return i.evalDeferStatement(ctx, node, scope)
+
+	case *ast.GoStatement:
+		return i.evalGoStatement(ctx, node, scope)
+
+	case *ast.SendStatement:
+		return i.evalSendStatement(ctx, node, scope)
+
+	case *ast.SelectStatement:
+		return i.evalSelectStatement(ctx, node, scope)
 
 	case *ast.VarStatement:
-		val := i.Eval(ctx, node.Value, scope)
-		scope.Set(node.Name.Value, val)
-		return nil
+		return i.evalVarStatement(ctx, node, scope)
+
+	case *ast.TypeStatement:
+		i.g.Define(scope, node.Name, objects.NewDefinedType(node.Name.Value, i.evalType(ctx, node.Type, scope)))
+		return objects.UntypedNil
 
 	case *ast.AssignStatement:
 		return i.evalAssignStatement(ctx, node, scope)
@@ -86,39 +122,58 @@ func (i *Interpreter) Eval(ctx context.Context, node ast.Node, scope *objects.Sc
 	case *ast.ForStatement:
 		return i.evalForStatement(ctx, node, scope)
 
+	case *ast.RangeStatement:
+		return i.evalRangeStatement(ctx, node, scope)
+
 	case *ast.IfStatement:
 		return i.evalIfStatement(ctx, node, scope)
 
 	case *ast.IncrementDecrementStatement:
-		return i.evalIncrementDecrementStatement(node, scope)
+		return i.evalIncrementDecrementStatement(ctx, node, scope)
 
 	case *ast.ContinueStatement:
 		return &objects.Continue{}
 
+	case *ast.ImportStatement:
+		i.g.Import(ctx, node, scope)
+		return objects.UntypedNil
+
 	case *ast.Identifier:
-		val, ok := scope.Lookup(node.Value)
+		val, ok := ops.Lookup(scope, node)
 		if !ok {
-			i.crash("identifier not found: %s", node.Value)
+			i.g.Crash("identifier not found: %s", node.Value)
 		}
 		return val
 
 	case *ast.PrefixExpression:
-		right := i.Eval(ctx, node.Right, scope)
-		return i.evalPrefixExpression(node.Token.Literal, right)
+		if node.Token.Type == tokens.Arrow {
+			val, _ := i.evalReceive(ctx, node, scope)
+			return val
+		}
+		if ops.IsConstant(node) {
+			return ops.Untyped(node)
+		}
+		right := i.eval(ctx, node.Right, scope)
+		return ops.Unary(node.Token.Literal, right)
 
 	case *ast.InfixExpression:
-		left := i.Eval(ctx, node.Left, scope)
-		right := i.Eval(ctx, node.Right, scope)
-		return i.evalInfixExpression(node.Token.Literal, left, right)
-
-	case *ast.IntegerLiteral:
-		return &objects.Integer{Value: node.Value}
+		if ops.IsConstant(node) {
+			return ops.Untyped(node)
+		}
+		switch node.Token.Type {
+		case tokens.LogicalAnd, tokens.LogicalOr:
+			return i.evalLogicalExpression(ctx, node, scope)
+		case tokens.ShiftLeft, tokens.ShiftRight:
+			return i.evalShiftExpression(ctx, node, scope)
+		}
+		left, right := i.evalInfixOperands(ctx, node, scope)
+		return i.evalInfixExpression(node, node.Token.Literal, left, right)
 
-	case *ast.FloatLiteral:
-		return &objects.Float{Value: node.Value}
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.ImaginaryLiteral, *ast.RuneLiteral, *ast.Constant:
+		return ops.Untyped(node.(ast.Expression))
 
 	case *ast.BooleanLiteral:
-		return &objects.Boolean{Value: node.Value}
+		return objects.NewBoolean(node.Value)
 
 	case *ast.StringLiteral:
 		return &objects.String{Value: node.Value}
@@ -126,251 +181,466 @@ func (i *Interpreter) Eval(ctx context.Context, node ast.Node, scope *objects.Sc
 	case *ast.FunctionLiteral:
 		return &objects.Function{
 			Parameters: node.Parameters,
+			Types:      node.Types,
+			Results:    node.Results,
 			Body:       node.Body,
 			Scope:      scope,
+			File:       i.g.Current().File,
+			Lines:      i.g.Current().Lines,
 		}
 
 	case *ast.CallExpression:
-		return i.evalCallExpression(ctx, node, scope)
+		return ops.SingleValue(node, i.evalCallExpression(ctx, node, scope), false)
+
+	case *ast.SelectorExpression:
+		return ops.Select(node, i.eval(ctx, node.X, scope))
+
+	case *ast.IndexExpression:
+		return i.evalIndexExpression(ctx, node, scope)
+
+	case *ast.SliceExpression:
+		return i.evalSliceExpression(ctx, node, scope)
+
+	case *ast.SliceType, *ast.MapType, *ast.PointerType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return i.evalType(ctx, node.(ast.Expression), scope)
 
 	default:
-		i.crash("unexpected node %T:\n%#v", node, node)
+		i.g.Crash("unexpected node %T:\n%#v", node, node)
 		panic("not reached")
 	}
 }
 
-func (i *Interpreter) evalPrefixExpression(operator string, right objects.Object) objects.Object {
-	switch operator {
-	case "!":
-		if b, ok := right.(*objects.Boolean); ok {
-			return &objects.Boolean{Value: !b.Value}
+// evalBlockStatement evaluates block statements in the given scope.
+// Callers create a new scope for the block if needed.
+func (i *Interpreter) evalBlockStatement(ctx context.Context, node *ast.BlockStatement, scope *objects.Scope) objects.Object {
+	return i.evalStatements(ctx, node.Statements, scope)
+}
+
+// evalStatements evaluates statements of a block or a case clause in the given scope.
+func (i *Interpreter) evalStatements(ctx context.Context, statements []ast.Statement, scope *objects.Scope) objects.Object {
+	var res objects.Object = objects.UntypedNil
+	for _, s := range statements {
+		i.g.Current().Offset = ops.StatementOffset(s)
+		res = i.eval(ctx, s, scope)
+		if t := res.Type(); t == objects.ContinueType || t == objects.ReturnType {
+			return res
 		}
-		i.crash("prefix expression operator ! on %T:\n%#v", right, right)
+	}
+	return res
+}
 
-	case "-":
-		if i, ok := right.(*objects.Integer); ok {
-			return &objects.Integer{Value: -i.Value}
+// define declares a named entity in the given scope. Blank identifier is not declared.
+func (i *Interpreter) define(scope *objects.Scope, id *ast.Identifier, obj objects.Object) {
+	if !ops.Define(scope, id, obj) {
+		i.g.Crash("%s redeclared in this block", id.Value)
+	}
+}
+
+// assign assigns a new value to the declared variable. Assignment to blank identifier discards the value.
+func (i *Interpreter) assign(scope *objects.Scope, id *ast.Identifier, obj objects.Object) {
+	if !ops.Assign(scope, id, obj) {
+		i.g.Crash("undefined: %s", id.Value)
+	}
+}
+
+// evalInfixExpression evaluates binary operation on operand values; node is used in error messages, if not nil.
+func (i *Interpreter) evalInfixExpression(node ast.Expression, operator string, left, right objects.Object) objects.Object {
+	if operator == "+" {
+		i.g.Alloc(ops.ConcatSize(left, right))
+	}
+	return ops.Binary(node, operator, left, right)
+}
+
+// evalLogicalExpression evaluates && and || operators.
+// The right operand is evaluated only if the left operand does not determine the result.
+func (i *Interpreter) evalLogicalExpression(ctx context.Context, node *ast.InfixExpression, scope *objects.Scope) objects.Object {
+	operator := node.Token.Literal
+
+	var left objects.Object
+	if ops.IsConstant(node.Left) {
+		left = ops.Untyped(node.Left)
+	} else {
+		left = i.eval(ctx, node.Left, scope)
+	}
+	b, ok := ops.Underlying(left).(*objects.Boolean)
+	if !ok {
+		i.g.Crash("invalid operation: operator %s not defined on %s (type %s)", operator, node.Left, ops.TypeString(left))
+	}
+	if b.Value == (operator == "||") {
+		return left
+	}
+
+	var right objects.Object
+	if ops.IsConstant(node.Right) {
+		right = ops.ConstantOperand(node, node.Right, left)
+	} else {
+		right = i.eval(ctx, node.Right, scope)
+		if ops.IsConstant(node.Left) {
+			left = ops.ConstantOperand(node, node.Left, right)
 		}
-		i.crash("prefix expression operator - on %T:\n%#v", right, right)
+	}
+	return i.evalInfixExpression(node, operator, left, right)
+}
 
-	default:
-		i.crash("unhandled prefix expression operator %s", operator)
+// evalShiftExpression evaluates << and >> operators.
+// The result has the type of the left operand; untyped constant left operand takes its default type.
+func (i *Interpreter) evalShiftExpression(ctx context.Context, node *ast.InfixExpression, scope *objects.Scope) objects.Object {
+	var left objects.Object
+	if ops.IsConstant(node.Left) {
+		left = ops.Untyped(node.Left)
+	} else {
+		left = i.eval(ctx, node.Left, scope)
 	}
-	panic("not reached")
+	count := i.eval(ctx, node.Right, scope)
+	return ops.Shift(node, left, count)
 }
 
-func (i *Interpreter) evalInfixIntegerExpression(operator string, left, right int) objects.Object {
-	switch operator {
-	case "+":
-		return &objects.Integer{Value: left + right}
-	case "-":
-		return &objects.Integer{Value: left - right}
-	case "*":
-		return &objects.Integer{Value: left * right}
-	case "/":
-		return &objects.Integer{Value: left / right}
-	case "%":
-		return &objects.Integer{Value: left % right}
-
-	case "<":
-		return &objects.Boolean{Value: left < right}
-	case "<=":
-		return &objects.Boolean{Value: left <= right}
-	case ">":
-		return &objects.Boolean{Value: left > right}
-	case ">=":
-		return &objects.Boolean{Value: left >= right}
-	case "==":
-		return &objects.Boolean{Value: left == right}
-	case "!=":
-		return &objects.Boolean{Value: left != right}
+func (i *Interpreter) evalExpressions(ctx context.Context, exps []ast.Expression, scope *objects.Scope) []objects.Object {
+	res := make([]objects.Object, len(exps))
+	for n, e := range exps {
+		res[n] = i.eval(ctx, e, scope)
+	}
+	return res
+}
 
+func (i *Interpreter) evalVarStatement(ctx context.Context, node *ast.VarStatement, scope *objects.Scope) objects.Object {
+	var t *objects.TypeObject
+	if node.Type != nil {
+		t = i.evalType(ctx, node.Type, scope)
+	}
+
+	var val objects.Object
+	switch {
+	case node.Value == nil:
+		val = t.Zero()
 	default:
-		i.crash("unhandled infix expression operator %s for two Integers", operator)
-		panic("not reached")
+		val = i.evalAssigned(ctx, node.Value, t, scope)
+	}
+
+	if n, ok := val.(*objects.Nil); ok && n.T == nil {
+		i.g.Crash("use of untyped nil")
 	}
+
+	i.g.Define(scope, node.Name, val)
+	return objects.UntypedNil
 }
 
-func (i *Interpreter) evalInfixFloatExpression(operator string, left, right float64) objects.Object {
-	switch operator {
-	case "+":
-		return &objects.Float{Value: left + right}
-	case "-":
-		return &objects.Float{Value: left - right}
-	case "*":
-		return &objects.Float{Value: left * right}
-	case "/":
-		return &objects.Float{Value: left / right}
-
-	case "<":
-		return &objects.Boolean{Value: left < right}
-	case "<=":
-		return &objects.Boolean{Value: left <= right}
-	case ">":
-		return &objects.Boolean{Value: left > right}
-	case ">=":
-		return &objects.Boolean{Value: left >= right}
-	case "==":
-		return &objects.Boolean{Value: left == right}
-	case "!=":
-		return &objects.Boolean{Value: left != right}
+func (i *Interpreter) evalAssignStatement(ctx context.Context, node *ast.AssignStatement, scope *objects.Scope) objects.Object {
+	if node.Rest != nil {
+		return i.evalMultiAssignStatement(ctx, node, scope)
+	}
 
-	default:
-		i.crash("unhandled infix expression operator %s for two Floats", operator)
-		panic("not reached")
+	name, ok := node.Name.(*ast.Identifier)
+	if !ok {
+		return i.evalAssignAddressable(ctx, node, scope)
 	}
+
+	if node.Token.Type == tokens.Define {
+		val := i.evalAssigned(ctx, node.Value, nil, scope)
+		if n, ok := val.(*objects.Nil); ok && n.T == nil {
+			i.g.Crash("use of untyped nil in assignment")
+		}
+		if name.Value == "_" || !ops.Define(scope, name, val) {
+			i.g.Crash("no new variables on left side of :=")
+		}
+		return objects.UntypedNil
+	}
+
+	exp := node.Value
+	if node.Token.Type != tokens.Assignment {
+		exp = ops.CompoundExpression(node)
+	}
+
+	var t *objects.TypeObject
+	if name.Value != "_" {
+		old, ok := ops.Lookup(scope, name)
+		if !ok {
+			i.g.Crash("undefined: %s", name.Value)
+		}
+		t = objects.TypeOf(old)
+	}
+	val := i.evalAssigned(ctx, exp, t, scope)
+	i.g.Assign(scope, name, val)
+	return objects.UntypedNil
 }
 
-func (i *Interpreter) evalInfixBooleanExpression(operator string, left, right bool) objects.Object {
-	switch operator {
-	case "==":
-		return &objects.Boolean{Value: left == right}
-	case "!=":
-		return &objects.Boolean{Value: left != right}
-	case "&&":
-		return &objects.Boolean{Value: left && right}
-	case "||":
-		return &objects.Boolean{Value: left || right}
+// evalMultiAssignStatement evaluates assignment of several values: received value and a flag
+// (e.g. `v, ok := <-ch`), or results of Go function call (e.g. `n, err := strconv.Atoi(s)`).
+func (i *Interpreter) evalMultiAssignStatement(ctx context.Context, node *ast.AssignStatement, scope *objects.Scope) objects.Object {
+	var values []objects.Object
+	switch value := node.Value.(type) {
+	case *ast.CallExpression:
+		values = ops.CallValues(node, i.evalCallExpression(ctx, value, scope))
+	case *ast.PrefixExpression:
+		if value.Token.Type != tokens.Arrow || len(node.Rest) != 1 {
+			i.g.Crash("%s", ops.AssignmentMismatch(node, 1))
+		}
+		val, received := i.evalReceive(ctx, value, scope)
+		values = ops.ReceivedValues(node, val, received)
 	default:
-		i.crash("unhandled infix expression operator %s for two Booleans", operator)
-		panic("not reached")
+		i.g.Crash("%s", ops.AssignmentMismatch(node, 1))
 	}
+
+	i.g.AssignValues(node, values, scope)
+	return objects.UntypedNil
 }
 
-func (i *Interpreter) evalInfixExpression(operator string, left, right objects.Object) objects.Object {
-	switch left.Type() {
-	case objects.IntegerType:
-		switch right.Type() {
-		case objects.IntegerType:
-			l := left.(*objects.Integer).Value
-			r := right.(*objects.Integer).Value
-			return i.evalInfixIntegerExpression(operator, l, r)
+// assignValues assigns values to variables on the left side of assignment statement node.
+func (i *Interpreter) assignValues(node *ast.AssignStatement, values []objects.Object, scope *objects.Scope) {
+	names := append([]*ast.Identifier{node.Name.(*ast.Identifier)}, node.Rest...)
+
+	var declared bool
+	for n, name := range names {
+		if name.Value == "_" {
+			continue
 		}
 
-	case objects.FloatType:
-		switch right.Type() {
-		case objects.FloatType:
-			l := left.(*objects.Float).Value
-			r := right.(*objects.Float).Value
-			return i.evalInfixFloatExpression(operator, l, r)
+		// := redeclares variables declared in the same scope
+		if node.Token.Type == tokens.Define && ops.Define(scope, name, values[n]) {
+			declared = true
+			continue
 		}
 
-	case objects.BooleanType:
-		switch right.Type() {
-		case objects.BooleanType:
-			l := left.(*objects.Boolean).Value
-			r := right.(*objects.Boolean).Value
-			return i.evalInfixBooleanExpression(operator, l, r)
+		old, ok := ops.Lookup(scope, name)
+		if !ok {
+			i.g.Crash("undefined: %s", name.Value)
 		}
+		i.g.Assign(scope, name, ops.ConvertAssigned(node.Value, values[n], objects.TypeOf(old)))
 	}
 
-	i.crash("unhandled combination: %T %s %T", left, operator, right)
-	panic("not reached")
+	if node.Token.Type == tokens.Define && !declared {
+		i.g.Crash("no new variables on left side of :=")
+	}
 }
 
-func (i *Interpreter) evalExpressions(ctx context.Context, exps []ast.Expression, scope *objects.Scope) []objects.Object {
-	res := make([]objects.Object, len(exps))
-	for n, e := range exps {
-		res[n] = i.Eval(ctx, e, scope)
+// evalAssignAddressable evaluates assignment to slice element or module member.
+// Operands of the left side are evaluated once, before the right side.
+func (i *Interpreter) evalAssignAddressable(ctx context.Context, node *ast.AssignStatement, scope *objects.Scope) objects.Object {
+	old, set := i.evalAddressable(ctx, node.Name, scope)
+	if node.Token.Type == tokens.Assignment {
+		set(i.evalAssigned(ctx, node.Value, objects.TypeOf(old), scope))
+		return objects.UntypedNil
 	}
-	return res
-}
 
-func (i *Interpreter) evalAssignStatement(ctx context.Context, node *ast.AssignStatement, scope *objects.Scope) objects.Object {
-	val := i.Eval(ctx, node.Value, scope)
-	switch node.Token.Type {
-	case tokens.Assignment:
-		// nothing
+	// x op= y is evaluated as x = x op y with the current value of x
+	exp := ops.CompoundExpression(node)
+	switch {
+	case exp.Token.Type == tokens.ShiftLeft || exp.Token.Type == tokens.ShiftRight:
+		set(ops.Shift(exp, old, i.eval(ctx, node.Value, scope)))
+	case ops.IsConstant(node.Value):
+		set(i.evalInfixExpression(exp, exp.Token.Literal, old, ops.ConstantOperand(exp, node.Value, old)))
 	default:
-		i.crash("unhandled token %s", node.Token)
+		set(i.evalInfixExpression(exp, exp.Token.Literal, old, i.eval(ctx, node.Value, scope)))
 	}
-	scope.Set(node.Name.Value, val)
-	return nil
+	return objects.UntypedNil
 }
 
 func (i *Interpreter) evalForStatement(ctx context.Context, node *ast.ForStatement, scope *objects.Scope) objects.Object {
-	i.Eval(ctx, node.Init, scope)
+	// each iteration has its own copy of variables declared by init statement
+	scope = ops.BlockScope(scope, node.Slots)
+	if node.Init != nil {
+		i.eval(ctx, node.Init, scope)
+	}
 	for {
-		cond := i.Eval(ctx, node.Cond, scope)
-		var b *objects.Boolean
-		var ok bool
-		if b, ok = cond.(*objects.Boolean); !ok {
-			i.crash("expected boolean, got %T %s", cond, cond)
+		i.g.CheckContext(ctx)
+
+		if node.Cond != nil {
+			cond := i.eval(ctx, node.Cond, scope)
+			var b *objects.Boolean
+			var ok bool
+			if b, ok = cond.(*objects.Boolean); !ok {
+				i.g.Crash("expected boolean, got %T %s", cond, cond)
+			}
+			if !b.Value {
+				return objects.UntypedNil
+			}
+		}
+
+		if res := i.eval(ctx, node.Body, scope); res.Type() == objects.ReturnType {
+			return res
 		}
-		if !b.Value {
-			return nil
+		if node.Slots > 0 {
+			scope = scope.Copy()
 		}
+		if node.Post != nil {
+			i.eval(ctx, node.Post, scope)
+		}
+	}
+}
 
-		body := i.Eval(ctx, node.Body, scope)
+func (i *Interpreter) evalRangeStatement(ctx context.Context, node *ast.RangeStatement, scope *objects.Scope) objects.Object {
+	set := func(s *objects.Scope, id *ast.Identifier, val objects.Object) {
+		switch {
+		case id == nil:
+			return
+		case node.Define:
+			i.g.Define(s, id, val)
+		default:
+			i.g.Assign(s, id, val)
+		}
+	}
+
+	// each iteration has its own variables declared with :=
+	var res objects.Object = objects.UntypedNil
+	iterate := func(key, value objects.Object) bool {
+		s := scope
+		if node.Define {
+			s = ops.BlockScope(scope, node.Slots)
+		}
+		set(s, node.Key, key)
+		set(s, node.Value, value)
+		if body := i.eval(ctx, node.Body, s); body.Type() == objects.ReturnType {
+			res = body
+			return false
+		}
+		i.g.CheckContext(ctx)
+		return true
+	}
 
-		i.Eval(ctx, node.Post, scope)
+	switch x := ops.Underlying(i.eval(ctx, node.X, scope)).(type) {
+	case *objects.String:
+		for n, r := range x.Value {
+			if !iterate(objects.NewInt(n), &objects.Int32{Value: r}) {
+				break
+			}
+		}
 
-		if body != nil {
-			switch body.Type() {
-			case objects.ContinueType:
-				continue
+	case *objects.Slice:
+		for n, v := range x.Values {
+			if !iterate(objects.NewInt(n), v) {
+				break
 			}
 		}
+
+	case *objects.Channel:
+		i.rangeChannel(ctx, node, x, iterate)
+
+	case *objects.Nil:
+		if x.T != nil && x.T.Kind == objects.ChannelType {
+			i.rangeChannel(ctx, node, x, iterate)
+			break
+		}
+		if x.T == nil || x.T.Kind != objects.SliceType {
+			i.g.Crash("cannot range over %s", node.X)
+		}
+
+	default:
+		i.g.Crash("cannot range over %s (type %s)", node.X, ops.TypeString(x))
 	}
+
+	return res
 }
 
 func (i *Interpreter) evalIfStatement(ctx context.Context, node *ast.IfStatement, scope *objects.Scope) objects.Object {
-	cond := i.Eval(ctx, node.Cond, scope)
+	cond := i.eval(ctx, node.Cond, scope)
 	var b *objects.Boolean
 	var ok bool
 	if b, ok = cond.(*objects.Boolean); !ok {
-		i.crash("expected boolean, got %T %s", cond, cond)
+		i.g.Crash("expected boolean, got %T %s", cond, cond)
 	}
 	if !b.Value {
-		return nil
+		return objects.UntypedNil
 	}
 
-	body := i.Eval(ctx, node.Body, scope)
-	if body != nil {
-		switch body.Type() {
-		case objects.ContinueType:
-			return body
-		}
+	body := i.eval(ctx, node.Body, scope)
+	if t := body.Type(); t == objects.ContinueType || t == objects.ReturnType {
+		return body
 	}
-	return nil
+	return objects.UntypedNil
 }
 
-func (i *Interpreter) evalIncrementDecrementStatement(node *ast.IncrementDecrementStatement, scope *objects.Scope) objects.Object {
-	name := node.Name.Value
-	val, ok := scope.Lookup(name)
-	if !ok {
-		i.crash("failed to lookup %s", name)
+func (i *Interpreter) evalIncrementDecrementStatement(ctx context.Context, node *ast.IncrementDecrementStatement, scope *objects.Scope) objects.Object {
+	// variables are assigned directly, without a closure
+	if id, ok := node.X.(*ast.Identifier); ok {
+		val, ok := ops.Lookup(scope, id)
+		if !ok {
+			i.g.Crash("identifier not found: %s", id.Value)
+		}
+		i.g.Assign(scope, id, ops.IncDec(node, val))
+		return objects.UntypedNil
 	}
 
-	v := val.(*objects.Integer).Value
+	val, set := i.evalAddressable(ctx, node.X, scope)
+	set(ops.IncDec(node, val))
+	return objects.UntypedNil
+}
 
-	switch node.Token.Type {
-	case tokens.Increment:
-		v++
-	case tokens.Decrement:
-		v--
-	default:
-		i.crash("unexpected token")
+// evalAddressable evaluates addressable expression (variable, slice element or module member) once,
+// and returns its current value and a function that stores a new value there.
+func (i *Interpreter) evalAddressable(ctx context.Context, exp ast.Expression, scope *objects.Scope) (objects.Object, func(objects.Object)) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		val, ok := ops.Lookup(scope, exp)
+		if !ok {
+			i.g.Crash("identifier not found: %s", exp.Value)
+		}
+		return val, func(v objects.Object) { i.g.Assign(scope, exp, v) }
+
+	case *ast.IndexExpression:
+		x := ops.Underlying(i.eval(ctx, exp.Left, scope))
+		if m, ok := ops.GoMap(x); ok {
+			key := i.eval(ctx, exp.Index, scope)
+			return ops.MapIndex(exp, m, key), func(v objects.Object) { ops.SetMapIndex(exp, m, key, v) }
+		}
+		l := ops.Length(exp, x)
+		if _, ok := x.(*objects.String); ok {
+			i.g.Crash("cannot assign to %s (neither addressable nor a map index expression)", exp)
+		}
+		idx := ops.Index(exp.Index, i.eval(ctx, exp.Index, scope))
+		ops.CheckBounds(idx, l)
+		values := x.(*objects.Slice).Values
+		return values[idx], func(v objects.Object) { values[idx] = v }
+
+	case *ast.SelectorExpression:
+		x := i.eval(ctx, exp.X, scope)
+		return ops.Select(exp, x), func(v objects.Object) { ops.AssignMember(exp, x, v) }
 	}
 
-	scope.Set(name, &objects.Integer{Value: v})
-	return nil
+	i.g.Crash("cannot assign to %s (neither addressable nor a map index expression)", exp)
+	panic("not reached")
 }
 
 func (i *Interpreter) evalCallExpression(ctx context.Context, node *ast.CallExpression, scope *objects.Scope) objects.Object {
-	f := i.Eval(ctx, node.Function, scope)
-	args := i.evalExpressions(ctx, node.Arguments, scope)
-	switch f := f.(type) {
-	case *objects.Function:
-		newScope := objects.NewScope(scope)
-		for i, name := range f.Parameters {
-			newScope.Set(name.Value, args[i])
-		}
-		return i.Eval(ctx, f.Body, newScope)
-	case *objects.GoFunction:
-		return f.Func(args...)
-	default:
-		i.crash("unexpected node %T:\n%#v", node, node)
-		panic("not reached")
+	f := i.eval(ctx, node.Function, scope)
+	if t, ok := f.(*objects.TypeObject); ok {
+		if len(node.Arguments) != 1 {
+			i.g.Crash("wrong number of arguments in conversion to %s", t)
+		}
+		return i.evalConversion(ctx, node, t, scope)
+	}
+
+	// arguments are evaluated to the top of the stack and removed after the call;
+	// nested calls while evaluating them or during the call use the stack above
+	g := i.g
+	base := len(g.Stack)
+	for _, e := range node.Arguments {
+		arg := i.eval(ctx, e, scope)
+		g.Stack = append(g.Stack, arg)
+	}
+	res := g.Call(ctx, node, f, g.Stack[base:len(g.Stack):len(g.Stack)])
+	g.Truncate(base)
+	return res
+}
+
+func (i *Interpreter) evalIndexExpression(ctx context.Context, node *ast.IndexExpression, scope *objects.Scope) objects.Object {
+	x := ops.Underlying(i.eval(ctx, node.Left, scope))
+	if m, ok := ops.GoMap(x); ok {
+		return ops.MapIndex(node, m, i.eval(ctx, node.Index, scope))
+	}
+	ops.Length(node, x)
+	return ops.Element(x, ops.Index(node.Index, i.eval(ctx, node.Index, scope)))
+}
+
+func (i *Interpreter) evalSliceExpression(ctx context.Context, node *ast.SliceExpression, scope *objects.Scope) objects.Object {
+	val := i.eval(ctx, node.Left, scope)
+	ops.Length(node, ops.Underlying(val))
+
+	var low, high objects.Object
+	if node.Low != nil {
+		low = i.eval(ctx, node.Low, scope)
+	}
+	if node.High != nil {
+		high = i.eval(ctx, node.High, scope)
 	}
+	return ops.Slice(node, val, low, high)
 }
@@ -29,6 +29,6 @@ func Fuzz(data []byte) int {
 	p := parser.New(s, nil)
 	program := p.ParseProgram()
 	i := New(nil)
-	i.Eval(context.TODO(), program, objects.NewScope(objects.Builtin(ioutil.Discard)))
+	_, _ = i.Eval(context.TODO(), program, objects.NewScope(objects.Builtin(ioutil.Discard)))
 	return 0
 }
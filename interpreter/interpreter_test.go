@@ -10,19 +10,71 @@ package interpreter
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"gosh-lang.org/gosh/ast"
 	"gosh-lang.org/gosh/internal/gofuzz"
 	"gosh-lang.org/gosh/internal/golden"
 	"gosh-lang.org/gosh/objects"
 	"gosh-lang.org/gosh/parser"
 	"gosh-lang.org/gosh/scanner"
+	"gosh-lang.org/gosh/tokens"
 )
 
+// Engine runs Gosh program like Interpreter.Eval does.
+type Engine func(ctx context.Context, program *ast.Program, scope *objects.Scope, config *Config) (objects.Object, error)
+
+// Engines are other engines (like the virtual machine) registered by external tests.
+// Tests check that they produce the same results, output and errors as the interpreter.
+var Engines = map[string]Engine{}
+
+// evalInterpreter is an Engine for the interpreter.
+func evalInterpreter(ctx context.Context, program *ast.Program, scope *objects.Scope, config *Config) (objects.Object, error) {
+	return New(config).Eval(ctx, program, scope)
+}
+
+// allEngines returns the interpreter and other engines.
+func allEngines() map[string]Engine {
+	res := map[string]Engine{"interpreter": evalInterpreter}
+	for name, e := range Engines {
+		res[name] = e
+	}
+	return res
+}
+
+// assertSameResult checks that engine produced the same result, output and error as the interpreter.
+func assertSameResult(t *testing.T, name string, expectedRes, actualRes objects.Object, expectedOut, actualOut string, expectedErr, actualErr error) {
+	t.Helper()
+
+	assert.Equal(t, expectedOut, actualOut, "%s: output", name)
+
+	// engines count steps differently
+	if errors.Is(expectedErr, ErrMaxSteps) {
+		assert.True(t, errors.Is(actualErr, ErrMaxSteps), "%s: %v", name, actualErr)
+		return
+	}
+	assert.Equal(t, expectedErr, actualErr, "%s: error", name)
+
+	// functions are compared by their source
+	if f, ok := expectedRes.(*objects.Function); ok {
+		require.IsType(t, f, actualRes, "%s: result", name)
+		assert.Equal(t, f.String(), actualRes.String(), "%s: result", name)
+		return
+	}
+	assert.Equal(t, expectedRes, actualRes, "%s: result", name)
+}
+
 func TestGolden(t *testing.T) {
 	for _, f := range golden.Data {
 		t.Run(f.File, func(t *testing.T) {
@@ -32,11 +84,14 @@ func TestGolden(t *testing.T) {
 			require.NoError(t, err)
 			p := parser.New(s, nil)
 			program := p.ParseProgram()
-			var buf bytes.Buffer
-			i := New(nil)
-			res := i.Eval(context.Background(), program, objects.NewScope(objects.Builtin(&buf)))
-			t.Log(res)
-			assert.Equal(t, f.Output, strings.Split(buf.String(), "\n"))
+
+			for name, engine := range allEngines() {
+				var buf bytes.Buffer
+				res, err := engine(context.Background(), program, objects.NewScope(objects.Builtin(&buf)), nil)
+				require.NoError(t, err, "%s", name)
+				t.Log(name, res)
+				assert.Equal(t, f.Output, strings.Split(buf.String(), "\n"), "%s", name)
+			}
 		})
 	}
 }
@@ -44,6 +99,53 @@ func TestGolden(t *testing.T) {
 func eval(t *testing.T, input string) (objects.Object, *bytes.Buffer) {
 	t.Helper()
 
+	res, buf, err := evalWithError(t, input)
+	require.NoError(t, err)
+	return res, buf
+}
+
+func evalError(t *testing.T, input string) *RuntimeError {
+	t.Helper()
+
+	_, _, err := evalWithError(t, input)
+	require.IsType(t, (*RuntimeError)(nil), err)
+	return err.(*RuntimeError)
+}
+
+func evalWithError(t *testing.T, input string) (objects.Object, *bytes.Buffer, error) {
+	t.Helper()
+
+	return evalWithContext(context.Background(), t, input, nil)
+}
+
+// evalWithContext evaluates input with additional predeclared variables.
+func evalWithContext(ctx context.Context, t *testing.T, input string, vars map[string]objects.Object) (objects.Object, *bytes.Buffer, error) {
+	t.Helper()
+
+	return evalWithConfig(ctx, t, input, vars, nil)
+}
+
+// evalWithConfig evaluates input with additional predeclared variables and given interpreter configuration.
+// Unless ctx can be done, other engines are checked to produce the same result.
+func evalWithConfig(ctx context.Context, t *testing.T, input string, vars map[string]objects.Object, config *Config) (objects.Object, *bytes.Buffer, error) {
+	t.Helper()
+
+	res, buf, err := evalWithEngine(ctx, t, evalInterpreter, input, vars, config)
+	if ctx.Done() != nil {
+		return res, buf, err
+	}
+
+	for name, engine := range Engines {
+		actualRes, actualBuf, actualErr := evalWithEngine(ctx, t, engine, input, vars, config)
+		assertSameResult(t, name, res, actualRes, buf.String(), actualBuf.String(), err, actualErr)
+	}
+	return res, buf, err
+}
+
+// evalWithEngine evaluates input with given engine.
+func evalWithEngine(ctx context.Context, t *testing.T, engine Engine, input string, vars map[string]objects.Object, config *Config) (objects.Object, *bytes.Buffer, error) {
+	t.Helper()
+
 	s, err := scanner.New(input, nil)
 	require.NoError(t, err)
 
@@ -52,10 +154,13 @@ func eval(t *testing.T, input string) (objects.Object, *bytes.Buffer) {
 	require.Nil(t, p.Errors(), "%s", p.Errors())
 	require.NotNil(t, program)
 
-	i := New(nil)
 	var buf bytes.Buffer
-	res := i.Eval(context.Background(), program, objects.NewScope(objects.Builtin(&buf)))
-	return res, &buf
+	scope := objects.NewScope(objects.Builtin(&buf))
+	for name, v := range vars {
+		scope.Define(name, v)
+	}
+	res, err := engine(ctx, program, scope, config)
+	return res, &buf, err
 }
 
 func TestInfixExpression(t *testing.T) {
@@ -171,13 +276,1076 @@ func TestIf(t *testing.T) {
 		`if (true) { print(true) }`:          "true",
 		`if (false && true) { print(true) }`: "",
 		`if (true && false) { print(true) }`: "",
+		`if 1 < 2 { print(true) }`:           "true",
 	} {
 		t.Run(input, func(t *testing.T) {
 			gofuzz.AddDataToCorpus("interpreter", []byte(input))
 
 			res, buf := eval(t, input)
-			assert.Nil(t, res)
+			assert.Equal(t, &objects.Nil{}, res)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestSizedNumbers(t *testing.T) {
+	for input, output := range map[string]string{
+		`var a int8 = 127; a++; println(a)`:                   "-128\n",
+		`var a uint8 = 250; a = a + 10; println(a)`:           "4\n",
+		`var a byte; a--; println(a)`:                         "255\n",
+		`var a int16 = -32768; println(-a, a / -1)`:           "-32768 -32768\n",
+		`var a uint32 = 1; println(a - 2)`:                    "4294967295\n",
+		`var a uint64 = 1; println(a - 2)`:                    "18446744073709551615\n",
+		`var a int32 = 1073741824; println(a * 4)`:            "0\n",
+		`var a float32 = 0.1; println(a, float64(a))`:         "1e-01 1.0000000149011612e-01\n",
+		`var a float64 = 0.0 - 3.9; println(int(a), int8(a))`: "-3 -3\n",
+		`var a int = 300; println(int8(a), uint8(a))`:         "44 44\n",
+		`var a uintptr = 5; println(a * 2)`:                   "10\n",
+		`var a = 1.5; println(a + 1, 2 * a)`:                  "2.5e+00 3e+00\n",
+		`var a = 'a'; a = 1; println(a + 1, 'b' - a)`:         "2 97\n",
+		`var a float64; a = 1 << 70; println(a > 1 << 69)`:    "true\n",
+		`type T int; var a T = 2; a = 3; println(a * 3)`:      "9\n",
+		`var a int8; f := func(a) { a += 300 }; f(1)`:         "",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestSizedNumbersErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var a int8 = 300`:                                "constant 300 overflows int8",
+		`var a uint = -1`:                                 "constant -1 overflows uint",
+		`println(int(2.5))`:                               "constant 2.5 truncated to integer",
+		`var a int8 = 1; println(a + 1000)`:               "constant 1000 overflows int8",
+		`var a int8 = 1; var b int16 = 2; println(a + b)`: "invalid operation: a + b (mismatched types int8 and int16)",
+		`var a int8 = 1; var b = 2; a = b`:                "cannot use b (type int) as type int8 in assignment",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestComplex(t *testing.T) {
+	for input, output := range map[string]string{
+		`println(1 + 2i)`: "(1+2i)\n",
+		`var c = complex(1, 2); println(c * c, c - 1)`:                      "(-3+4i) (0+2i)\n",
+		`var c = 3 + 4i; println(real(c), imag(c))`:                         "3e+00 4e+00\n",
+		`var c complex64 = 1.5 + 2i; println(c / 2)`:                        "(0.75+1i)\n",
+		`var c complex64 = 1i; println(complex128(c) == 1i)`:                "true\n",
+		`var f float32 = 2; println(complex(f, 1))`:                         "(2+1i)\n",
+		`var c complex128; println(c, c != 1i)`:                             "(0+0i) true\n",
+		`var w complex64 = complex(1, 2); println(w * w, real(w))`:          "(-3+4i) 1e+00\n",
+		`var complex = func(a, b) { return a - b }; println(complex(3, 2))`: "1\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestNil(t *testing.T) {
+	for input, output := range map[string]string{
+		`var s []int; println(s == nil, s)`:                                    "true []\n",
+		`var m map[string]int; println(m == nil, m)`:                           "true map[]\n",
+		`var p *int; println(p == nil, nil != p, p)`:                           "true false <nil>\n",
+		`var f func(int) bool; println(f == nil)`:                              "true\n",
+		`var c chan int; println(c == nil)`:                                    "true\n",
+		`var e interface{}; println(e == nil, e)`:                              "true <nil>\n",
+		`var p *int; var e interface{} = p; println(p == nil, e == nil)`:       "true false\n",
+		`var e interface{} = 42; println(e == 42, e != nil, e)`:                "true true 42\n",
+		`var s []int = nil; var e interface{} = s; e = nil; println(e == nil)`: "true\n",
+		`var i int; var b bool; var s string; println(i, b, len(s))`:           "0 false 0\n",
+		`var f = func() {}; println(f != nil)`:                                 "true\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestNilErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var a = nil`:                              "use of untyped nil",
+		`var a int = nil`:                          "cannot use nil as type int in assignment",
+		`var a int; println(a == nil)`:             "invalid operation: mismatched types int and nil",
+		`var p *int; var s []int; println(p == s)`: "invalid operation: mismatched types *int and []int",
+		`var f func(); f()`:                        "invalid memory address or nil pointer dereference",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	for input, output := range map[string]string{
+		`var a = 3; println(float64(a) / 2)`:                                             "1.5e+00\n",
+		`var f = 0.0 - 2.9; println(int(f), uint8(f))`:                                   "-2 254\n",
+		`println(string('a'), string(19990), string(65))`:                                "a 世 A\n",
+		`var r = -1; println(string(r))`:                                                 "\uFFFD\n",
+		`var b = []byte("hi"); println(b, len(b), string(b))`:                            "[104 105] 2 hi\n",
+		`var r = []rune("héllo"); println(len(r))`:                                       "5\n",
+		`var r = []rune("世界"); println(r, string(r))`:                                    "[19990 30028] 世界\n",
+		`var b []byte; println(len(string(b)), len(b))`:                                  "0 0\n",
+		`type Celsius float64; var c = Celsius(36.6); println(c + 1, float64(c) < 40.0)`: "3.76e+01 true\n",
+		`type Temp int; var c Temp = 10; c++; println(-c)`:                               "-11\n",
+		`type Bytes []byte; var b = Bytes("ab"); var s []byte = b; println(s)`:           "[97 98]\n",
+		`type Name string; var n = Name("gosh"); println(len(n), string(n))`:             "4 gosh\n",
+		`println('a' + 1, 'a' * 2.0)`:                                                    "98 1.94e+02\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestConversionsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`println(int8(300))`:                                       "1:13: constant 300 overflows int8",
+		`var s = "a"; println(int(s))`:                             "1:25: cannot convert s (type string) to type int",
+		`var f = 1.5; println(string(f))`:                          "1:28: cannot convert f (type float64) to type string",
+		`var b = true; var s = []int(b)`:                           "1:28: cannot convert b (type bool) to type []int",
+		`type Celsius float64; var f = 1.5; var c Celsius = f`:     "1:36: cannot use f (type float64) as type Celsius in assignment",
+		`type A int; type B int; var a A; var b B; println(a + b)`: "1:43: invalid operation: a + b (mismatched types A and B)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.EqualError(t, evalError(t, input), msg)
+		})
+	}
+}
+
+func TestUntypedConstants(t *testing.T) {
+	for input, output := range map[string]string{
+		`println(1 + 2.5, 7 / 2, 7 / 2.0, 7 % 3)`:                       "3.5e+00 3 3.5e+00 1\n",
+		`var x = 3; println(x * 2, x / 2)`:                              "6 1\n",
+		`var x = 3.0; println(x * 0.5, x * 2, 1 / x < 1)`:               "1.5e+00 6e+00 true\n",
+		`println(0.1 + 0.2 == 0.3)`:                                     "true\n",
+		`var x = 0.1; println(x + 0.2 == 0.3)`:                          "false\n",
+		`var x int64 = 100000000000000000000 / 10000000000; println(x)`: "10000000000\n",
+		`var u uint64 = 18446744073709551615; println(u)`:               "18446744073709551615\n",
+		`println(uint64(18446744073709551615 - 1))`:                     "18446744073709551614\n",
+		`var f float32 = 1.0 / 3; println(f)`:                           "3.3333334e-01\n",
+		`var b int8 = 1000 - 999; println(b + 'a')`:                     "98\n",
+		`println("go" + "sh", "a" < "b", !(1 > 2) && true)`:             "gosh true true\n",
+		`var c = 2 + 3i; println(c * 2, 1i * 1i == -1)`:                 "(4+6i) true\n",
+		`var x int8 = 100; println(x + 100)`:                            "-56\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
 			assert.Equal(t, output, buf.String())
 		})
 	}
 }
+
+func TestUntypedConstantsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`println(1 / 0)`:                         "invalid operation: division by zero",
+		`var x = 1; println(x + 2 % 0)`:          "invalid operation: division by zero",
+		`var x = 0; println(1 / x)`:              "runtime error: integer divide by zero",
+		`var x uint8 = 0; println(7 % x)`:        "runtime error: integer divide by zero",
+		`println(9223372036854775808)`:           "constant 9223372036854775808 overflows int",
+		`var x = 1; println(x * 0.5)`:            "constant 0.5 truncated to integer",
+		`var s = "a"; println(s + 1)`:            "invalid operation: s + 1 (mismatched types string and untyped int)",
+		`println("a" + 1)`:                       "invalid operation: \"a\" + 1 (mismatched types untyped string and untyped int)",
+		`var x = 1; var y = 2.5; println(x + y)`: "invalid operation: x + y (mismatched types int and float64)",
+		`println(int(1) + float64(2.0))`:         "invalid operation: int(1) + float64(2.0) (mismatched types int and float64)",
+		`var x int = "a"`:                        "cannot use \"a\" (type untyped string) as type int in assignment",
+		`println(1.5 % 1)`:                       "invalid operation: operator % not defined on 1.5 (untyped float constant)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestConstantsErrorsBeforeExecution(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var x int8; println(x); x = 300`:          "1:27: constant 300 overflows int8",
+		`var x int8; println(x); x += 300`:         "1:27: constant 300 overflows int8",
+		`var x int8; println(x); println(x + 300)`: "1:25: constant 300 overflows int8",
+		`println(0); println(1 << 64)`:             "1:13: constant 18446744073709551616 overflows int",
+		`println(0); var x = 1 << 64`:              "1:13: constant 18446744073709551616 overflows int",
+		`println(0); x := 1 << 64`:                 "1:15: constant 18446744073709551616 overflows int",
+		`println(0); println(string(65.0))`:        "1:27: cannot convert 65.0 (type untyped float) to type string",
+		`println(0); println(bool(1))`:             "1:25: cannot convert 1 (type untyped int) to type bool",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf, err := evalWithError(t, input)
+			require.IsType(t, (*RuntimeError)(nil), err)
+			assert.EqualError(t, err, msg)
+			assert.True(t, err.(*RuntimeError).Static)
+			assert.Empty(t, buf.String())
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	for input, output := range map[string]string{
+		`var s = "go"; s += "sh"; println(s, s + "!", len(s))`:                         "gosh gosh! 4\n",
+		`var a = "abc"; var b = "abd"; println(a < b, a >= b, a == "abc", a != b)`:     "true false true true\n",
+		`var s = "héllo"; println(s[0], s[1], s[2], len(s))`:                           "104 195 169 6\n",
+		`var s = "hello"; println(s[1:3], s[:2], s[3:], s[:], len(s[5:]))`:             "el he lo hello 0\n",
+		`var s = "héllo"; var b byte = s[0]; println(string(b), s[0] == 'h')`:          "h true\n",
+		`for i, r := range "aé世" { println(i, r, string(r)) }`:                         "0 97 a\n1 233 é\n3 19990 世\n",
+		`var s = "a\xffb\u00e9"; for i, r := range s { println(i, r, r == '\uFFFD') }`: "0 97 false\n1 65533 true\n2 98 false\n3 233 false\n",
+		`var n = 0; for range "héllo" { n++ }; println(n)`:                             "5\n",
+		`var i = 0; var r rune; for i, r = range "ab" {}; println(i, r)`:               "1 98\n",
+		`var b = []byte("abc"); for _, c := range b[1:] { println(c) }`:                "98\n99\n",
+		`type Name string; var n Name = "gosh"; println(n[1:], n + "!", n < "z")`:      "osh gosh! true\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestStringsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var s = "abc"; println(s[3])`:        "runtime error: index out of range [3] with length 3",
+		`var s = "abc"; var i = -1; s[i]`:     "runtime error: index out of range [-1] with length 3",
+		`var s = "abc"; println(s[1:4])`:      "runtime error: slice bounds out of range [:4] with length 3",
+		`var s = "abc"; println(s[2:1])`:      "runtime error: slice bounds out of range [2:1]",
+		`var s = "abc"; println(s["a"])`:      "invalid argument: index \"a\" (type string) must be integer",
+		`var s = "abc"; println(s - "a")`:     "unhandled infix expression operator - for two strings",
+		`var s = "abc"; println(s + 1)`:       "invalid operation: s + 1 (mismatched types string and untyped int)",
+		`var x = 1; println(x[0])`:            "invalid operation: x[0] (type int does not support indexing)",
+		`for _, r := range 42 { println(r) }`: "cannot range over 42 (type int)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestOperators(t *testing.T) {
+	for input, output := range map[string]string{
+		`var s = ""; println(len(s) > 0 && s[0] == 'a', len(s) == 0 || s[0] == 'a')`:                                "false true\n",
+		`var i = 3; var b = []byte("ab"); println(i < len(b) && b[i] == 0)`:                                         "false\n",
+		`var t = true; println(t && false, false || t, true && t)`:                                                  "false true true\n",
+		`var x = 6; x -= 1; x *= 4; x /= 3; x %= 4; println(x)`:                                                     "2\n",
+		`var x = 12; x &= 10; x |= 1; x ^= 3; x &^= 8; println(x)`:                                                  "2\n",
+		`var x = 1; x <<= 4; x >>= 1; println(x, x << 2, -x >> 1)`:                                                  "8 32 -4\n",
+		`var u uint8 = 200; var n = 1; println(u << n, u >> 3, ^u, u & 15, u | 7)`:                                  "144 25 55 8 207\n",
+		`var f = 1.5; var c = 1 + 2i; println(-f, +f, -c)`:                                                          "-1.5e+00 1.5e+00 (-1-2i)\n",
+		`var x = 5; println(^x, +x, -x, ^0, 1 << 10, 1 << 70 >> 68, 7 &^ 2)`:                                        "-6 5 -5 -1 1024 4 5\n",
+		`var b = []byte("abc"); var i = 1; b[i]++; b[0]--; println(string(b))`:                                      "`cc\n",
+		`var a = make([]int, 3); a[0] = 1; a[1] = 5; a[2] = 3; a[1] += 2; a[2] <<= 1; println(a[0], a[1], a[2])`:    "1 7 6\n",
+		`var a = make([]float64, 1); var i = 0; a[i] = 2; a[i] *= 1.5; println(a[0])`:                               "3e+00\n",
+		`var i = 0; var next = func() { i++; return i }; var a = make([]int, 3); a[next()] += 10; println(a[1], i)`: "10 1\n",
+		`type Celsius float64; var c Celsius = 1.5; c++; c += 1; println(c, -c)`:                                    "3.5e+00 -3.5e+00\n",
+		`type Flags uint8; var f Flags = 1; f <<= 3; f |= 1; println(f, f &^ 8)`:                                    "9 1\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestOperatorsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var x = 1; println(x && true)`:          "invalid operation: operator && not defined on x (type int)",
+		`var x = 1; var n = -1; println(x << n)`: "runtime error: negative shift amount",
+		`var f = 1.5; println(f << 1)`:           "invalid operation: shifted operand f (type float64) must be integer",
+		`println(1.5 << 1)`:                      "invalid operation: shifted operand 1.5 (untyped float constant) must be integer",
+		`println(1 << -1)`:                       "invalid shift count (-1)",
+		`println(1.5 & 1)`:                       "invalid operation: operator & not defined on 1.5 (untyped float constant)",
+		`var s = "abc"; s[0]++`:                  "cannot assign to s[0] (neither addressable nor a map index expression)",
+		`var s = "abc"; s++`:                     "invalid operation: s++ (non-numeric type string)",
+		`var b = []byte("a"); b[1]++`:            "runtime error: index out of range [1] with length 1",
+		`var s = "abc"; s[0] = 'x'`:              "cannot assign to s[0] (neither addressable nor a map index expression)",
+		`var a = make([]int, 1); a[1] = 2`:       "runtime error: index out of range [1] with length 1",
+		`var a = make([]int, 1); a[0] = "x"`:     "cannot use \"x\" (type untyped string) as type int in assignment",
+		`var a = make([]int, 1); a[0] += "x"`:    "invalid operation: a[0] + \"x\" (mismatched types int and untyped string)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestScopes(t *testing.T) {
+	for input, output := range map[string]string{
+		`var x = 1; var f = func() { x = 2 }; f(); println(x)`:                                                   "2\n",
+		`var x = 1; if (true) { x = 2; var x = 3; x += 4; println(x) }; println(x)`:                              "7\n2\n",
+		`var x = 1; if (true) { x := "s"; println(x) }; println(x)`:                                              "s\n1\n",
+		`var n = 0; var add = func(d) { n += d }; add(2); add(3); println(n)`:                                    "5\n",
+		`var x = 1; var f = func() { println(x) }; var g = func() { var x = 2; f(); _ = x }; g()`:                "1\n",
+		`var f = func() {}; for i := 0; i < 3; i++ { if (i == 1) { f = func() { println(i) } } }; f()`:           "1\n",
+		`var f = func() {}; var i = 0; for i = 0; i < 3; i++ { if (i == 1) { f = func() { println(i) } } }; f()`: "3\n",
+		`var f = func() {}; for _, r := range "ab" { if (r == 'a') { f = func() { println(r) } } }; f()`:         "97\n",
+		`var s = 0; for i := 0; i < 3; i++ { var d = i * 2; s += d }; println(s)`:                                "6\n",
+		`x := 1; y := x + 1; _ = y; println(x, y)`:                                                               "1 2\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestScopesErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`x = 1`:                               "undefined: x",
+		`var f = func() { y = 1 }; f()`:       "undefined: y",
+		`var x = 1; var x = 2`:                "x redeclared in this block",
+		`type T int; var T = 1`:               "T redeclared in this block",
+		`var f = func(a) { var a = 1 }; f(1)`: "a redeclared in this block",
+		`x := 1; x := 2`:                      "no new variables on left side of :=",
+		`x := nil`:                            "use of untyped nil in assignment",
+		`for i, i := range "ab" { _ = i }`:    "i redeclared in this block",
+		`var x = 1; for x, _ = range "ab" {}; for y, _ = range "ab" {}`: "undefined: y",
+		`if (true) { var z = 1; _ = z }; println(z)`:                    "undefined: z",
+		`var f = func() { var x = 1; x = 2 }; f()`:                      "declared and not used: x",
+		`var f = func() { var x = 1; x++ }; f()`:                        "declared and not used: x",
+		`var f = func() { _ += 1 }; f()`:                                "cannot use _ as value",
+		`println("before"); var f = func() { undefined() }`:             "undefined: undefined",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	for input, output := range map[string]string{
+		`func add(a, b int) int { return a + b }; println(add(1, 2))`:                                                 "3\n",
+		`func half(x float64) float64 { return x / 2 }; println(half(3))`:                                             "1.5e+00\n",
+		`type Celsius float64; func double(c Celsius) Celsius { return c * 2 }; println(double(20) == 40)`:            "true\n",
+		`func fib(n int) int { if n < 2 { return n }; return fib(n-1) + fib(n-2) }; println(fib(10))`:                 "55\n",
+		`var f = func(s string, n int) (r string) { for i := 0; i < n; i++ { r += s }; return }; println(f("ab", 2))`: "abab\n",
+		`func f() int { defer func() { recover() }(); panic("boom") }; println(f())`:                                  "0\n",
+		`func f(s []int, n int8) { println(len(s), n) }; f(make([]int, 2), 'a')`:                                      "2 97\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestFunctionsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`func f(n int) {}; f("x")`:                      "cannot use n (type string) as type int in assignment",
+		`func f(n T) {}; f(1)`:                          "undefined: T",
+		`type C float64; func f(c C) {}; f(float64(1))`: "cannot use c (type float64) as type C in assignment",
+		`func f() int { return "x" }; f()`:              `cannot use "x" (type untyped string) as type int in assignment`,
+		`func f(s []int) {}; f(make([]string, 1))`:      "cannot use s (type []string) as type []int in assignment",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	input := "var f = func(x) {\n\tprintln(x)\n\tprintln(1 / x)\n}\nvar g = func() { f(0) }\ng()\n"
+	err := evalError(t, input)
+	assert.Equal(t, "runtime error: integer divide by zero", err.Msg)
+	assert.Equal(t, 31, err.Offset)
+	expected := []Frame{
+		{Function: "f", Offset: 31},
+		{Function: "g", Offset: 66},
+		{Function: "main", Offset: 73},
+	}
+	assert.Equal(t, expected, err.Stack)
+	assert.EqualError(t, err, "3:2: runtime error: integer divide by zero")
+	assert.False(t, err.Static)
+
+	err = evalError(t, `println(len(1))`)
+	assert.Equal(t, "len: unexpected argument type *objects.Integer", err.Msg)
+	assert.Equal(t, []Frame{{Function: "main", Offset: 11}}, err.Stack)
+
+	// errors reported before execution
+	err = evalError(t, "println(1)\nprintln(x)\n")
+	assert.EqualError(t, err, "2:9: undefined: x")
+	assert.True(t, err.Static)
+}
+
+func TestMust(t *testing.T) {
+	s, err := scanner.New(`var x = 0; println(1 / x)`, nil)
+	require.NoError(t, err)
+	program := parser.New(s, nil).ParseProgram()
+
+	i := New(nil)
+	scope := objects.NewScope(objects.Builtin(ioutil.Discard))
+	assert.Panics(t, func() { Must(i.Eval(context.Background(), program, scope)) })
+	assert.Equal(t, &objects.Integer{Value: 2}, Must(i.Eval(context.Background(), &ast.IntegerLiteral{
+		Token: tokens.Token{Type: tokens.Integer, Literal: "2"},
+		Value: 2,
+	}, scope)))
+}
+
+func TestDeferPanicRecover(t *testing.T) {
+	for input, output := range map[string]string{
+		`var f = func() { defer println(1); defer println(2); println(3) }; f()`:                                                                 "3\n2\n1\n",
+		`var f = func() { var x = 1; defer println(x); x = 2; println(x) }; f()`:                                                                 "2\n1\n",
+		`var f = func() { for i := 0; i < 3; i++ { defer println(i) } }; f()`:                                                                    "2\n1\n0\n",
+		`var f = func() { defer func() { println(recover()) }(); panic("boom") }; f(); println("ok")`:                                            "boom\nok\n",
+		`var f = func() (r int) { defer func() { r *= 2 }(); return 21 }; println(f())`:                                                          "42\n",
+		`var f = func() (err) { defer func() { err = recover() }(); panic(42) }; println(f())`:                                                   "42\n",
+		`var f = func() { defer func() { println(recover()) }(); var x = 0; println(1 / x) }; f()`:                                               "runtime error: integer divide by zero\n",
+		`var f = func() { defer println("deferred"); return; println("not reached") }; f()`:                                                      "deferred\n",
+		`var f = func() { println(recover()) }; f()`:                                                                                             "<nil>\n",
+		`var r = func() { println(recover()) }; var f = func() { defer func() { r() }(); defer func() { println(recover()) }(); panic(1) }; f()`: "1\n<nil>\n",
+		`var f = func() { defer func() { println(recover()) }(); defer panic("second"); panic("first") }; f()`:                                   "second\n",
+		`var g = func() { panic("deep") }; var f = func() { defer func() { println(recover()) }(); g() }; f()`:                                   "deep\n",
+		`var f = func(x) { if (x > 0) { return x * 2 }; return 0 }; println(f(2), f(-1))`:                                                        "4 0\n",
+		`var f = func() { for _, r := range "abc" { if (r == 'b') { return r } }; return 0 }; println(f())`:                                      "98\n",
+		`defer println("main"); println("body")`:                                                                                                 "body\nmain\n",
+		`var f = func(a, b, c) { return a - b - c }; println(f(100, f(20, 3, f(4, 2, 1)), 7), f(1, 2, 3))`:                                       "77 -4\n",
+		`var p = func() (r) { defer func() { r = recover() }(); panic(5) }; var f = func(a, b) { return a == b }; println(f(5, p()), f(3, 1))`:   "true false\n",
+		`var f = func() { defer func() { var r = recover(); println(r != nil, r) }(); panic("boom") }; f()`:                                      "true boom\n",
+		`var f = func() { var r = recover(); println(r == nil) }; f()`:                                                                           "true\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestDeferPanicRecoverErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`panic("boom")`: "boom",
+		`var f = func() { defer println("deferred"); panic(42) }; f()`:             "42",
+		`var f = func() { defer func() { recover() }(); panic(1) }; f(); panic(2)`: "2",
+		`var f = func() { defer recover(); panic("not recovered") }; f()`:          "not recovered",
+		`var f = func() { defer int(1) }; f()`:                                     "defer requires function call, not conversion",
+		`var f = func() (a, b int) { return 1 }; f()`:                              "multiple function results are not supported",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestPanicStack(t *testing.T) {
+	input := "var g = func() {\n\tpanic(\"boom\")\n}\nvar f = func() {\n\tdefer println(\"deferred\")\n\tg()\n}\nf()\n"
+	_, buf, err := evalWithError(t, input)
+	require.IsType(t, (*RuntimeError)(nil), err)
+	assert.Equal(t, "deferred\n", buf.String())
+
+	re := err.(*RuntimeError)
+	assert.Equal(t, &objects.String{Value: "boom"}, re.Value)
+	expected := []Frame{
+		{Function: "g", Offset: 23},
+		{Function: "f", Offset: 80},
+		{Function: "main", Offset: 86},
+	}
+	assert.Equal(t, expected, re.Stack)
+}
+
+func TestChannels(t *testing.T) {
+	for input, output := range map[string]string{
+		`var ch = make(chan int, 2); ch <- 1; ch <- 2; println(len(ch), cap(ch)); println(<-ch, <-ch)`:                               "2 2\n1 2\n",
+		`var ch = make(chan int); go func() { ch <- 42 }(); println(<-ch)`:                                                           "42\n",
+		`var ch = make(chan int); go func(n) { for i := 0; i < n; i++ { ch <- i }; close(ch) }(3); for v := range ch { println(v) }`: "0\n1\n2\n",
+		`var ch = make(chan string, 1); close(ch); v, ok := <-ch; println(len(v), ok)`:                                               "0 false\n",
+		`var ch = make(chan int, 1); ch <- 1; var ok = false; var v = 0; v, ok = <-ch; println(v, ok)`:                               "1 true\n",
+		`var ch = make(chan int); var r <-chan int = ch; var s chan<- int = ch; go func() { s <- 1 }(); println(<-r, r == ch)`:       "1 true\n",
+		`var done = make(chan bool); var x = 0; go func() { x = 42; done <- true }(); <-done; println(x)`:                            "42\n",
+		`var c chan int; println(c == nil, len(c), cap(c))`:                                                                          "true 0 0\n",
+		`var f = func() { defer func() { println(recover()) }(); var ch = make(chan int); close(ch); close(ch) }; f()`:               "close of closed channel\n",
+		`var f = func() { defer func() { println(recover()) }(); var ch = make(chan int, 1); close(ch); ch <- 1 }; f()`:              "send on closed channel\n",
+		`var ch = make(chan int); go func() { for range ch { } }(); ch <- 1; println("main returns")`:                                "main returns\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestChannelsErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`var ch = make(chan int); <-ch`:                                      "all goroutines are asleep - deadlock!",
+		`var ch = make(chan int); ch <- 1`:                                   "all goroutines are asleep - deadlock!",
+		`var ch chan int; go func() {}(); <-ch`:                              "all goroutines are asleep - deadlock!",
+		`var ch = make(chan int); for v := range ch { println(v) }`:          "all goroutines are asleep - deadlock!",
+		`var ch chan int; close(ch)`:                                         "close of nil channel",
+		`var ch = make(chan int, 1); var r <-chan int = ch; r <- 1`:          "invalid operation: cannot send to receive-only channel r (type <-chan int)",
+		`var ch = make(chan int, 1); var s chan<- int = ch; <-s`:             "invalid operation: cannot receive from send-only channel s (type chan<- int)",
+		`var x = 1; x <- 1`:                                                  "invalid operation: cannot send to non-channel x (type int)",
+		`var ch = make(chan int, 1); ch <- "a"`:                              `cannot use "a" (type untyped string) as type int in assignment`,
+		`var ch = make(chan int, 1); for k, v := range ch { println(k, v) }`: "range over ch permits only one iteration variable",
+		`var x = 1; v, ok := x`:                                              "assignment mismatch: 2 variables but 1 value",
+		`var ch = make(chan int, 1); ch <- 1; var v = 0; v, _ := <-ch`:       "no new variables on left side of :=",
+		`go int(1)`: "go requires function call, not conversion",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	for input, output := range map[string]string{
+		`var ch = make(chan int, 1); ch <- 42; select { case v := <-ch: println(v) }`:                                  "42\n",
+		`var ch = make(chan int, 1); select { case ch <- 1: println("sent") }; println(<-ch)`:                          "sent\n1\n",
+		`var ch = make(chan int); select { case v := <-ch: println(v); default: println("default") }`:                  "default\n",
+		`var ch = make(chan int, 1); close(ch); select { case v, ok := <-ch: println(v, ok) }`:                         "0 false\n",
+		`var ch = make(chan int); var v = 0; go func() { ch <- 1 }(); select { case v = <-ch: }; println(v)`:           "1\n",
+		`var ch chan int; select { case <-ch: println("nil"); case <-after(1000000): println("timeout") }`:             "timeout\n",
+		`var ch = make(chan int); select { case ch <- 1: println("sent"); case <-after(1000000): println("timeout") }`: "timeout\n",
+		`var c1 = make(chan int)
+		var c2 = make(chan int)
+		go func() { c1 <- 1 }()
+		go func() { c2 <- 2 }()
+		var sum = 0
+		for i := 0; i < 2; i++ {
+			select {
+			case v := <-c1:
+				sum += v
+			case v := <-c2:
+				sum += v
+			}
+		}
+		println(sum)`: "3\n",
+		`var ch = make(chan int, 1)
+		var ones = 0
+		for i := 0; i < 100; i++ {
+			select {
+			case ch <- 1:
+			case ch <- 2:
+			}
+			if (<-ch == 1) {
+				ones++
+			}
+		}
+		println(ones > 0 && ones < 100)`: "true\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf := eval(t, input)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+}
+
+func TestSelectErrors(t *testing.T) {
+	for input, msg := range map[string]string{
+		`select {}`: "all goroutines are asleep - deadlock!",
+		`var ch = make(chan int); select { case <-ch: }`:               "all goroutines are asleep - deadlock!",
+		`var ch = make(chan int); close(ch); select { case ch <- 1: }`: "send on closed channel",
+		`var ch = make(chan int); select { case println(1): }`:         "select case must be receive, send or assign recv",
+		`var ch = make(chan int); select { default: default: }`:        "multiple defaults in select",
+		`var x = 1; select { case <-x: }`:                              "invalid operation: cannot receive from non-channel x (type int)",
+		`after("1")`:                                                   "after: unexpected argument type *objects.String",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			assert.Equal(t, msg, evalError(t, input).Msg)
+		})
+	}
+}
+
+func TestGoroutinePanic(t *testing.T) {
+	input := "var ch = make(chan int)\nvar f = func() {\n\tpanic(\"boom\")\n}\ngo f()\n<-ch\n"
+	err := evalError(t, input)
+	assert.Equal(t, &objects.String{Value: "boom"}, err.Value)
+	assert.Equal(t, 2, err.Goroutine)
+	assert.False(t, err.Fatal)
+	expected := []Frame{
+		{Function: "f", Offset: 47},
+		{Function: "main", Offset: 58},
+	}
+	assert.Equal(t, expected, err.Stack)
+
+	err = evalError(t, "var ch = make(chan int)\n<-ch\n")
+	assert.Equal(t, 1, err.Goroutine)
+	assert.True(t, err.Fatal)
+	assert.Equal(t, []Frame{{Function: "main", Offset: 24}}, err.Stack)
+}
+
+func TestContext(t *testing.T) {
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, buf, err := evalWithContext(ctx, t, `println("never")`, nil)
+		assert.Equal(t, context.Canceled, err)
+		assert.Empty(t, buf.String())
+	})
+
+	for name, input := range map[string]string{
+		"Loop":       `for { }`,
+		"Range":      `for { for range "abc" { } }`,
+		"Call":       `var f = func() { }; for { f() }`,
+		"Channel":    `var ch = make(chan int); go func() { for { } }(); <-ch`,
+		"Select":     `var ch = make(chan int); go func() { for { } }(); select { case <-ch: }`,
+		"Deferred":   `defer println("deferred"); for { }`,
+		"Goroutine":  `var ch = make(chan int); go func() { for { } }(); for { }`,
+		"GoFunction": `wait()`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			vars := map[string]objects.Object{
+				"wait": &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+					<-ctx.Done()
+					return nil
+				}},
+			}
+			for e, engine := range allEngines() {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				res, buf, err := evalWithEngine(ctx, t, engine, input, vars, nil)
+				cancel()
+				assert.Nil(t, res, "%s", e)
+				assert.Equal(t, context.DeadlineExceeded, err, "%s", e)
+				assert.Empty(t, buf.String(), "%s", e)
+			}
+		})
+	}
+}
+
+func TestGo(t *testing.T) {
+	vars := map[string]objects.Object{
+		"repeat":   objects.FromGo(reflect.ValueOf(strings.Repeat)),
+		"fields":   objects.FromGo(reflect.ValueOf(strings.Fields)),
+		"join":     objects.FromGo(reflect.ValueOf(strings.Join)),
+		"atoi":     objects.FromGo(reflect.ValueOf(strconv.Atoi)),
+		"sprint":   objects.FromGo(reflect.ValueOf(fmt.Sprint)),
+		"duration": objects.FromGo(reflect.ValueOf(time.Duration.String)),
+		"ages":     objects.FromGo(reflect.ValueOf(map[string]int{"gopher": 9})),
+		"get":      objects.FromGo(reflect.ValueOf(func(m map[string]int, k string) int { return m[k] })),
+		"divmod":   objects.FromGo(reflect.ValueOf(func(a, b int) (int, int) { return a / b, a % b })),
+		"cut":      objects.FromGo(reflect.ValueOf(strings.Cut)),
+		"builder":  objects.FromGo(reflect.ValueOf(func() *strings.Builder { return new(strings.Builder) })),
+		"newPoint": objects.FromGo(reflect.ValueOf(func(x, y int) *testPoint { return &testPoint{X: x, Y: y} })),
+		"newAges":  objects.FromGo(reflect.ValueOf(func() map[string]int { return map[string]int{} })),
+		"nilAges":  objects.FromGo(reflect.ValueOf(map[string]int(nil))),
+		"ints":     objects.FromGo(reflect.ValueOf(sort.Ints)),
+		"sort":     objects.FromGo(reflect.ValueOf(sort.Slice)),
+		"validate": objects.FromGo(reflect.ValueOf(func(s string) error {
+			if s == "" {
+				return errors.New("empty")
+			}
+			return nil
+		})),
+	}
+
+	for input, output := range map[string]string{
+		`println(repeat("ab", 3))`:                                               "ababab\n",
+		`println(join(fields(" a b  c "), ","))`:                                 "a,b,c\n",
+		`var n = atoi("41"); n++; println(n)`:                                    "42\n",
+		`println(sprint("a", 1, 2.5, true), sprint())`:                           "a1 2.5 true \n",
+		`var d int64 = 1500000000; println(duration(d), duration(2))`:            "1.5s 2ns\n",
+		`println(get(ages, "gopher"), ages)`:                                     "9 map[gopher:9]\n",
+		`defer func() { println(recover()) }(); atoi("x")`:                       "strconv.Atoi: parsing \"x\": invalid syntax\n",
+		`var s []string; println(len(fields("")), join(s, ","), repeat("", 0))`:  "0  \n",
+		`n, err := atoi("41"); println(n+1, err == nil, err)`:                    "42 true <nil>\n",
+		`n, err := atoi("x"); println(n, err != nil, err)`:                       "0 true strconv.Atoi: parsing \"x\": invalid syntax\n",
+		`_, err := atoi("x"); println(err.Error())`:                              "strconv.Atoi: parsing \"x\": invalid syntax\n",
+		`n := 1; n, err := atoi("5"); println(n, err)`:                           "5 <nil>\n",
+		`before, after, found := cut("a=b", "="); println(before, after, found)`: "a b true\n",
+		`q, r := divmod(7, 2); println(q, r); divmod(1, 1)`:                      "3 1\n",
+		`b := builder(); b.WriteString("go"); println(b.String(), b.Len())`:      "go 2\n",
+		`p := newPoint(1, 2); p.X += 3; p.Y++; println(p.X, p.Y, p.Sum())`:       "4 3 7\n",
+		`println(ages["gopher"], ages["none"], nilAges["none"])`:                 "9 0 0\n",
+		`m := newAges(); m["a"] = 1; m["a"]++; m["a"] += 2; println(m["a"], m)`:  "4 map[a:4]\n",
+		`err := validate(""); println(err != nil, err)`:                          "true empty\n",
+		`var err = validate("a"); println(err == nil, err); err = validate("")`:  "true <nil>\n",
+		`validate("a"); println(validate(""))`:                                   "empty\n",
+		`defer func() { println(recover()) }(); validate("")`:                    "empty\n",
+
+		// Go functions change slice arguments, and callbacks see the changes
+		`s := make([]int, 3); s[0] = 3; s[1] = 1; s[2] = 2; ints(s); println(s)`:                                                                          "[1 2 3]\n",
+		`s := make([]int, 3); s[0] = 3; s[1] = 1; s[2] = 2; sort(s, func(i, j) { return s[i] < s[j] }); println(s)`:                                       "[1 2 3]\n",
+		`s := fields("b c a"); sort(s, func(i, j) { return s[i] > s[j] }); println(s)`:                                                                    "[c b a]\n",
+		`type age int; s := make([]age, 3); s[0] = 30; s[1] = 10; s[2] = 20; sort(s, func(i, j) { return s[i] < s[j] }); var a age = s[0]; println(s, a)`: "[10 20 30] 10\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf, err := evalWithContext(context.Background(), t, input, vars)
+			require.NoError(t, err)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+
+	for input, msg := range map[string]string{
+		`repeat(1, 2)`:                           "func(string, int) string: argument 1: cannot use 1 (type int) as Go type string",
+		`repeat("a")`:                            "func(string, int) string: expected 2 arguments, got 1",
+		`repeat("a", -1)`:                        "func(string, int) string: strings: negative Repeat count",
+		`repeat("a", 1.5)`:                       "func(string, int) string: argument 2: cannot use 1.5e+00 (type float64) as Go type int",
+		`println(divmod(7, 2))`:                  "multiple-value divmod(7, 2) in single-value context",
+		`a, b, c := divmod(7, 2)`:                "assignment mismatch: 3 variables but divmod(7, 2) returns 2 values",
+		`a, b := repeat("a", 2)`:                 "assignment mismatch: 2 variables but repeat(\"a\", 2) returns 1 value",
+		`a, b := 1`:                              "assignment mismatch: 2 variables but 1 value",
+		`var x = ages + ages`:                    "unhandled combination: *objects.GoValue + *objects.GoValue",
+		`p := newPoint(1, 2); println(p.Z)`:      "p.Z undefined (type *interpreter.testPoint has no field or method Z)",
+		`p := newPoint(1, 2); println(p.secret)`: "p.secret undefined (cannot refer to unexported field or method secret)",
+		`p := newPoint(1, 2); p.Sum = 1`:         "cannot assign to p.Sum (neither addressable nor a map index expression)",
+		`p := newPoint(1, 2); p.X = "x"`:         "cannot use \"x\" (type untyped string) as type int in assignment",
+		`nilAges["a"] = 1`:                       "assignment to entry in nil map",
+		`println(ages[1])`:                       "invalid map index 1: cannot use 1 (type int) as Go type string",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, _, err := evalWithContext(context.Background(), t, input, vars)
+			require.IsType(t, (*RuntimeError)(nil), err)
+			assert.Equal(t, msg, err.(*RuntimeError).Msg)
+		})
+	}
+}
+
+func TestCallback(t *testing.T) {
+	vars := map[string]objects.Object{
+		"sortBy": &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+			s, less := args[0].(*objects.Slice), args[1]
+			sort.SliceStable(s.Values, func(i, j int) bool {
+				res, err := objects.Call(ctx, less, s.Values[i], s.Values[j])
+				if err != nil {
+					panic(err)
+				}
+				return res.(*objects.Boolean).Value
+			})
+			return nil
+		}},
+		"try": &objects.GoFunction{Func: func(ctx context.Context, args ...objects.Object) objects.Object {
+			res, err := objects.Call(ctx, args[0])
+			if err != nil {
+				return &objects.String{Value: "error: " + err.(*RuntimeError).Msg}
+			}
+			return res
+		}},
+		"mapRunes":   objects.FromGo(reflect.ValueOf(strings.Map)),
+		"fieldsFunc": objects.FromGo(reflect.ValueOf(strings.FieldsFunc)),
+		"join":       objects.FromGo(reflect.ValueOf(strings.Join)),
+		"fields":     objects.FromGo(reflect.ValueOf(strings.Fields)),
+	}
+
+	for input, output := range map[string]string{
+		`var s = fields("c a b"); sortBy(s, func(a, b) { return a > b }); println(s)`:                              "[c b a]\n",
+		`println(mapRunes(func(r) { return r + 1 }, "HAL"))`:                                                       "IBM\n",
+		`println(join(fieldsFunc("a1b22c", func(r) { return r >= '0' && r <= '9' }), ","))`:                        "a,b,c\n",
+		`println(try(func() { panic("boom") }), try(func() { return 1 }), try(println))`:                           "\nerror: boom 1 <nil>\n",
+		`println(try(func() { var s []int; return s[1] }))`:                                                        "error: runtime error: index out of range [1] with length 0\n",
+		`defer func() { println(recover()) }(); sortBy(fields("a b"), func(a, b) { panic("boom") })`:               "boom\n",
+		`defer func() { println(recover()) }(); mapRunes(func(r) { panic(r) }, "A")`:                               "65\n",
+		`println(try(func() { return mapRunes(func(r) { return try(func() { panic(r) }) }, "A") }))`:               "error: func(int32) int32: result: cannot use error: 65 (type string) as Go type int32\n",
+		`var ch = make(chan string); go func() { ch <- mapRunes(func(r) { return r - 1 }, "b") }(); println(<-ch)`: "a\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf, err := evalWithContext(context.Background(), t, input, vars)
+			require.NoError(t, err)
+			assert.Equal(t, output, buf.String())
+		})
+	}
+
+	t.Run("Stack", func(t *testing.T) {
+		input := "var f = func(a, b) {\n\tpanic(\"boom\")\n}\nsortBy(fields(\"a b\"), f)\n"
+		_, _, err := evalWithContext(context.Background(), t, input, vars)
+		require.IsType(t, (*RuntimeError)(nil), err)
+		re := err.(*RuntimeError)
+		assert.Equal(t, &objects.String{Value: "boom"}, re.Value)
+		assert.False(t, re.Fatal)
+		expected := []Frame{
+			{Function: "func", Offset: 27},
+			{Function: "main", Offset: 44},
+		}
+		assert.Equal(t, expected, re.Stack)
+	})
+
+	t.Run("Fatal", func(t *testing.T) {
+		input := `defer func() { println(recover()) }(); sortBy(fields("a b"), func(a, b) { var ch chan int; <-ch; return true })`
+		_, buf, err := evalWithContext(context.Background(), t, input, vars)
+		require.IsType(t, (*RuntimeError)(nil), err)
+		assert.True(t, err.(*RuntimeError).Fatal)
+		assert.Equal(t, objects.ErrDeadlock, err.(*RuntimeError).Err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		input := `defer println("deferred"); mapRunes(func(r) { for { } }, "a")`
+		for e, engine := range allEngines() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			res, buf, err := evalWithEngine(ctx, t, engine, input, vars, nil)
+			cancel()
+			assert.Nil(t, res, "%s", e)
+			assert.Equal(t, context.DeadlineExceeded, err, "%s", e)
+			assert.Empty(t, buf.String(), "%s", e)
+		}
+	})
+}
+
+// testCounter is a variable of Go package "counter" imported by tests.
+var testCounter int
+
+// testImporter imports Gosh modules from sources by import path, and Go packages.
+type testImporter struct {
+	sources  map[string]string
+	packages map[string]*objects.GoPackage
+}
+
+// Import implements Importer.
+func (ti *testImporter) Import(ctx context.Context, path, file string) (*objects.Module, error) {
+	if p := ti.packages[path]; p != nil {
+		return objects.NewGoModule(p), nil
+	}
+	src, ok := ti.sources[path]
+	if !ok {
+		return nil, fmt.Errorf("module %s not found", path)
+	}
+
+	s, err := scanner.New(src, nil)
+	if err != nil {
+		return nil, err
+	}
+	program := parser.New(s, nil).ParseProgram()
+	program.File = path + ".gosh"
+	name := path
+	if program.Package != nil {
+		name = program.Package.Value
+	}
+
+	scope := objects.NewScope(objects.Builtin(ioutil.Discard))
+	if _, err = New(&Config{Importer: ti}).Eval(ctx, program, scope); err != nil {
+		return nil, err
+	}
+	return &objects.Module{Path: path, Name: name, Scope: scope}, nil
+}
+
+func TestImport(t *testing.T) {
+	config := &Config{Importer: &testImporter{
+		sources: map[string]string{
+			"util":   "var Name = \"util\"\nvar Count = 0\nvar private = 1\ntype Celsius float64\nvar Double = func(n) { return n * 2 }\nvar Fail = func() {\n\tpanic(\"boom\")\n}\n",
+			"helper": "package helper\nimport \"util\"\nvar Quad = func(n) { return util.Double(util.Double(n)) }\n",
+			"named":  "package other\n",
+			"broken": "var x = 0\nvar y = 1 / x\n",
+		},
+		packages: map[string]*objects.GoPackage{
+			"strings": {
+				Path:   "strings",
+				Name:   "strings",
+				Values: map[string]reflect.Value{"Repeat": reflect.ValueOf(strings.Repeat)},
+			},
+			"counter": {
+				Path:   "counter",
+				Name:   "counter",
+				Values: map[string]reflect.Value{"Value": reflect.ValueOf(&testCounter).Elem()},
+			},
+		},
+	}}
+
+	for input, expected := range map[string]string{
+		`import "util"; println(util.Double(21), util.Name)`:                                                  "42 util\n",
+		`import ("util"; u "util"); var c u.Celsius = 36; println(util.Double(c) == u.Double(u.Celsius(36)))`: "true\n",
+		`import ("helper"; "strings"); println(strings.Repeat("ab", helper.Quad(1)))`:                         "abababab\n",
+		`import _ "named"; println("ok")`:                                                                     "ok\n",
+		`import "util"; util.Name = "changed"; util.Name += "!"; println(util.Name)`:                          "changed!\n",
+		`import "util"; util.Count++; util.Count <<= 2; util.Count -= 1; println(util.Count)`:                 "3\n",
+		`import "counter"; counter.Value = 5; counter.Value += 2; counter.Value++; println(counter.Value)`:    "8\n",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, buf, err := evalWithConfig(context.Background(), t, input, nil, config)
+			require.NoError(t, err)
+			assert.Equal(t, expected, buf.String())
+		})
+	}
+
+	for input, expected := range map[string]string{
+		`import "missing"`:                       "1:8: could not import missing (module missing not found)",
+		`import "util"; println(util.x)`:         "1:16: cannot refer to unexported name util.x",
+		`import "util"; println(util.Foo)`:       "1:16: undefined: util.Foo",
+		`import "named"`:                         `1:8: package other does not match import path "named"; use named import`,
+		`import "util"; var util = 1`:            "1:20: util redeclared in this block",
+		`var s = "x"; println(s.Foo)`:            "1:14: s.Foo undefined (type string has no field or method Foo)",
+		`import "util"; util.private = 2`:        "1:29: cannot refer to unexported name util.private",
+		`import "util"; util.Name = 1`:           "1:26: cannot use 1 (type untyped int) as type string in assignment",
+		`import "util"; util.Celsius = 1`:        "1:29: cannot assign to util.Celsius (neither addressable nor a map index expression)",
+		`import "strings"; strings.Repeat = nil`: "1:34: cannot assign to strings.Repeat (neither addressable nor a map index expression)",
+	} {
+		t.Run(input, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(input))
+
+			_, _, err := evalWithConfig(context.Background(), t, input, nil, config)
+			require.IsType(t, (*RuntimeError)(nil), err)
+			assert.Equal(t, expected, err.Error())
+		})
+	}
+
+	t.Run("Stack", func(t *testing.T) {
+		_, _, err := evalWithConfig(context.Background(), t, "import \"util\"\nutil.Fail()\n", nil, config)
+		require.IsType(t, (*RuntimeError)(nil), err)
+		expected := []Frame{
+			{Function: "util.Fail", File: "util.gosh", Offset: 133},
+			{Function: "main", Offset: 23},
+		}
+		assert.Equal(t, expected, err.(*RuntimeError).Stack)
+	})
+
+	t.Run("Init", func(t *testing.T) {
+		_, _, err := evalWithConfig(context.Background(), t, "import \"broken\"\n", nil, config)
+		require.IsType(t, (*RuntimeError)(nil), err)
+		e := err.(*RuntimeError)
+		assert.Equal(t, "runtime error: integer divide by zero", e.Msg)
+		assert.Equal(t, []Frame{{Function: "main", File: "broken.gosh", Offset: 10}}, e.Stack)
+	})
+
+	t.Run("NoImporter", func(t *testing.T) {
+		_, _, err := evalWithError(t, `import "util"`)
+		assert.EqualError(t, err, "1:8: could not import util (no importer)")
+	})
+}
+
+func TestLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		input     string
+		config    *Config
+		err       error
+		depth     int
+		goroutine int
+	}{{
+		name:      "Steps",
+		input:     `for { }`,
+		config:    &Config{MaxSteps: 1000},
+		err:       ErrMaxSteps,
+		depth:     1,
+		goroutine: 1,
+	}, {
+		name:      "StepsRecover",
+		input:     `defer func() { recover() }(); for { }`,
+		config:    &Config{MaxSteps: 1000},
+		err:       ErrMaxSteps,
+		depth:     1,
+		goroutine: 1,
+	}, {
+		name:      "StepsGoroutine",
+		input:     `var ch = make(chan int); go func() { for { } }(); <-ch`,
+		config:    &Config{MaxSteps: 1000},
+		err:       ErrMaxSteps,
+		depth:     2,
+		goroutine: 2,
+	}, {
+		name:      "CallDepth",
+		input:     `var f = func() { f() }; f()`,
+		err:       ErrMaxCallDepth,
+		depth:     DefaultMaxCallDepth,
+		goroutine: 1,
+	}, {
+		name:      "CallDepthConfig",
+		input:     `var f = func(n) { if (n > 0) { f(n-1) } }; f(5); f(10)`,
+		config:    &Config{MaxCallDepth: 10},
+		err:       ErrMaxCallDepth,
+		depth:     10,
+		goroutine: 1,
+	}, {
+		name:      "AllocString",
+		input:     `var s = "x"; for { s += s }`,
+		config:    &Config{MaxAlloc: 1 << 20},
+		err:       ErrMaxAlloc,
+		depth:     1,
+		goroutine: 1,
+	}, {
+		name:      "AllocMake",
+		input:     `make([]int, 10, 1 << 40)`,
+		config:    &Config{MaxAlloc: 1 << 20},
+		err:       ErrMaxAlloc,
+		depth:     1,
+		goroutine: 1,
+	}, {
+		name:      "AllocMakeNegative",
+		input:     `var f = func() { defer func() { recover() }(); make([]int, -100000000) }; f(); make([]int, 1000000)`,
+		config:    &Config{MaxAlloc: 1 << 20},
+		err:       ErrMaxAlloc,
+		depth:     1,
+		goroutine: 1,
+	}, {
+		name:      "AllocConversion",
+		input:     `var s = "x"; for { s = string([]byte(s + s)) }`,
+		config:    &Config{MaxAlloc: 1 << 20},
+		err:       ErrMaxAlloc,
+		depth:     1,
+		goroutine: 1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			gofuzz.AddDataToCorpus("interpreter", []byte(tc.input))
+
+			_, _, err := evalWithConfig(context.Background(), t, tc.input, nil, tc.config)
+			require.IsType(t, (*RuntimeError)(nil), err)
+			e := err.(*RuntimeError)
+			assert.True(t, errors.Is(err, tc.err), "%v", err)
+			assert.Equal(t, tc.err.Error(), e.Msg)
+			assert.True(t, e.Fatal)
+			assert.Len(t, e.Stack, tc.depth)
+			assert.Equal(t, tc.goroutine, e.Goroutine)
+		})
+	}
+
+	t.Run("WithinLimits", func(t *testing.T) {
+		input := `var f = func(n) { if (n > 0) { f(n-1) } }; f(8); println(len(make([]int, 100)), string([]byte("ok")))`
+		config := &Config{MaxSteps: 1000, MaxCallDepth: 10, MaxAlloc: 1 << 20}
+		_, buf, err := evalWithConfig(context.Background(), t, input, nil, config)
+		require.NoError(t, err)
+		assert.Equal(t, "100 ok\n", buf.String())
+	})
+}
+
+// testPoint is a Go struct used by Gosh programs in tests.
+type testPoint struct {
+	X, Y   int
+	secret int
+}
+
+func (p *testPoint) Sum() int { return p.X + p.Y }
@@ -0,0 +1,73 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package interpreter
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+// evalAssigned evaluates expression exp which value is assigned to a variable of type t.
+// Constant expression takes that type. If t is nil, the value of expression is returned as is.
+func (i *Interpreter) evalAssigned(ctx context.Context, exp ast.Expression, t *objects.TypeObject, scope *objects.Scope) objects.Object {
+	if t == nil || !t.Kind.IsBasic() || !ops.IsConstant(exp) {
+		return ops.ConvertAssigned(exp, i.eval(ctx, exp, scope), t)
+	}
+	return ops.Assigned(exp, t)
+}
+
+// evalConversion evaluates conversion expression T(x).
+func (i *Interpreter) evalConversion(ctx context.Context, node *ast.CallExpression, t *objects.TypeObject, scope *objects.Scope) objects.Object {
+	exp := node.Arguments[0]
+	if ops.IsConstantConversion(exp, t) {
+		res, msg := ops.ConvertConstant(exp, t)
+		if res == nil {
+			i.g.CrashAt(node.Token.Offset, "%s", msg)
+		}
+		return res
+	}
+
+	val := i.eval(ctx, exp, scope)
+	i.g.Alloc(ops.ConversionSize(val, t))
+	return ops.Convert(node, val, t)
+}
+
+// evalType evaluates type expression.
+func (i *Interpreter) evalType(ctx context.Context, exp ast.Expression, scope *objects.Scope) *objects.TypeObject {
+	switch exp := exp.(type) {
+	case *ast.SliceType:
+		return &objects.TypeObject{Kind: objects.SliceType, Elem: i.evalType(ctx, exp.Elem, scope)}
+	case *ast.MapType:
+		return &objects.TypeObject{Kind: objects.MapType, Key: i.evalType(ctx, exp.Key, scope), Elem: i.evalType(ctx, exp.Value, scope)}
+	case *ast.PointerType:
+		return &objects.TypeObject{Kind: objects.PointerType, Elem: i.evalType(ctx, exp.Elem, scope)}
+	case *ast.ChanType:
+		return &objects.TypeObject{Kind: objects.ChannelType, Dir: exp.Dir, Elem: i.evalType(ctx, exp.Elem, scope)}
+	case *ast.InterfaceType:
+		return &objects.TypeObject{Kind: objects.InterfaceType}
+	case *ast.FuncType:
+		t := &objects.TypeObject{Kind: objects.FunctionType}
+		for _, p := range exp.Params {
+			t.Params = append(t.Params, i.evalType(ctx, p, scope))
+		}
+		for _, r := range exp.Results {
+			t.Results = append(t.Results, i.evalType(ctx, r, scope))
+		}
+		return t
+	}
+
+	obj := i.eval(ctx, exp, scope)
+	t, ok := obj.(*objects.TypeObject)
+	if !ok {
+		i.g.Crash("%s is not a type", exp)
+	}
+	return t
+}
@@ -8,40 +8,249 @@
 package objects
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+
+	"gosh-lang.org/gosh/ast"
 )
 
 var (
-	lenBuiltin = &GoFunction{Func: func(args ...Object) Object {
+	lenBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
 		if len(args) != 1 {
 			panic(fmt.Errorf("len: expected 1 argument, got %d", len(args)))
 		}
 		arg := args[0]
 		switch arg := arg.(type) {
 		case *String:
-			return &Integer{
-				Value: len(arg.Value),
+			return NewInt(len(arg.Value))
+		case *Slice:
+			return NewInt(len(arg.Values))
+		case *Channel:
+			return NewInt(arg.Len())
+		case *Nil:
+			if arg.T != nil && (arg.T.Kind == SliceType || arg.T.Kind == ChannelType) {
+				return NewInt(0)
+			}
+			panic(fmt.Errorf("len: unexpected argument %s", arg))
+		case *Named:
+			if s, ok := arg.Value.(*String); ok {
+				return NewInt(len(s.Value))
 			}
+			panic(fmt.Errorf("len: unexpected argument type %s", arg.T))
 		default:
 			panic(fmt.Errorf("len: unexpected argument type %T", arg))
 		}
 	}}
 
+	capBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("cap: expected 1 argument, got %d", len(args)))
+		}
+		arg := args[0]
+		if n, ok := arg.(*Named); ok {
+			arg = n.Value
+		}
+		switch arg := arg.(type) {
+		case *Slice:
+			return NewInt(cap(arg.Values))
+		case *Channel:
+			return NewInt(arg.Cap())
+		case *Nil:
+			if arg.T != nil && (arg.T.Kind == SliceType || arg.T.Kind == ChannelType) {
+				return NewInt(0)
+			}
+			panic(fmt.Errorf("cap: unexpected argument %s", arg))
+		default:
+			panic(fmt.Errorf("cap: unexpected argument type %T", arg))
+		}
+	}}
+
+	closeBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("close: expected 1 argument, got %d", len(args)))
+		}
+		switch arg := args[0].(type) {
+		case *Channel:
+			if arg.T.Dir == ast.RecvOnly {
+				panic(fmt.Errorf("invalid operation: cannot close receive-only channel of type %s", arg.T))
+			}
+			arg.Close()
+			return nil
+		case *Nil:
+			if arg.T != nil && arg.T.Kind == ChannelType {
+				panic(errors.New("close of nil channel"))
+			}
+			panic(fmt.Errorf("close: unexpected argument %s", arg))
+		default:
+			panic(fmt.Errorf("close: unexpected argument type %T", arg))
+		}
+	}}
+
+	// Make is make builtin. Interpreter accounts memory used by made slices before calling it.
+	Make = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) == 0 {
+			panic(errors.New("make: expected type argument"))
+		}
+		t, ok := args[0].(*TypeObject)
+		if !ok {
+			panic(fmt.Errorf("make: %s is not a type", args[0]))
+		}
+
+		sizes := make([]int, len(args)-1)
+		for i, arg := range args[1:] {
+			if n, ok := arg.(*Named); ok {
+				arg = n.Value
+			}
+			var size int
+			switch {
+			case arg.Type().IsSigned():
+				size = int(Int64Value(arg))
+			case arg.Type().IsUnsigned():
+				size = int(Uint64Value(arg))
+			default:
+				panic(fmt.Errorf("make: size argument %s must be integer", arg))
+			}
+			if size < 0 {
+				panic(fmt.Errorf("make: size argument %d must not be negative", size))
+			}
+			sizes[i] = size
+		}
+
+		switch t.Kind {
+		case ChannelType:
+			switch len(sizes) {
+			case 0:
+				return NewChannel(t, 0)
+			case 1:
+				return NewChannel(t, sizes[0])
+			}
+			panic(fmt.Errorf("make: expected 1 or 2 arguments for %s, got %d", t, len(args)))
+
+		case SliceType:
+			switch len(sizes) {
+			case 1:
+				sizes = append(sizes, sizes[0])
+			case 2:
+				if sizes[0] > sizes[1] {
+					panic(fmt.Errorf("make: len larger than cap in make(%s)", t))
+				}
+			default:
+				panic(fmt.Errorf("make: expected 2 or 3 arguments for %s, got %d", t, len(args)))
+			}
+			values := make([]Object, sizes[0], sizes[1])
+			for i := range values {
+				values[i] = t.Elem.Zero()
+			}
+			return &Slice{T: t, Values: values}
+
+		default:
+			panic(fmt.Errorf("make: cannot make %s", t))
+		}
+	}}
+
+	complexBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 2 {
+			panic(fmt.Errorf("complex: expected 2 arguments, got %d", len(args)))
+		}
+
+		// integer arguments are constants that take the type of other argument
+		t := ComplexType
+		for _, arg := range args {
+			switch arg.Type() {
+			case Float32Type:
+				t = Complex64Type
+			case FloatType:
+				// nothing
+			default:
+				if !arg.Type().IsInteger() {
+					panic(fmt.Errorf("complex: unexpected argument type %T", arg))
+				}
+			}
+		}
+		if args[0].Type().IsFloat() && args[1].Type().IsFloat() && args[0].Type() != args[1].Type() {
+			panic(fmt.Errorf("complex: mismatched argument types %s and %s", args[0].Type().Name(), args[1].Type().Name()))
+		}
+
+		return NewComplex(t, complex(Float64Value(args[0]), Float64Value(args[1])))
+	}}
+
+	realBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("real: expected 1 argument, got %d", len(args)))
+		}
+		switch arg := args[0].(type) {
+		case *Complex:
+			return &Float{Value: real(arg.Value)}
+		case *Complex64:
+			return &Float32{Value: real(arg.Value)}
+		default:
+			panic(fmt.Errorf("real: unexpected argument type %T", arg))
+		}
+	}}
+
+	imagBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("imag: expected 1 argument, got %d", len(args)))
+		}
+		switch arg := args[0].(type) {
+		case *Complex:
+			return &Float{Value: imag(arg.Value)}
+		case *Complex64:
+			return &Float32{Value: imag(arg.Value)}
+		default:
+			panic(fmt.Errorf("imag: unexpected argument type %T", arg))
+		}
+	}}
+
+	panicBuiltin = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("panic: expected 1 argument, got %d", len(args)))
+		}
+		panic(&PanicError{Value: args[0]})
+	}}
+
+	// Recover is recover builtin. Interpreter handles its calls made directly by deferred functions
+	// while panicking; in all other cases it returns nil interface{} value, like in Go.
+	Recover = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 0 {
+			panic(fmt.Errorf("recover: expected 0 arguments, got %d", len(args)))
+		}
+		return &Interface{T: &TypeObject{Kind: InterfaceType}}
+	}}
+
+	// After is after builtin: after(d) returns a channel that receives the current time
+	// in Unix nanoseconds after d nanoseconds, like time.After.
+	// Interpreter handles its calls with Scheduler.After; the function itself only checks arguments.
+	After = &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		if len(args) != 1 {
+			panic(fmt.Errorf("after: expected 1 argument, got %d", len(args)))
+		}
+		if !args[0].Type().IsInteger() {
+			panic(fmt.Errorf("after: unexpected argument type %T", args[0]))
+		}
+		return args[0]
+	}}
+
 	// TODO append
-	// TODO cap
-	// TODO close
 	// TODO copy
 	// TODO delete
-	// TODO make
 	// TODO new
-	// TODO panic
-	// TODO recover
 )
 
+// PanicError is a Go panic value used by panic builtin.
+type PanicError struct {
+	Value Object
+}
+
+func (e *PanicError) Error() string {
+	return e.Value.String()
+}
+
 func makePrintBuiltin(stdout io.Writer) *GoFunction {
-	return &GoFunction{Func: func(args ...Object) Object {
+	return &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
 		res := make([]string, len(args))
 		for i, arg := range args {
 			res[i] = arg.String()
@@ -52,7 +261,7 @@ func makePrintBuiltin(stdout io.Writer) *GoFunction {
 }
 
 func makePrintlnBuiltin(stdout io.Writer) *GoFunction {
-	return &GoFunction{Func: func(args ...Object) Object {
+	return &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
 		res := make([]string, len(args))
 		for i, arg := range args {
 			res[i] = arg.String()
@@ -64,11 +273,37 @@ func makePrintlnBuiltin(stdout io.Writer) *GoFunction {
 
 // Builtin returns a Scope of predeclared identifiers.
 func Builtin(stdout io.Writer) *Scope {
-	return &Scope{
-		store: map[string]Object{
-			"print":   makePrintBuiltin(stdout),
-			"println": makePrintlnBuiltin(stdout),
-			"len":     lenBuiltin,
-		},
+	store := map[string]Object{
+		"print":   makePrintBuiltin(stdout),
+		"println": makePrintlnBuiltin(stdout),
+		"len":     lenBuiltin,
+		"cap":     capBuiltin,
+		"make":    Make,
+		"close":   closeBuiltin,
+		"complex": complexBuiltin,
+		"real":    realBuiltin,
+		"imag":    imagBuiltin,
+		"panic":   panicBuiltin,
+		"recover": Recover,
+		"after":   After,
+	}
+	for name, t := range predeclaredTypes {
+		store[name] = t
+	}
+	store["nil"] = UntypedNil
+
+	s := &Scope{
+		names: make(map[string]int, len(store)),
+		slots: make([]Object, 0, len(store)),
+	}
+	for name, obj := range store {
+		s.names[name] = len(s.slots)
+		s.slots = append(s.slots, obj)
 	}
+	return s
 }
+
+// check interfaces
+var (
+	_ error = (*PanicError)(nil)
+)
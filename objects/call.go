@@ -0,0 +1,61 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"context"
+	"errors"
+)
+
+// Caller calls functions in the current goroutine of Gosh program.
+// Interpreter and virtual machine pass it to Go functions in the context; see Call.
+type Caller interface {
+	// Call calls function f with given arguments and returns its result.
+	// Recoverable runtime errors and panics are returned as errors; other failures are Go panics.
+	Call(ctx context.Context, f Object, args []Object) (Object, error)
+}
+
+// callerKey is a context key for Caller.
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx with the given caller.
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// callerFrom returns caller from ctx, or nil.
+func callerFrom(ctx context.Context) Caller {
+	c, _ := ctx.Value(callerKey{}).(Caller)
+	return c
+}
+
+// Call calls Gosh or Go function f with given arguments, and returns its result.
+// It is intended for Go functions that take callbacks; ctx should be the context passed to them,
+// and Call should be made before the function returns, in the same goroutine.
+//
+// Recoverable runtime errors and panics in f (including failures of Go functions called by f) are returned
+// as *interpreter.RuntimeError. Go function may handle the error, or return or panic with it:
+// then the panic continues in the Gosh program with the original value and call stack.
+// Fatal errors and stopping of the program unwind Go function with a Go panic that should not be recovered.
+//
+// Outside of Gosh program (when ctx has no caller) only Go functions can be called, and they are called directly.
+func Call(ctx context.Context, f Object, args ...Object) (Object, error) {
+	if c := callerFrom(ctx); c != nil {
+		return c.Call(ctx, f, args)
+	}
+
+	gf, ok := f.(*GoFunction)
+	if !ok {
+		return nil, errors.New("only Go functions can be called outside of Gosh program")
+	}
+	res := gf.Func(ctx, args...)
+	if res == nil {
+		res = UntypedNil
+	}
+	return res, nil
+}
@@ -0,0 +1,453 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gosh-lang.org/gosh/ast"
+)
+
+// ErrDeadlock is returned by blocking channel operations when all goroutines are blocked.
+var ErrDeadlock = errors.New("all goroutines are asleep - deadlock!")
+
+// Channel represents non-nil channel runtime object.
+type Channel struct {
+	T     *TypeObject   // channel type
+	State *ChannelState // shared by all values of the same channel
+}
+
+// NewChannel returns a new channel of type t with the given buffer size.
+func NewChannel(t *TypeObject, size int) *Channel {
+	return &Channel{
+		T: t,
+		State: &ChannelState{
+			id:   atomic.AddUint64(&lastChannelID, 1),
+			elem: t.Elem,
+			size: size,
+		},
+	}
+}
+
+// Type returns ChannelType.
+func (c *Channel) Type() Type { return ChannelType }
+
+func (c *Channel) String() string { return fmt.Sprintf("%p", c.State) }
+
+// Len returns the number of buffered values.
+func (c *Channel) Len() int {
+	c.State.m.Lock()
+	defer c.State.m.Unlock()
+	return len(c.State.buf)
+}
+
+// Cap returns the buffer size.
+func (c *Channel) Cap() int {
+	return c.State.size
+}
+
+// Close closes the channel: blocked receivers get zero values, and blocked senders panic.
+func (c *Channel) Close() {
+	st := c.State
+	st.m.Lock()
+	defer st.m.Unlock()
+
+	if st.closed {
+		panic(errors.New("close of closed channel"))
+	}
+	st.closed = true
+
+	for w := dequeue(&st.recvq); w != nil; w = dequeue(&st.recvq) {
+		w.value, w.ok = st.elem.Zero(), false
+		w.wake()
+	}
+	for w := dequeue(&st.sendq); w != nil; w = dequeue(&st.sendq) {
+		w.ok = false
+		w.wake()
+	}
+}
+
+// lastChannelID is used to order channel locks.
+var lastChannelID uint64
+
+// ChannelState is a buffer and queues of blocked goroutines of a channel.
+type ChannelState struct {
+	id     uint64
+	m      sync.Mutex
+	elem   *TypeObject
+	size   int
+	buf    []Object
+	closed bool
+	recvq  []*waiter
+	sendq  []*waiter
+}
+
+// trySend sends v if that can be done without blocking; channel should be open and locked.
+func (st *ChannelState) trySend(v Object) bool {
+	// pass value directly to the blocked receiver
+	if w := dequeue(&st.recvq); w != nil {
+		w.value, w.ok = v, true
+		w.wake()
+		return true
+	}
+
+	if len(st.buf) < st.size {
+		st.buf = append(st.buf, v)
+		return true
+	}
+	return false
+}
+
+// tryRecv receives value if that can be done without blocking; channel should be locked.
+// It returns received value, false if channel is closed, and false if operation would block.
+func (st *ChannelState) tryRecv() (Object, bool, bool) {
+	if len(st.buf) > 0 {
+		v := st.buf[0]
+		st.buf = st.buf[1:]
+
+		// move the value of the blocked sender to the buffer
+		if w := dequeue(&st.sendq); w != nil {
+			st.buf = append(st.buf, w.value)
+			w.ok = true
+			w.wake()
+		}
+		return v, true, true
+	}
+
+	// take value directly from the blocked sender of unbuffered channel
+	if w := dequeue(&st.sendq); w != nil {
+		v := w.value
+		w.ok = true
+		w.wake()
+		return v, true, true
+	}
+
+	if st.closed {
+		return st.elem.Zero(), false, true
+	}
+	return nil, false, false
+}
+
+// removeSelection removes waiters of the given select statement from queues; channel should be locked.
+func (st *ChannelState) removeSelection(sel *selection) {
+	remove := func(q []*waiter) []*waiter {
+		res := q[:0]
+		for _, w := range q {
+			if w.sel != sel {
+				res = append(res, w)
+			}
+		}
+		return res
+	}
+	st.recvq = remove(st.recvq)
+	st.sendq = remove(st.sendq)
+}
+
+// waiter is a goroutine blocked on channel operation.
+type waiter struct {
+	sched *Scheduler
+	sel   *selection // for select statements; nil for single channel operation
+	index int        // select case index
+	value Object     // value to send, or received value
+	ok    bool       // false if channel was closed
+	done  chan struct{}
+}
+
+// wake unblocks the goroutine. The waker is running, so the goroutine is counted as running before that.
+func (w *waiter) wake() {
+	w.sched.unblock()
+	close(w.done)
+}
+
+// selection is a blocked select statement: it has a waiter for each case, and only one of them can fire.
+type selection struct {
+	fired int32
+	w     *waiter // fired waiter
+}
+
+// dequeue removes the first waiter from the queue and returns it, or nil if the queue is empty.
+// Waiters of already fired select statements are skipped.
+func dequeue(q *[]*waiter) *waiter {
+	for len(*q) > 0 {
+		w := (*q)[0]
+		*q = (*q)[1:]
+		if w.sel == nil {
+			return w
+		}
+		if atomic.CompareAndSwapInt32(&w.sel.fired, 0, 1) {
+			w.sel.w = w
+			return w
+		}
+	}
+	return nil
+}
+
+// SelectCase is a send or receive operation of select statement.
+type SelectCase struct {
+	Chan  *Channel // nil for nil channel
+	Send  bool     // true for send operation, false for receive operation
+	Value Object   // value to send
+}
+
+// Scheduler tracks the state of goroutines of a single program to detect deadlocks.
+type Scheduler struct {
+	cancel context.CancelFunc
+
+	m       sync.Mutex
+	last    int   // the last goroutine ID
+	running int   // number of goroutines which are not blocked
+	timers  int   // number of pending timers
+	err     error // the first fatal error
+}
+
+// NewScheduler returns a new scheduler with the main goroutine running.
+// Function cancel is called on fatal error to stop all goroutines.
+func NewScheduler(cancel context.CancelFunc) *Scheduler {
+	return &Scheduler{
+		cancel:  cancel,
+		last:    1,
+		running: 1,
+	}
+}
+
+// Go registers a new running goroutine and returns its ID.
+func (s *Scheduler) Go() int {
+	// scopes are locked from now on
+	atomic.AddInt32(&goroutines, 1)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.last++
+	s.running++
+	return s.last
+}
+
+// Exit unregisters a goroutine other than the main one.
+func (s *Scheduler) Exit() {
+	defer atomic.AddInt32(&goroutines, -1)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.running--
+	_ = s.checkDeadlock()
+}
+
+// Fail stops the program with the given fatal error. Only the first error is kept.
+func (s *Scheduler) Fail(err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.fail(err)
+}
+
+func (s *Scheduler) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+	s.cancel()
+}
+
+// checkDeadlock stops the program if all goroutines are blocked and there are no pending timers
+// that can wake them up; s.m should be locked.
+func (s *Scheduler) checkDeadlock() error {
+	if s.running == 0 && s.timers == 0 {
+		s.fail(ErrDeadlock)
+		return ErrDeadlock
+	}
+	return nil
+}
+
+// Err returns the fatal error, if any.
+func (s *Scheduler) Err() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.err
+}
+
+// block marks the current goroutine as blocked. It returns ErrDeadlock if all goroutines are blocked.
+func (s *Scheduler) block() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.running--
+	return s.checkDeadlock()
+}
+
+func (s *Scheduler) unblock() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.running++
+}
+
+// wait waits until the blocked goroutine is woken up.
+// It returns non-nil error if the program is stopped instead.
+func (s *Scheduler) wait(ctx context.Context, done chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if err := s.Err(); err != nil {
+			return err
+		}
+		return ctx.Err()
+	}
+}
+
+// After returns a receive-only channel of type `<-chan int64` that receives the current time
+// in Unix nanoseconds after duration d, like time.After.
+// Pending timer prevents deadlock detection.
+func (s *Scheduler) After(d time.Duration) *Channel {
+	ch := NewChannel(afterType, 1)
+
+	s.m.Lock()
+	s.timers++
+	s.m.Unlock()
+
+	time.AfterFunc(d, func() {
+		ch.State.m.Lock()
+		ch.State.trySend(&Int64{Value: time.Now().UnixNano()})
+		ch.State.m.Unlock()
+
+		s.m.Lock()
+		defer s.m.Unlock()
+		s.timers--
+		_ = s.checkDeadlock()
+	})
+
+	return ch
+}
+
+var afterType = &TypeObject{Kind: ChannelType, Dir: ast.RecvOnly, Elem: predeclaredTypes["int64"]}
+
+// Send sends value v to channel ch; nil ch blocks forever.
+// Send on closed channel panics with Go error.
+// Returned error is non-nil if the program is stopped.
+func (s *Scheduler) Send(ctx context.Context, ch *Channel, v Object) error {
+	_, _, _, err := s.Select(ctx, []SelectCase{{Chan: ch, Send: true, Value: v}}, true)
+	return err
+}
+
+// Recv receives value from channel ch; nil ch blocks forever.
+// For closed channel, it returns zero value and false.
+// Returned error is non-nil if the program is stopped.
+func (s *Scheduler) Recv(ctx context.Context, ch *Channel) (Object, bool, error) {
+	_, v, ok, err := s.Select(ctx, []SelectCase{{Chan: ch}}, true)
+	return v, ok, err
+}
+
+// Select performs one of the given channel operations like Go select statement does.
+// If several operations can proceed, one of them is chosen randomly.
+// If none can, it blocks until one of them can, or returns -1 immediately if block is false.
+// It returns the index of chosen case, and, for receive operations, received value and false if channel is closed.
+// Send on closed channel panics with Go error.
+// Returned error is non-nil if the program is stopped.
+func (s *Scheduler) Select(ctx context.Context, cases []SelectCase, block bool) (int, Object, bool, error) {
+	// lock all channels in the same order to avoid deadlocks with other select statements
+	var states []*ChannelState
+	for _, c := range cases {
+		if c.Chan != nil {
+			states = append(states, c.Chan.State)
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].id < states[j].id })
+	lock := func() {
+		for n, st := range states {
+			if n == 0 || states[n-1] != st {
+				st.m.Lock()
+			}
+		}
+	}
+	unlock := func() {
+		for n, st := range states {
+			if n == 0 || states[n-1] != st {
+				st.m.Unlock()
+			}
+		}
+	}
+
+	lock()
+	for _, n := range rand.Perm(len(cases)) {
+		c := cases[n]
+		if c.Chan == nil {
+			continue
+		}
+
+		st := c.Chan.State
+		if c.Send {
+			if st.closed {
+				unlock()
+				panic(errors.New("send on closed channel"))
+			}
+			if st.trySend(c.Value) {
+				unlock()
+				return n, nil, false, nil
+			}
+			continue
+		}
+
+		if v, ok, ready := st.tryRecv(); ready {
+			unlock()
+			return n, v, ok, nil
+		}
+	}
+
+	if !block {
+		unlock()
+		return -1, nil, false, nil
+	}
+
+	// enqueue waiter for each case and wait until one of them fires
+	sel := new(selection)
+	done := make(chan struct{})
+	for n, c := range cases {
+		if c.Chan == nil {
+			continue
+		}
+		w := &waiter{sched: s, sel: sel, index: n, value: c.Value, done: done}
+		if c.Send {
+			c.Chan.State.sendq = append(c.Chan.State.sendq, w)
+		} else {
+			c.Chan.State.recvq = append(c.Chan.State.recvq, w)
+		}
+	}
+	err := s.block()
+	unlock()
+	if err != nil {
+		return -1, nil, false, err
+	}
+
+	if len(states) == 0 {
+		// nil channels block forever
+		done = nil
+	}
+	if err = s.wait(ctx, done); err != nil {
+		return -1, nil, false, err
+	}
+
+	lock()
+	for _, st := range states {
+		st.removeSelection(sel)
+	}
+	unlock()
+
+	w := sel.w
+	if cases[w.index].Send && !w.ok {
+		panic(errors.New("send on closed channel"))
+	}
+	return w.index, w.value, w.ok, nil
+}
+
+// check interfaces
+var (
+	_ Object = (*Channel)(nil)
+)
@@ -0,0 +1,75 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// GoPackage contains exported members of Go package for Gosh programs.
+// Bindings are usually generated by `gosh bind` command and registered in the module table by init function.
+type GoPackage struct {
+	Path   string                   // import path
+	Name   string                   // package name
+	Values map[string]reflect.Value // functions, constants and variables; variables are addressable
+	Types  map[string]reflect.Type  // types
+}
+
+// Member returns Gosh object for member of package with given name.
+// Values are converted by FromGo when they are used, so variables have their current values.
+func (p *GoPackage) Member(name string) (Object, bool) {
+	if v, ok := p.Values[name]; ok {
+		return FromGo(v), true
+	}
+	if t, ok := p.Types[name]; ok {
+		return typeFromGo(t), true
+	}
+	return nil, false
+}
+
+// Names returns sorted names of all package members.
+func (p *GoPackage) Names() []string {
+	res := make([]string, 0, len(p.Values)+len(p.Types))
+	for name := range p.Values {
+		res = append(res, name)
+	}
+	for name := range p.Types {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// module table: registered Go packages by import path
+var (
+	goPackagesM sync.RWMutex
+	goPackages  = make(map[string]*GoPackage)
+)
+
+// RegisterGoPackage adds Go package bindings to the module table.
+// It panics if bindings for the same import path are already registered.
+func RegisterGoPackage(p *GoPackage) {
+	goPackagesM.Lock()
+	defer goPackagesM.Unlock()
+
+	if _, ok := goPackages[p.Path]; ok {
+		panic(fmt.Sprintf("RegisterGoPackage: package %q is already registered", p.Path))
+	}
+	goPackages[p.Path] = p
+}
+
+// LookupGoPackage returns registered Go package bindings by import path, or nil.
+func LookupGoPackage(path string) *GoPackage {
+	goPackagesM.RLock()
+	defer goPackagesM.RUnlock()
+
+	return goPackages[path]
+}
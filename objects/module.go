@@ -0,0 +1,47 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"strconv"
+)
+
+// Module represents an imported module: Gosh module with its global scope, or Go package.
+// Programs access exported members of modules with selector expressions like `util.Join`.
+type Module struct {
+	Path  string     // import path
+	Name  string     // package name
+	Scope *Scope     // global scope of Gosh module; nil for Go package
+	Go    *GoPackage // Go package bindings; nil for Gosh module
+}
+
+// NewGoModule returns module for Go package bindings.
+func NewGoModule(p *GoPackage) *Module {
+	return &Module{Path: p.Path, Name: p.Name, Go: p}
+}
+
+// Type returns ModuleType.
+func (m *Module) Type() Type { return ModuleType }
+
+func (m *Module) String() string {
+	return "package " + m.Name + " (" + strconv.Quote(m.Path) + ")"
+}
+
+// Member returns module member with given name: global entity declared by Gosh module, or Go package member.
+// It does not check that the name is exported.
+func (m *Module) Member(name string) (Object, bool) {
+	if m.Go != nil {
+		return m.Go.Member(name)
+	}
+	return m.Scope.LookupLocal(name)
+}
+
+// check interfaces
+var (
+	_ Object = (*Module)(nil)
+)
@@ -0,0 +1,417 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Int8 represents int8 runtime object.
+type Int8 struct {
+	Value int8
+}
+
+// Type returns Int8Type.
+func (i *Int8) Type() Type { return Int8Type }
+
+func (i *Int8) String() string { return strconv.FormatInt(int64(i.Value), 10) }
+
+// Int16 represents int16 runtime object.
+type Int16 struct {
+	Value int16
+}
+
+// Type returns Int16Type.
+func (i *Int16) Type() Type { return Int16Type }
+
+func (i *Int16) String() string { return strconv.FormatInt(int64(i.Value), 10) }
+
+// Int32 represents int32 runtime object.
+type Int32 struct {
+	Value int32
+}
+
+// Type returns Int32Type.
+func (i *Int32) Type() Type { return Int32Type }
+
+func (i *Int32) String() string { return strconv.FormatInt(int64(i.Value), 10) }
+
+// Int64 represents int64 runtime object.
+type Int64 struct {
+	Value int64
+}
+
+// Type returns Int64Type.
+func (i *Int64) Type() Type { return Int64Type }
+
+func (i *Int64) String() string { return strconv.FormatInt(int64(i.Value), 10) }
+
+// Uint represents uint runtime object.
+type Uint struct {
+	Value uint
+}
+
+// Type returns UintType.
+func (u *Uint) Type() Type { return UintType }
+
+func (u *Uint) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Uint8 represents uint8 runtime object.
+type Uint8 struct {
+	Value uint8
+}
+
+// Type returns Uint8Type.
+func (u *Uint8) Type() Type { return Uint8Type }
+
+func (u *Uint8) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Uint16 represents uint16 runtime object.
+type Uint16 struct {
+	Value uint16
+}
+
+// Type returns Uint16Type.
+func (u *Uint16) Type() Type { return Uint16Type }
+
+func (u *Uint16) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Uint32 represents uint32 runtime object.
+type Uint32 struct {
+	Value uint32
+}
+
+// Type returns Uint32Type.
+func (u *Uint32) Type() Type { return Uint32Type }
+
+func (u *Uint32) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Uint64 represents uint64 runtime object.
+type Uint64 struct {
+	Value uint64
+}
+
+// Type returns Uint64Type.
+func (u *Uint64) Type() Type { return Uint64Type }
+
+func (u *Uint64) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Uintptr represents uintptr runtime object.
+type Uintptr struct {
+	Value uintptr
+}
+
+// Type returns UintptrType.
+func (u *Uintptr) Type() Type { return UintptrType }
+
+func (u *Uintptr) String() string { return strconv.FormatUint(uint64(u.Value), 10) }
+
+// Float32 represents float32 runtime object.
+type Float32 struct {
+	Value float32
+}
+
+// Type returns Float32Type.
+func (f *Float32) Type() Type { return Float32Type }
+
+func (f *Float32) String() string { return strconv.FormatFloat(float64(f.Value), 'e', -1, 32) }
+
+// Complex represents complex128 runtime object.
+type Complex struct {
+	Value complex128
+}
+
+// Type returns ComplexType.
+func (c *Complex) Type() Type { return ComplexType }
+
+func (c *Complex) String() string { return strconv.FormatComplex(c.Value, 'g', -1, 128) }
+
+// Complex64 represents complex64 runtime object.
+type Complex64 struct {
+	Value complex64
+}
+
+// Type returns Complex64Type.
+func (c *Complex64) Type() Type { return Complex64Type }
+
+func (c *Complex64) String() string { return strconv.FormatComplex(complex128(c.Value), 'g', -1, 64) }
+
+// Small int values are shared, as loop counters, indexes and lengths usually are.
+const (
+	minSmallInt = -128
+	maxSmallInt = 1023
+)
+
+var smallInts [maxSmallInt - minSmallInt + 1]Integer
+
+func init() {
+	for n := range smallInts {
+		smallInts[n].Value = n + minSmallInt
+	}
+}
+
+// NewInt returns an int object; small values are shared.
+func NewInt(v int) *Integer {
+	if v >= minSmallInt && v <= maxSmallInt {
+		return &smallInts[v-minSmallInt]
+	}
+	return &Integer{Value: v}
+}
+
+// NewInteger returns an integer object of the given type.
+// Bits are truncated to the type's size, which gives Go's wrap-around semantics.
+func NewInteger(t Type, bits uint64) Object {
+	switch t {
+	case IntegerType:
+		return NewInt(int(bits))
+	case Int8Type:
+		return &Int8{Value: int8(bits)}
+	case Int16Type:
+		return &Int16{Value: int16(bits)}
+	case Int32Type:
+		return &Int32{Value: int32(bits)}
+	case Int64Type:
+		return &Int64{Value: int64(bits)}
+	case UintType:
+		return &Uint{Value: uint(bits)}
+	case Uint8Type:
+		return &Uint8{Value: uint8(bits)}
+	case Uint16Type:
+		return &Uint16{Value: uint16(bits)}
+	case Uint32Type:
+		return &Uint32{Value: uint32(bits)}
+	case Uint64Type:
+		return &Uint64{Value: bits}
+	case UintptrType:
+		return &Uintptr{Value: uintptr(bits)}
+	default:
+		panic(fmt.Sprintf("NewInteger: unexpected type %s", t))
+	}
+}
+
+// NewFloat returns a floating-point object of the given type.
+func NewFloat(t Type, v float64) Object {
+	switch t {
+	case FloatType:
+		return &Float{Value: v}
+	case Float32Type:
+		return &Float32{Value: float32(v)}
+	default:
+		panic(fmt.Sprintf("NewFloat: unexpected type %s", t))
+	}
+}
+
+// NewComplex returns a complex object of the given type.
+func NewComplex(t Type, v complex128) Object {
+	switch t {
+	case ComplexType:
+		return &Complex{Value: v}
+	case Complex64Type:
+		return &Complex64{Value: complex64(v)}
+	default:
+		panic(fmt.Sprintf("NewComplex: unexpected type %s", t))
+	}
+}
+
+// Int64Value returns the value of an integer object as int64.
+// Unsigned values are converted with Go conversion rules.
+func Int64Value(o Object) int64 {
+	switch o := o.(type) {
+	case *Integer:
+		return int64(o.Value)
+	case *Int8:
+		return int64(o.Value)
+	case *Int16:
+		return int64(o.Value)
+	case *Int32:
+		return int64(o.Value)
+	case *Int64:
+		return o.Value
+	default:
+		return int64(Uint64Value(o))
+	}
+}
+
+// Uint64Value returns the value of an integer object as uint64.
+// Signed values are converted with Go conversion rules.
+func Uint64Value(o Object) uint64 {
+	switch o := o.(type) {
+	case *Uint:
+		return uint64(o.Value)
+	case *Uint8:
+		return uint64(o.Value)
+	case *Uint16:
+		return uint64(o.Value)
+	case *Uint32:
+		return uint64(o.Value)
+	case *Uint64:
+		return o.Value
+	case *Uintptr:
+		return uint64(o.Value)
+	case *Integer, *Int8, *Int16, *Int32, *Int64:
+		return uint64(Int64Value(o))
+	default:
+		panic(fmt.Sprintf("Uint64Value: unexpected object %T", o))
+	}
+}
+
+// Float64Value returns the value of a non-complex numeric object as float64.
+func Float64Value(o Object) float64 {
+	switch o := o.(type) {
+	case *Float:
+		return o.Value
+	case *Float32:
+		return float64(o.Value)
+	}
+
+	if o.Type().IsSigned() {
+		return float64(Int64Value(o))
+	}
+	return float64(Uint64Value(o))
+}
+
+// Complex128Value returns the value of a numeric object as complex128.
+func Complex128Value(o Object) complex128 {
+	switch o := o.(type) {
+	case *Complex:
+		return o.Value
+	case *Complex64:
+		return complex128(o.Value)
+	default:
+		return complex(Float64Value(o), 0)
+	}
+}
+
+// integer type ranges
+var (
+	minInts = map[Type]int64{
+		IntegerType: math.MinInt64,
+		Int8Type:    math.MinInt8,
+		Int16Type:   math.MinInt16,
+		Int32Type:   math.MinInt32,
+		Int64Type:   math.MinInt64,
+	}
+	maxInts = map[Type]int64{
+		IntegerType: math.MaxInt64,
+		Int8Type:    math.MaxInt8,
+		Int16Type:   math.MaxInt16,
+		Int32Type:   math.MaxInt32,
+		Int64Type:   math.MaxInt64,
+	}
+	maxUints = map[Type]uint64{
+		UintType:    math.MaxUint64,
+		Uint8Type:   math.MaxUint8,
+		Uint16Type:  math.MaxUint16,
+		Uint32Type:  math.MaxUint32,
+		Uint64Type:  math.MaxUint64,
+		UintptrType: math.MaxUint64,
+	}
+)
+
+// Representable returns true if numeric value v (of any numeric type) can be represented
+// by type t without overflow or truncation, as required for Go constants.
+func Representable(v Object, t Type) bool {
+	vt := v.Type()
+	if vt.IsComplex() {
+		c := Complex128Value(v)
+		if t.IsComplex() {
+			return t == ComplexType || (math.Abs(real(c)) <= math.MaxFloat32 && math.Abs(imag(c)) <= math.MaxFloat32)
+		}
+		if imag(c) != 0 {
+			return false
+		}
+		return Representable(&Float{Value: real(c)}, t)
+	}
+
+	switch {
+	case vt.IsFloat() && t.IsInteger():
+		f := Float64Value(v)
+		if f != math.Trunc(f) {
+			return false
+		}
+		if t.IsSigned() {
+			return f >= float64(minInts[t]) && f <= float64(maxInts[t])
+		}
+		return f >= 0 && f <= float64(maxUints[t])
+
+	case vt.IsSigned() && t.IsSigned():
+		i := Int64Value(v)
+		return i >= minInts[t] && i <= maxInts[t]
+
+	case vt.IsSigned() && t.IsUnsigned():
+		i := Int64Value(v)
+		return i >= 0 && uint64(i) <= maxUints[t]
+
+	case vt.IsUnsigned() && t.IsSigned():
+		return Uint64Value(v) <= uint64(maxInts[t])
+
+	case vt.IsUnsigned() && t.IsUnsigned():
+		return Uint64Value(v) <= maxUints[t]
+
+	case t == Float32Type, t == Complex64Type:
+		return math.Abs(Float64Value(v)) <= math.MaxFloat32
+
+	case t == FloatType, t == ComplexType:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Convert converts numeric object v to numeric type t with Go conversion rules:
+// integers wrap around, floats are truncated towards zero.
+// Complex values are converted to real types by dropping imaginary part;
+// that is allowed only for constants.
+func Convert(v Object, t Type) Object {
+	vt := v.Type()
+	if vt.IsComplex() && !t.IsComplex() {
+		v = &Float{Value: real(Complex128Value(v))}
+		vt = FloatType
+	}
+
+	switch {
+	case t.IsInteger() && vt.IsFloat():
+		f := Float64Value(v)
+		if t.IsSigned() {
+			return NewInteger(t, uint64(int64(f)))
+		}
+		return NewInteger(t, uint64(f))
+
+	case t.IsInteger():
+		return NewInteger(t, Uint64Value(v))
+
+	case t.IsFloat():
+		return NewFloat(t, Float64Value(v))
+
+	case t.IsComplex():
+		return NewComplex(t, Complex128Value(v))
+
+	default:
+		panic(fmt.Sprintf("Convert: unexpected type %s", t))
+	}
+}
+
+// check interfaces
+var (
+	_ Object = (*Int8)(nil)
+	_ Object = (*Int16)(nil)
+	_ Object = (*Int32)(nil)
+	_ Object = (*Int64)(nil)
+	_ Object = (*Uint)(nil)
+	_ Object = (*Uint8)(nil)
+	_ Object = (*Uint16)(nil)
+	_ Object = (*Uint32)(nil)
+	_ Object = (*Uint64)(nil)
+	_ Object = (*Uintptr)(nil)
+	_ Object = (*Float32)(nil)
+	_ Object = (*Complex)(nil)
+	_ Object = (*Complex64)(nil)
+)
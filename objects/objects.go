@@ -8,12 +8,14 @@
 package objects
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/tokens"
 )
 
 // Object is a common interface for all Gosh runtime objects.
@@ -53,6 +55,20 @@ func (b *Boolean) Type() Type { return BooleanType }
 
 func (b *Boolean) String() string { return strconv.FormatBool(b.Value) }
 
+// Shared boolean objects. Objects are never modified, so they can be used instead of allocating new ones.
+var (
+	True  = &Boolean{Value: true}
+	False = &Boolean{Value: false}
+)
+
+// NewBoolean returns True or False.
+func NewBoolean(v bool) *Boolean {
+	if v {
+		return True
+	}
+	return False
+}
+
 // String represents string runtime object.
 type String struct {
 	Value string
@@ -73,33 +89,53 @@ func (c *Continue) String() string {
 	return "continue"
 }
 
+// Return represents a value returned by return statement.
+type Return struct {
+	Value Object
+}
+
+// Type returns ReturnType.
+func (r *Return) Type() Type { return ReturnType }
+
+func (r *Return) String() string {
+	return "return " + r.Value.String()
+}
+
 // Function represents function runtime object.
 type Function struct {
 	Parameters []*ast.Identifier
+	Types      []ast.Expression // parameter types; nil for untyped parameters
+	Results    []*ast.Result
 	Body       *ast.BlockStatement
 	Scope      *Scope
+	File       string       // source file of the function literal; empty for programs without files
+	Lines      tokens.Lines // line offsets of that file; or nil
+	Code       interface{}  // compiled body for the virtual machine; nil for functions created by the interpreter
 }
 
 // Type returns FunctionType.
 func (f *Function) Type() Type { return FunctionType }
 
 func (f *Function) String() string {
-	params := make([]string, len(f.Parameters))
-	for i, p := range f.Parameters {
-		params[i] = p.String()
-	}
-
 	var res strings.Builder
 	res.WriteString("func(")
-	res.WriteString(strings.Join(params, ", "))
+	res.WriteString(ast.ParametersString(f.Parameters, f.Types))
 	res.WriteString(") ")
+	if len(f.Results) > 0 {
+		res.WriteString(ast.ResultsString(f.Results))
+		res.WriteString(" ")
+	}
 	res.WriteString(f.Body.String())
 	return res.String()
 }
 
 // GoFunction represents Go function.
+// Context ctx is the one passed to the interpreter;
+// functions that may run for a long time should return when it is done.
+// It also carries the caller of the current goroutine, so functions can call their function arguments with Call.
+// Func must not retain args slice after it returns: interpreter and virtual machine reuse it.
 type GoFunction struct {
-	Func func(args ...Object) Object
+	Func func(ctx context.Context, args ...Object) Object
 }
 
 // Type returns GoFunctionType.
@@ -107,10 +143,87 @@ func (gf *GoFunction) Type() Type { return GoFunctionType }
 
 func (gf *GoFunction) String() string { return reflect.ValueOf(gf.Func).String() }
 
+// Nil represents nil runtime object: untyped nil,
+// or a nil value of slice, map, pointer, function or channel type.
+type Nil struct {
+	T *TypeObject // nil for untyped nil
+}
+
+// Type returns NilType.
+func (n *Nil) Type() Type { return NilType }
+
+// UntypedNil is a shared untyped nil object; it is also returned by statements and functions without results.
+var UntypedNil = &Nil{}
+
+func (n *Nil) String() string {
+	if n.T != nil {
+		switch n.T.Kind {
+		case SliceType:
+			return "[]"
+		case MapType:
+			return "map[]"
+		}
+	}
+	return "<nil>"
+}
+
+// Interface represents a value of interface type: a dynamic value and its type.
+// Interface holding a typed nil value is not equal to nil interface.
+type Interface struct {
+	T     *TypeObject // interface type
+	Value Object      // dynamic value; nil for nil interface
+}
+
+// Type returns InterfaceType.
+func (i *Interface) Type() Type { return InterfaceType }
+
+func (i *Interface) String() string {
+	if i.Value == nil {
+		return "<nil>"
+	}
+	return i.Value.String()
+}
+
+// Slice represents non-nil slice runtime object.
+type Slice struct {
+	T      *TypeObject // slice type
+	Values []Object
+}
+
+// Type returns SliceType.
+func (s *Slice) Type() Type { return SliceType }
+
+func (s *Slice) String() string {
+	values := make([]string, len(s.Values))
+	for i, v := range s.Values {
+		values[i] = v.String()
+	}
+	return "[" + strings.Join(values, " ") + "]"
+}
+
+// Named represents a value of defined type with basic underlying type (e.g. `Celsius` in `type Celsius float64`).
+type Named struct {
+	T     *TypeObject // defined type
+	Value Object      // value of underlying type
+}
+
+// Type returns NamedType.
+func (n *Named) Type() Type { return NamedType }
+
+func (n *Named) String() string { return n.Value.String() }
+
 // check interfaces
 var (
 	_ Object = (*Integer)(nil)
+	_ Object = (*Float)(nil)
 	_ Object = (*Boolean)(nil)
+	_ Object = (*String)(nil)
+	_ Object = (*Continue)(nil)
+	_ Object = (*Return)(nil)
 	_ Object = (*Function)(nil)
 	_ Object = (*GoFunction)(nil)
+	_ Object = (*Nil)(nil)
+	_ Object = (*Interface)(nil)
+	_ Object = (*Slice)(nil)
+	_ Object = (*Named)(nil)
 )
@@ -8,10 +8,247 @@
 package objects
 
 import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/ast"
 )
 
-// This dummy test exists for correct test coverage calculations.
-// Remove it when real tests are added.
-func TestDummy(t *testing.T) {
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		v        Object
+		t        Type
+		expected Object
+	}{
+		{&Integer{Value: 300}, Int8Type, &Int8{Value: 44}},
+		{&Integer{Value: -1}, Uint16Type, &Uint16{Value: 65535}},
+		{&Int8{Value: -1}, Uint64Type, &Uint64{Value: 18446744073709551615}},
+		{&Uint8{Value: 255}, Int8Type, &Int8{Value: -1}},
+		{&Float{Value: -3.9}, IntegerType, &Integer{Value: -3}},
+		{&Float{Value: 3.9}, Uint8Type, &Uint8{Value: 3}},
+		{&Int32{Value: 7}, Float32Type, &Float32{Value: 7}},
+		{&Float32{Value: 0.5}, FloatType, &Float{Value: 0.5}},
+	} {
+		assert.Equal(t, tc.expected, Convert(tc.v, tc.t), "%s(%s)", tc.t.Name(), tc.v)
+	}
+}
+
+func TestRepresentable(t *testing.T) {
+	for _, tc := range []struct {
+		v        Object
+		t        Type
+		expected bool
+	}{
+		{&Integer{Value: 127}, Int8Type, true},
+		{&Integer{Value: 128}, Int8Type, false},
+		{&Integer{Value: -1}, UintType, false},
+		{&Integer{Value: 255}, Uint8Type, true},
+		{&Float{Value: 2.0}, IntegerType, true},
+		{&Float{Value: 2.5}, IntegerType, false},
+		{&Float{Value: 1e300}, Float32Type, false},
+		{&Uint64{Value: 1 << 63}, Int64Type, false},
+	} {
+		assert.Equal(t, tc.expected, Representable(tc.v, tc.t), "%s %s", tc.t.Name(), tc.v)
+	}
+}
+
+func TestShared(t *testing.T) {
+	assert.True(t, NewInt(-128) == NewInt(-128))
+	assert.True(t, NewInt(1023) == NewInteger(IntegerType, 1023))
+	assert.False(t, NewInt(1024) == NewInt(1024))
+	assert.Equal(t, &Integer{Value: 1024}, NewInt(1024))
+	assert.Equal(t, &Integer{Value: -129}, NewInt(-129))
+	assert.Equal(t, &Integer{Value: 7}, NewInt(7))
+
+	assert.True(t, NewBoolean(true) == True)
+	assert.True(t, NewBoolean(false) == False)
+	assert.True(t, (&TypeObject{Kind: BooleanType}).Zero() == False)
+}
+
+func TestBlockScope(t *testing.T) {
+	global := NewScope(nil)
+	assert.True(t, global.Define("g", &Integer{Value: 1}))
+
+	outer := NewBlockScope(global, 1)
+	inner := NewBlockScope(outer, 6)
+	assert.Nil(t, inner.Get(1, 0))
+	assert.False(t, inner.SetSlot(1, 0, &Integer{Value: 2}))
+	assert.True(t, outer.DefineSlot(0, &Integer{Value: 2}))
+	assert.False(t, outer.DefineSlot(0, &Integer{Value: 3}))
+	assert.True(t, inner.SetSlot(1, 0, &Integer{Value: 4}))
+	assert.Equal(t, &Integer{Value: 4}, outer.Get(0, 0))
+
+	// block scopes are skipped by name lookups
+	assert.True(t, inner.Assign("g", &Integer{Value: 5}))
+	obj, ok := inner.Lookup("g")
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 5}, obj)
+
+	c := outer.Copy()
+	assert.True(t, c.SetSlot(0, 0, &Integer{Value: 6}))
+	assert.Equal(t, &Integer{Value: 4}, outer.Get(0, 0))
+	assert.Equal(t, global, c.Outer())
+}
+
+func TestProgramScope(t *testing.T) {
+	global := NewScope(nil)
+	assert.True(t, global.Define("a", &Integer{Value: 1}))
+
+	// program's entities are stored in the outer scope by name
+	p := NewProgramScope(global, []string{"b", "a"})
+	_, ok := global.Lookup("b")
+	assert.False(t, ok, "not declared yet")
+	assert.True(t, p.DefineSlot(0, &Integer{Value: 2}))
+	assert.False(t, p.DefineSlot(1, &Integer{Value: 3}), "already declared")
+	obj, ok := global.Lookup("b")
+	assert.True(t, ok)
+	assert.Equal(t, &Integer{Value: 2}, obj)
+
+	inner := NewBlockScope(p, 1)
+	assert.True(t, inner.SetSlot(1, 1, &Integer{Value: 4}))
+	obj, _ = global.LookupLocal("a")
+	assert.Equal(t, &Integer{Value: 4}, obj)
+	assert.True(t, global.Assign("b", &Integer{Value: 5}))
+	assert.Equal(t, &Integer{Value: 5}, inner.Get(1, 0))
+
+	// other programs see the same entities
+	p2 := NewProgramScope(global, []string{"c", "b"})
+	assert.Equal(t, &Integer{Value: 5}, p2.Get(0, 1))
+	assert.True(t, p2.Define("d", &Integer{Value: 6}))
+	obj, _ = p.Lookup("d")
+	assert.Equal(t, &Integer{Value: 6}, obj)
+}
+
+func TestFromGo(t *testing.T) {
+	intSlice := &TypeObject{Kind: SliceType, Elem: predeclaredTypes["int"]}
+	for _, tc := range []struct {
+		v        interface{}
+		expected Object
+	}{
+		{nil, UntypedNil},
+		{42, &Integer{Value: 42}},
+		{uint8(255), &Uint8{Value: 255}},
+		{time.Second, &Int64{Value: int64(time.Second)}},
+		{float32(0.5), &Float32{Value: 0.5}},
+		{"gopher", &String{Value: "gopher"}},
+		{true, True},
+		{[]int{1, 2}, &Slice{T: intSlice, Values: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}},
+		{[2]int{3, 4}, &Slice{T: intSlice, Values: []Object{&Integer{Value: 3}, &Integer{Value: 4}}}},
+		{[]int(nil), &Nil{T: intSlice}},
+		{map[string]int(nil), &GoValue{Value: reflect.ValueOf(map[string]int(nil))}},
+	} {
+		assert.Equal(t, tc.expected, FromGo(reflect.ValueOf(tc.v)), "%#v", tc.v)
+	}
+
+	// interface values are replaced by their dynamic values
+	var err error = context.Canceled
+	v := FromGo(reflect.ValueOf(&err).Elem())
+	require.IsType(t, (*GoValue)(nil), v)
+	assert.Equal(t, "context canceled", v.String())
+	assert.Equal(t, "*errors.errorString", TypeOf(v).String())
+
+	// method values are functions
+	var b strings.Builder
+	write := FromGo(reflect.ValueOf(b.WriteString)).(*GoFunction)
+	errType := &TypeObject{Kind: InterfaceType}
+	expected := &Tuple{Values: []Object{&Integer{Value: 2}, &Interface{T: errType}}, HasError: true}
+	assert.Equal(t, expected, write.Func(context.Background(), &String{Value: "go"}))
+	assert.Equal(t, "go", b.String())
+
+	// multiple results are returned together; an error result is kept as interface value
+	atoi := FromGo(reflect.ValueOf(strconv.Atoi)).(*GoFunction)
+	res := atoi.Func(context.Background(), &String{Value: "x"}).(*Tuple)
+	assert.Equal(t, `(0, strconv.Atoi: parsing "x": invalid syntax)`, res.String())
+	assert.EqualError(t, res.Err, `strconv.Atoi: parsing "x": invalid syntax`)
+	assert.Equal(t, TupleType, res.Type())
+
+	// the only error result is a tuple too
+	errFunc := FromGo(reflect.ValueOf(func() error { return context.Canceled })).(*GoFunction)
+	res = errFunc.Func(context.Background()).(*Tuple)
+	assert.Equal(t, "(context canceled)", res.String())
+	assert.Equal(t, context.Canceled, res.Err)
+	okFunc := FromGo(reflect.ValueOf(func() error { return nil })).(*GoFunction)
+	expected = &Tuple{Values: []Object{&Interface{T: errType}}, HasError: true}
+	assert.Equal(t, expected, okFunc.Func(context.Background()))
+}
+
+func TestToGo(t *testing.T) {
+	intSlice := &TypeObject{Kind: SliceType, Elem: predeclaredTypes["int"]}
+	for _, tc := range []struct {
+		o        Object
+		t        interface{} // value of Go type
+		expected interface{}
+	}{
+		{&Integer{Value: 200}, uint8(0), uint8(200)},
+		{&Float{Value: 2}, int(0), 2},
+		{&Integer{Value: 2}, time.Duration(0), 2 * time.Nanosecond},
+		{&Named{T: NewDefinedType("celsius", predeclaredTypes["float64"]), Value: &Float{Value: 36.6}}, float64(0), 36.6},
+		{&String{Value: "gopher"}, "", "gopher"},
+		{False, true, false},
+		{&Slice{T: intSlice, Values: []Object{&Integer{Value: 1}}}, []int8(nil), []int8{1}},
+		{UntypedNil, map[string]int(nil), map[string]int(nil)},
+		{&Interface{T: &TypeObject{Kind: InterfaceType}}, []int(nil), []int(nil)},
+	} {
+		gt := reflect.TypeOf(tc.t)
+		v, err := ToGo(tc.o, gt)
+		require.NoError(t, err, "%s to %s", tc.o, gt)
+		assert.Equal(t, tc.expected, v.Interface(), "%s to %s", tc.o, gt)
+	}
+
+	// Go type for interface{} is derived from Gosh type
+	var i interface{}
+	iface := reflect.TypeOf(&i).Elem()
+	v, err := ToGo(&Slice{T: intSlice, Values: []Object{&Integer{Value: 1}}}, iface)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, v.Interface())
+	v, err = ToGo(&GoValue{Value: reflect.ValueOf(context.Canceled)}, reflect.TypeOf((*error)(nil)).Elem())
+	require.NoError(t, err)
+	assert.Equal(t, context.Canceled, v.Interface())
+
+	// Go functions are converted to Go function types
+	double := &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		return NewInt(args[0].(*Integer).Value * 2)
+	}}
+	v, err = ToGo(double, reflect.TypeOf(func(int) int8 { return 0 }))
+	require.NoError(t, err)
+	assert.Equal(t, int8(42), v.Interface().(func(int) int8)(21))
+
+	for _, tc := range []struct {
+		o   Object
+		t   interface{}
+		msg string
+	}{
+		{&Integer{Value: 300}, int8(0), "cannot use 300 (type int) as Go type int8"},
+		{&Float{Value: 1.5}, int(0), "cannot use 1.5e+00 (type float64) as Go type int"},
+		{&String{Value: "1"}, int(0), "cannot use 1 (type string) as Go type int"},
+		{UntypedNil, "", "cannot use nil as Go type string"},
+		{&Slice{T: intSlice, Values: []Object{&Integer{Value: -1}}}, []uint(nil), "cannot use -1 (type int) as Go type uint"},
+		{&GoValue{Value: reflect.ValueOf(1)}, "", "cannot use 1 (type int) as Go type string"},
+	} {
+		_, err := ToGo(tc.o, reflect.TypeOf(tc.t))
+		require.Error(t, err)
+		assert.Equal(t, tc.msg, err.Error())
+	}
+}
+
+func TestCall(t *testing.T) {
+	// outside of Gosh program only Go functions can be called
+	ctx := context.Background()
+	res, err := Call(ctx, FromGo(reflect.ValueOf(strings.ToUpper)), &String{Value: "go"})
+	require.NoError(t, err)
+	assert.Equal(t, &String{Value: "GO"}, res)
+
+	f := &Function{Body: &ast.BlockStatement{}}
+	_, err = Call(ctx, f)
+	assert.EqualError(t, err, "only Go functions can be called outside of Gosh program")
+	_, err = ToGo(f, reflect.TypeOf(func() {}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "as Go type func()")
 }
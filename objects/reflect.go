@@ -0,0 +1,572 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// GoValue represents Go value that has no Gosh equivalent (map, pointer, struct, channel, etc.).
+// Gosh programs can't inspect it, but can pass it to Go functions and methods.
+type GoValue struct {
+	Value reflect.Value
+}
+
+// Type returns GoValueType.
+func (v *GoValue) Type() Type { return GoValueType }
+
+func (v *GoValue) String() string {
+	if !v.Value.CanInterface() {
+		return v.Value.String()
+	}
+	return fmt.Sprint(v.Value.Interface())
+}
+
+// Tuple represents multiple results of Go function call, or its only result of Go error type.
+// Programs assign them to several variables (e.g. `n, err := strconv.Atoi(s)`).
+// If the last result has Go error type, the call can be used as a single value:
+// non-nil error is reported as a Go function failure, and the value is the first result.
+// The only error result is the value itself (e.g. `err := os.Remove(name)`);
+// it is reported as a failure only if the call is an expression statement.
+type Tuple struct {
+	Values   []Object
+	HasError bool  // the last result has Go error type
+	Err      error // the value of that result
+}
+
+// Type returns TupleType.
+func (t *Tuple) Type() Type { return TupleType }
+
+func (t *Tuple) String() string {
+	values := make([]string, len(t.Values))
+	for i, v := range t.Values {
+		values[i] = v.String()
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+var (
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// basicKinds maps kinds of Go basic types to Gosh object types.
+var basicKinds = map[reflect.Kind]Type{
+	reflect.Int:        IntegerType,
+	reflect.Int8:       Int8Type,
+	reflect.Int16:      Int16Type,
+	reflect.Int32:      Int32Type,
+	reflect.Int64:      Int64Type,
+	reflect.Uint:       UintType,
+	reflect.Uint8:      Uint8Type,
+	reflect.Uint16:     Uint16Type,
+	reflect.Uint32:     Uint32Type,
+	reflect.Uint64:     Uint64Type,
+	reflect.Uintptr:    UintptrType,
+	reflect.Float64:    FloatType,
+	reflect.Float32:    Float32Type,
+	reflect.Complex128: ComplexType,
+	reflect.Complex64:  Complex64Type,
+	reflect.Bool:       BooleanType,
+	reflect.String:     StringType,
+}
+
+// goKinds maps Gosh basic types to Go types.
+var goKinds = map[Type]reflect.Type{
+	IntegerType:   reflect.TypeOf(int(0)),
+	Int8Type:      reflect.TypeOf(int8(0)),
+	Int16Type:     reflect.TypeOf(int16(0)),
+	Int32Type:     reflect.TypeOf(int32(0)),
+	Int64Type:     reflect.TypeOf(int64(0)),
+	UintType:      reflect.TypeOf(uint(0)),
+	Uint8Type:     reflect.TypeOf(uint8(0)),
+	Uint16Type:    reflect.TypeOf(uint16(0)),
+	Uint32Type:    reflect.TypeOf(uint32(0)),
+	Uint64Type:    reflect.TypeOf(uint64(0)),
+	UintptrType:   reflect.TypeOf(uintptr(0)),
+	FloatType:     reflect.TypeOf(float64(0)),
+	Float32Type:   reflect.TypeOf(float32(0)),
+	ComplexType:   reflect.TypeOf(complex128(0)),
+	Complex64Type: reflect.TypeOf(complex64(0)),
+	BooleanType:   reflect.TypeOf(false),
+	StringType:    reflect.TypeOf(""),
+}
+
+// typeFromGo returns Gosh type for Go type t.
+// Defined Go types are represented by their underlying types.
+func typeFromGo(t reflect.Type) *TypeObject {
+	if k, ok := basicKinds[t.Kind()]; ok {
+		return predeclaredTypes[k.Name()]
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return &TypeObject{Kind: SliceType, Elem: typeFromGo(t.Elem())}
+	case reflect.Interface:
+		return &TypeObject{Kind: InterfaceType}
+	case reflect.Func:
+		res := &TypeObject{Kind: FunctionType}
+		for n := 0; n < t.NumIn(); n++ {
+			res.Params = append(res.Params, typeFromGo(t.In(n)))
+		}
+		for n := 0; n < t.NumOut(); n++ {
+			res.Results = append(res.Results, typeFromGo(t.Out(n)))
+		}
+		return res
+	default:
+		return &TypeObject{Name: t.String(), Kind: GoValueType}
+	}
+}
+
+// typeToGo returns Go type for Gosh type t.
+// Types without Go equivalent are mapped to interface{}.
+func typeToGo(t *TypeObject) reflect.Type {
+	if t == nil {
+		return interfaceType
+	}
+	if gt, ok := goKinds[t.Kind]; ok {
+		return gt
+	}
+	if t.Kind == SliceType {
+		return reflect.SliceOf(typeToGo(t.Elem))
+	}
+	return interfaceType
+}
+
+// FromGo returns Gosh object for Go value v:
+//  * values of basic types are converted to basic objects (defined Go types are represented by their underlying types);
+//  * slices and arrays are copied to Gosh slices;
+//  * functions (including method values) are wrapped into Go functions that convert arguments with ToGo
+//    and results with FromGo; multiple results and error results are returned as Tuple;
+//  * interface values are replaced by their dynamic values;
+//  * other values (maps, pointers, structs, channels) are wrapped into GoValue objects;
+//    programs index maps and select exported fields and methods of them.
+// Invalid value is converted to untyped nil.
+// Go values are usually made available to programs with gosh.Runtime.Set or gosh.WithGlobals
+// (for example, rt.Set("atoi", strconv.Atoi)); they call FromGo.
+func FromGo(v reflect.Value) Object {
+	if !v.IsValid() {
+		return UntypedNil
+	}
+
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewInteger(basicKinds[t.Kind()], uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewInteger(basicKinds[t.Kind()], v.Uint())
+	case reflect.Float64, reflect.Float32:
+		return NewFloat(basicKinds[t.Kind()], v.Float())
+	case reflect.Complex128, reflect.Complex64:
+		return NewComplex(basicKinds[t.Kind()], v.Complex())
+	case reflect.Bool:
+		return NewBoolean(v.Bool())
+	case reflect.String:
+		return &String{Value: v.String()}
+
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return &Nil{T: typeFromGo(t)}
+		}
+		values := make([]Object, v.Len())
+		for n := range values {
+			values[n] = FromGo(v.Index(n))
+		}
+		return &Slice{T: typeFromGo(t), Values: values}
+
+	case reflect.Func:
+		if v.IsNil() {
+			return &Nil{T: typeFromGo(t)}
+		}
+		return goFunction(v)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return UntypedNil
+		}
+		return FromGo(v.Elem())
+
+	default:
+		return &GoValue{Value: v}
+	}
+}
+
+// goFunction wraps Go function value f.
+// Multiple results and the only result of error type are returned as Tuple.
+func goFunction(f reflect.Value) *GoFunction {
+	t := f.Type()
+	name := t.String()
+
+	return &GoFunction{Func: func(ctx context.Context, args ...Object) Object {
+		in := t.NumIn()
+		if t.IsVariadic() {
+			if len(args) < in-1 {
+				panic(fmt.Errorf("%s: expected at least %d arguments, got %d", name, in-1, len(args)))
+			}
+		} else if len(args) != in {
+			panic(fmt.Errorf("%s: expected %d arguments, got %d", name, in, len(args)))
+		}
+
+		// Go copies of slice arguments are copied back after the call and around callbacks
+		var slices []*goSlice
+		if hasSlices(args) {
+			ctx = context.WithValue(ctx, slicesKey{}, &slices)
+		}
+
+		values := make([]reflect.Value, len(args))
+		for n, arg := range args {
+			var pt reflect.Type
+			if t.IsVariadic() && n >= in-1 {
+				pt = t.In(in - 1).Elem()
+			} else {
+				pt = t.In(n)
+			}
+			v, err := toGo(ctx, arg, pt)
+			if err != nil {
+				panic(fmt.Errorf("%s: argument %d: %s", name, n+1, err))
+			}
+			values[n] = v
+		}
+
+		// Go panics are reported as Go function failures; Go runtime errors are not interpreter bugs there
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if err, ok := p.(error); ok {
+				if _, ok = err.(runtime.Error); !ok {
+					panic(err)
+				}
+			}
+			panic(fmt.Errorf("%s: %v", name, p))
+		}()
+		out := f.Call(values)
+		for _, s := range slices {
+			s.fromGo()
+		}
+
+		switch {
+		case len(out) == 0:
+			return UntypedNil
+		case len(out) == 1 && t.Out(0) != errorType:
+			return FromGo(out[0])
+		}
+
+		// results of interface types (e.g. error) stay interface values, so they can be compared with nil
+		res := &Tuple{Values: make([]Object, len(out))}
+		for n, v := range out {
+			res.Values[n] = FromGo(v)
+			if v.Kind() == reflect.Interface {
+				i := &Interface{T: typeFromGo(v.Type())}
+				if !v.IsNil() {
+					i.Value = res.Values[n]
+				}
+				res.Values[n] = i
+			}
+		}
+		if last := out[len(out)-1]; t.Out(len(out)-1) == errorType {
+			res.HasError = true
+			if !last.IsNil() {
+				res.Err = last.Interface().(error)
+			}
+		}
+		return res
+	}}
+}
+
+// slicesKey is the context key for Go copies of Gosh slices passed to the current Go function call.
+type slicesKey struct{}
+
+// goSlice is a Go copy of Gosh slice passed to Go function.
+// Copies of slices with elements of basic types are copied back, so Go functions like sort.Ints
+// can change their arguments, and callbacks like sort.Slice's less function see the changes.
+type goSlice struct {
+	gosh *Slice
+	v    reflect.Value
+}
+
+// hasSlices returns true if some of args are slices.
+func hasSlices(args []Object) bool {
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case *Named:
+			if _, ok := arg.Value.(*Slice); ok {
+				return true
+			}
+		case *Interface:
+			if _, ok := arg.Value.(*Slice); ok {
+				return true
+			}
+		case *Slice:
+			return true
+		}
+	}
+	return false
+}
+
+// basic returns true if the Go copy has elements of basic type, so they can be copied back.
+func (s *goSlice) basic() bool {
+	_, ok := basicKinds[s.v.Type().Elem().Kind()]
+	return ok
+}
+
+// toGo copies elements of the Gosh slice to the Go copy.
+func (s *goSlice) toGo(ctx context.Context) error {
+	for n, e := range s.gosh.Values {
+		ev, err := toGo(ctx, e, s.v.Type().Elem())
+		if err != nil {
+			return err
+		}
+		s.v.Index(n).Set(ev)
+	}
+	return nil
+}
+
+// fromGo copies elements of the Go copy back to the Gosh slice, keeping their Gosh type.
+func (s *goSlice) fromGo() {
+	elem := s.gosh.T.UnderlyingType().Elem
+	k := elem.UnderlyingType().Kind
+	for n := range s.gosh.Values {
+		e := FromGo(s.v.Index(n))
+		if e.Type() != k && k.IsNumeric() {
+			e = Convert(e, k)
+		}
+		if elem.IsDefinedBasic() {
+			e = &Named{T: elem, Value: e}
+		}
+		s.gosh.Values[n] = e
+	}
+}
+
+// ToGo returns Go value of type t for Gosh object o, or error if it can't be converted:
+//  * numeric objects are converted to any Go numeric type that can represent their values exactly;
+//  * string and boolean objects are converted to Go types with the same kinds;
+//  * slices are copied to Go slices; Go functions' changes of slice arguments with elements of basic types
+//    are copied back;
+//  * nil is converted to nil value of Go slice, map, pointer, function, channel and interface types;
+//  * Go functions are converted to Go functions of type t;
+//  * GoValue objects are converted if their values are assignable to t.
+// For interface type t, the Go type is derived from the object's Gosh type.
+// Gosh functions are converted to Go functions only for arguments of Go functions called by Gosh program.
+func ToGo(o Object, t reflect.Type) (reflect.Value, error) {
+	return toGo(context.Background(), o, t)
+}
+
+// toGo implements ToGo. Converted functions call Gosh and Go functions with Call using ctx.
+//nolint:gocyclo
+func toGo(ctx context.Context, o Object, t reflect.Type) (reflect.Value, error) {
+	switch v := o.(type) {
+	case *Named:
+		return toGo(ctx, v.Value, t)
+	case *Interface:
+		if v.Value == nil {
+			return toGo(ctx, UntypedNil, t)
+		}
+		return toGo(ctx, v.Value, t)
+	}
+
+	cantUse := func() (reflect.Value, error) {
+		if n, ok := o.(*Nil); ok && n.T == nil {
+			return reflect.Value{}, fmt.Errorf("cannot use nil as Go type %s", t)
+		}
+		typ := o.Type().Name()
+		if ot := TypeOf(o); ot != nil {
+			typ = ot.String()
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use %s (type %s) as Go type %s", o, typ, t)
+	}
+
+	if t.Kind() == reflect.Interface {
+		var v reflect.Value
+		switch o := o.(type) {
+		case *Nil:
+			return reflect.Zero(t), nil
+		case *GoValue:
+			v = o.Value
+		case *GoFunction:
+			v = reflect.ValueOf(o.Func)
+		default:
+			gt := typeToGo(TypeOf(o))
+			if gt == interfaceType {
+				return cantUse()
+			}
+			var err error
+			if v, err = toGo(ctx, o, gt); err != nil {
+				return v, err
+			}
+		}
+		if !v.Type().Implements(t) {
+			return cantUse()
+		}
+		res := reflect.New(t).Elem()
+		res.Set(v)
+		return res, nil
+	}
+
+	switch v := o.(type) {
+	case *Nil:
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Ptr, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+			return reflect.Zero(t), nil
+		}
+
+	case *Boolean:
+		if t.Kind() == reflect.Bool {
+			return reflect.ValueOf(v.Value).Convert(t), nil
+		}
+
+	case *String:
+		if t.Kind() == reflect.String {
+			return reflect.ValueOf(v.Value).Convert(t), nil
+		}
+
+	case *Slice:
+		if t.Kind() != reflect.Slice {
+			break
+		}
+		res := reflect.MakeSlice(t, len(v.Values), len(v.Values))
+		s := &goSlice{gosh: v, v: res}
+		if err := s.toGo(ctx); err != nil {
+			return reflect.Value{}, err
+		}
+		if slices, _ := ctx.Value(slicesKey{}).(*[]*goSlice); slices != nil && s.basic() {
+			*slices = append(*slices, s)
+		}
+		return res, nil
+
+	case *GoFunction:
+		if t.Kind() != reflect.Func {
+			break
+		}
+		if reflect.TypeOf(v.Func).AssignableTo(t) {
+			return reflect.ValueOf(v.Func), nil
+		}
+		return goFunc(ctx, v, t), nil
+
+	case *Function:
+		if t.Kind() != reflect.Func || callerFrom(ctx) == nil {
+			break
+		}
+		return goFunc(ctx, v, t), nil
+
+	case *GoValue:
+		if v.Value.Type().AssignableTo(t) {
+			res := reflect.New(t).Elem()
+			res.Set(v.Value)
+			return res, nil
+		}
+
+	default:
+		k, ok := basicKinds[t.Kind()]
+		if !ok || !o.Type().IsNumeric() || !k.IsNumeric() || !Representable(o, k) {
+			break
+		}
+		return reflect.ValueOf(goValue(Convert(o, k))).Convert(t), nil
+	}
+
+	return cantUse()
+}
+
+// goValue returns Go value of basic object o.
+func goValue(o Object) interface{} {
+	switch o := o.(type) {
+	case *Integer:
+		return o.Value
+	case *Int8:
+		return o.Value
+	case *Int16:
+		return o.Value
+	case *Int32:
+		return o.Value
+	case *Int64:
+		return o.Value
+	case *Uint:
+		return o.Value
+	case *Uint8:
+		return o.Value
+	case *Uint16:
+		return o.Value
+	case *Uint32:
+		return o.Value
+	case *Uint64:
+		return o.Value
+	case *Uintptr:
+		return o.Value
+	case *Float:
+		return o.Value
+	case *Float32:
+		return o.Value
+	case *Complex:
+		return o.Value
+	case *Complex64:
+		return o.Value
+	default:
+		panic(fmt.Sprintf("goValue: unexpected type %T", o))
+	}
+}
+
+// goFunc returns Go function of type t that calls f with Call, converting arguments with FromGo and result with ToGo.
+// Failures are reported with panics, so Gosh panics propagate through Go code that called the function.
+func goFunc(ctx context.Context, f Object, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(in []reflect.Value) []reflect.Value {
+		args := make([]Object, len(in))
+		for n, v := range in {
+			args[n] = FromGo(v)
+		}
+		if t.IsVariadic() && len(in) > 0 {
+			// pass variadic arguments separately
+			last := args[len(args)-1]
+			args = args[:len(args)-1]
+			if s, ok := last.(*Slice); ok {
+				args = append(args, s.Values...)
+			}
+		}
+
+		// callbacks see and change Gosh slices passed to the Go function
+		slices, _ := ctx.Value(slicesKey{}).(*[]*goSlice)
+		if slices != nil {
+			for _, s := range *slices {
+				s.fromGo()
+			}
+		}
+		res, err := Call(ctx, f, args...)
+		if err != nil {
+			panic(err)
+		}
+		if slices != nil {
+			for _, s := range *slices {
+				if err = s.toGo(ctx); err != nil {
+					panic(fmt.Errorf("%s: %s", t, err))
+				}
+			}
+		}
+		out := make([]reflect.Value, t.NumOut())
+		for n := range out {
+			out[n] = reflect.Zero(t.Out(n))
+		}
+		if len(out) > 0 {
+			v, err := toGo(ctx, res, t.Out(0))
+			if err != nil {
+				panic(fmt.Errorf("%s: result: %s", t, err))
+			}
+			out[0] = v
+		}
+		return out
+	})
+}
+
+// check interfaces
+var (
+	_ Object = (*GoValue)(nil)
+	_ Object = (*Tuple)(nil)
+)
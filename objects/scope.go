@@ -7,31 +7,284 @@
 
 package objects
 
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// goroutines is the number of running goroutines of all programs, other than the main ones; see Scheduler.
+// While there are none, each scope is used by a single goroutine, so scopes are not locked.
+var goroutines int32
+
+// rlock locks the scope for reading if programs have other goroutines, and returns true if it did.
+func (e *Scope) rlock() bool {
+	if atomic.LoadInt32(&goroutines) == 0 {
+		return false
+	}
+	e.m.RLock()
+	return true
+}
+
+// lock locks the scope for writing if programs have other goroutines, and returns true if it did.
+func (e *Scope) lock() bool {
+	if atomic.LoadInt32(&goroutines) == 0 {
+		return false
+	}
+	e.m.Lock()
+	return true
+}
+
 // A Scope maintains the set of named language entities declared in the scope
 // and a link to the immediately surrounding (outer) scope.
+// Block scopes store entities bound by resolver in slots instead.
+// It is safe for concurrent use by multiple goroutines.
 type Scope struct {
 	outer *Scope
-	store map[string]Object
+	m     sync.RWMutex
+	names map[string]int // slots of named entities; nil for block scopes
+	slots []Object       // nil for entities that are not declared yet
+	small [4]Object      // storage for slots of small blocks
+	index []int          // slots of the outer scope for program scopes; nil for other scopes
 }
 
 // NewScope creates a new scope nested in the outer scope.
 func NewScope(outer *Scope) *Scope {
 	return &Scope{
 		outer: outer,
-		store: make(map[string]Object),
+		names: make(map[string]int),
 	}
 }
 
+// NewBlockScope creates a new block scope with the given number of slots nested in the outer scope.
+func NewBlockScope(outer *Scope, slots int) *Scope {
+	return new(Scope).Reset(outer, slots)
+}
+
+// Reset makes this unused block scope a new block scope with the given number of slots nested in the outer scope,
+// and returns it. It saves allocations for blocks that are entered repeatedly, such as loop bodies.
+func (e *Scope) Reset(outer *Scope, slots int) *Scope {
+	*e = Scope{
+		outer: outer,
+	}
+	if slots <= len(e.small) {
+		e.slots = e.small[:slots]
+	} else {
+		e.slots = make([]Object, slots)
+	}
+	return e
+}
+
+// NewProgramScope creates a block scope for top-level entities of a program nested in the outer scope.
+// The entity in slot n is stored in the outer scope as named names[n], so it is visible to other programs
+// using that scope; named entities are also declared in the outer scope.
+func NewProgramScope(outer *Scope, names []string) *Scope {
+	s := &Scope{
+		outer: outer,
+		index: make([]int, len(names)),
+	}
+
+	if outer.lock() {
+		defer outer.m.Unlock()
+	}
+	if outer.names == nil {
+		outer.names = make(map[string]int)
+	}
+	for n, name := range names {
+		slot, ok := outer.names[name]
+		if !ok {
+			slot = len(outer.slots)
+			outer.slots = append(outer.slots, nil)
+			outer.names[name] = slot
+		}
+		s.index[n] = slot
+	}
+	return s
+}
+
+// Outer returns the immediately surrounding scope, or nil.
+func (e *Scope) Outer() *Scope {
+	return e.outer
+}
+
+// named returns the scope storing named entities declared in this one.
+func (e *Scope) named() *Scope {
+	if e.index != nil {
+		return e.outer
+	}
+	return e
+}
+
+// lookupLocal returns a named entity declared in this scope. The caller should hold the lock.
+func (e *Scope) lookupLocal(name string) (Object, bool) {
+	slot, ok := e.names[name]
+	if !ok || e.slots[slot] == nil {
+		return nil, false
+	}
+	return e.slots[slot], true
+}
+
 // Lookup return a named entity with this or outer scope (recursively).
 func (e *Scope) Lookup(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Lookup(name)
+	for s := e; s != nil; s = s.outer {
+		if s.names == nil {
+			continue
+		}
+		locked := s.rlock()
+		obj, ok := s.lookupLocal(name)
+		if locked {
+			s.m.RUnlock()
+		}
+		if ok {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// LookupFrom returns a named entity within the scope depth levels up from this one or its outer scopes.
+func (e *Scope) LookupFrom(depth int, name string) (Object, bool) {
+	return e.block(depth).Lookup(name)
+}
+
+// LookupLocal returns a named entity declared in this scope, without looking in outer scopes.
+func (e *Scope) LookupLocal(name string) (Object, bool) {
+	s := e.named()
+	if s.rlock() {
+		defer s.m.RUnlock()
 	}
-	return obj, ok
+	return s.lookupLocal(name)
 }
 
-// Set adds or replaces a named entity in scope.
-func (e *Scope) Set(name string, obj Object) {
-	e.store[name] = obj
+// Define declares a named entity in this scope.
+// It returns false if the name is already declared in this scope.
+func (e *Scope) Define(name string, obj Object) bool {
+	s := e.named()
+	if s.lock() {
+		defer s.m.Unlock()
+	}
+	slot, ok := s.names[name]
+	switch {
+	case !ok:
+		if s.names == nil {
+			s.names = make(map[string]int)
+		}
+		s.names[name] = len(s.slots)
+		s.slots = append(s.slots, obj)
+	case s.slots[slot] != nil:
+		return false
+	default:
+		s.slots[slot] = obj
+	}
+	return true
+}
+
+// Assign replaces a named entity in the scope where it is declared: this or outer scope (recursively).
+// It returns false if the name is not declared.
+func (e *Scope) Assign(name string, obj Object) bool {
+	for s := e; s != nil; s = s.outer {
+		if s.names == nil {
+			continue
+		}
+		locked := s.lock()
+		slot, ok := s.names[name]
+		ok = ok && s.slots[slot] != nil
+		if ok {
+			s.slots[slot] = obj
+		}
+		if locked {
+			s.m.Unlock()
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignFrom replaces a named entity in the scope where it is declared:
+// the scope depth levels up from this one or its outer scopes.
+// It returns false if the name is not declared.
+func (e *Scope) AssignFrom(depth int, name string, obj Object) bool {
+	return e.block(depth).Assign(name, obj)
+}
+
+// block returns the block scope depth levels up from this one.
+func (e *Scope) block(depth int) *Scope {
+	s := e
+	for ; depth > 0; depth-- {
+		s = s.outer
+	}
+	return s
+}
+
+// slot returns the scope storing the slot of block scope depth levels up from this one, and its slot there.
+func (e *Scope) slot(depth, slot int) (*Scope, int) {
+	s := e.block(depth)
+	if s.index != nil {
+		return s.outer, s.index[slot]
+	}
+	return s, slot
+}
+
+// Get returns the entity in the slot of block scope depth levels up from this one,
+// or nil if it is not declared yet.
+func (e *Scope) Get(depth, slot int) Object {
+	s, slot := e.slot(depth, slot)
+	if s.rlock() {
+		obj := s.slots[slot]
+		s.m.RUnlock()
+		return obj
+	}
+	return s.slots[slot]
+}
+
+// DefineSlot declares an entity in the slot of this block scope.
+// It returns false if the slot is already occupied.
+func (e *Scope) DefineSlot(slot int, obj Object) bool {
+	s, slot := e.slot(0, slot)
+	locked := s.lock()
+	ok := s.slots[slot] == nil
+	if ok {
+		s.slots[slot] = obj
+	}
+	if locked {
+		s.m.Unlock()
+	}
+	return ok
+}
+
+// SetSlot replaces the entity in the slot of block scope depth levels up from this one.
+// It returns false if the slot is not occupied yet.
+func (e *Scope) SetSlot(depth, slot int, obj Object) bool {
+	s, slot := e.slot(depth, slot)
+	locked := s.lock()
+	ok := s.slots[slot] != nil
+	if ok {
+		s.slots[slot] = obj
+	}
+	if locked {
+		s.m.Unlock()
+	}
+	return ok
+}
+
+// Copy returns a new scope with the same outer scope and copies of entities declared in this scope.
+// Copies of program scopes share entities with them.
+func (e *Scope) Copy() *Scope {
+	if e.index != nil {
+		return &Scope{outer: e.outer, index: e.index}
+	}
+
+	s := NewBlockScope(e.outer, len(e.slots))
+	if e.rlock() {
+		defer e.m.RUnlock()
+	}
+	copy(s.slots, e.slots)
+	if e.names != nil {
+		s.names = make(map[string]int, len(e.names))
+		for name, slot := range e.names {
+			s.names[name] = slot
+		}
+	}
+	return s
 }
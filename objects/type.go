@@ -15,10 +15,118 @@ type Type int
 // The list of object types.
 const (
 	IntegerType Type = iota
+	Int8Type
+	Int16Type
+	Int32Type
+	Int64Type
+	UintType
+	Uint8Type
+	Uint16Type
+	Uint32Type
+	Uint64Type
+	UintptrType
 	FloatType
+	Float32Type
+	ComplexType
+	Complex64Type
 	BooleanType
 	StringType
 	FunctionType
 	GoFunctionType
 	ContinueType
+	ReturnType
+	TypeObjectType
+	NilType
+	SliceType
+	MapType
+	PointerType
+	ChannelType
+	InterfaceType
+	NamedType
+	GoValueType
+	ModuleType
+	TupleType
 )
+
+// IsInteger returns true for signed and unsigned integer types.
+func (t Type) IsInteger() bool {
+	return t.IsSigned() || t.IsUnsigned()
+}
+
+// IsSigned returns true for signed integer types.
+func (t Type) IsSigned() bool {
+	switch t {
+	case IntegerType, Int8Type, Int16Type, Int32Type, Int64Type:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsUnsigned returns true for unsigned integer types.
+func (t Type) IsUnsigned() bool {
+	switch t {
+	case UintType, Uint8Type, Uint16Type, Uint32Type, Uint64Type, UintptrType:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFloat returns true for floating-point types.
+func (t Type) IsFloat() bool {
+	return t == FloatType || t == Float32Type
+}
+
+// IsComplex returns true for complex types.
+func (t Type) IsComplex() bool {
+	return t == ComplexType || t == Complex64Type
+}
+
+// IsNumeric returns true for integer, floating-point and complex types.
+func (t Type) IsNumeric() bool {
+	return t.IsInteger() || t.IsFloat() || t.IsComplex()
+}
+
+// IsBasic returns true for numeric, boolean and string types.
+func (t Type) IsBasic() bool {
+	return t.IsNumeric() || t == BooleanType || t == StringType
+}
+
+// IsNillable returns true for types which values can be nil.
+func (t Type) IsNillable() bool {
+	switch t {
+	case SliceType, MapType, PointerType, FunctionType, ChannelType, InterfaceType:
+		return true
+	default:
+		return false
+	}
+}
+
+// Name returns the type name as it is written in Gosh source code.
+func (t Type) Name() string {
+	if n, ok := typeNames[t]; ok {
+		return n
+	}
+	return t.String()
+}
+
+var typeNames = map[Type]string{
+	IntegerType:   "int",
+	Int8Type:      "int8",
+	Int16Type:     "int16",
+	Int32Type:     "int32",
+	Int64Type:     "int64",
+	UintType:      "uint",
+	Uint8Type:     "uint8",
+	Uint16Type:    "uint16",
+	Uint32Type:    "uint32",
+	Uint64Type:    "uint64",
+	UintptrType:   "uintptr",
+	FloatType:     "float64",
+	Float32Type:   "float32",
+	ComplexType:   "complex128",
+	Complex64Type: "complex64",
+	BooleanType:   "bool",
+	StringType:    "string",
+}
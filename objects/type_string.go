@@ -4,9 +4,9 @@ package objects
 
 import "strconv"
 
-const _Type_name = "IntegerTypeFloatTypeBooleanTypeStringTypeFunctionTypeGoFunctionTypeContinueType"
+const _Type_name = "IntegerTypeInt8TypeInt16TypeInt32TypeInt64TypeUintTypeUint8TypeUint16TypeUint32TypeUint64TypeUintptrTypeFloatTypeFloat32TypeComplexTypeComplex64TypeBooleanTypeStringTypeFunctionTypeGoFunctionTypeContinueTypeReturnTypeTypeObjectTypeNilTypeSliceTypeMapTypePointerTypeChannelTypeInterfaceTypeNamedTypeGoValueTypeModuleTypeTupleType"
 
-var _Type_index = [...]uint8{0, 11, 20, 31, 41, 53, 67, 79}
+var _Type_index = [...]uint16{0, 11, 19, 28, 37, 46, 54, 63, 73, 83, 93, 104, 113, 124, 135, 148, 159, 169, 181, 195, 207, 217, 231, 238, 247, 254, 265, 276, 289, 298, 309, 319, 328}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
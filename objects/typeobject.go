@@ -0,0 +1,191 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package objects
+
+import (
+	"fmt"
+	"strings"
+
+	"gosh-lang.org/gosh/ast"
+)
+
+// TypeObject represents a type as a runtime object (e.g. `int8` in `int8(x)` or `[]int` in `var s []int`).
+type TypeObject struct {
+	Name       string        // name of predeclared or defined type; empty for composite types
+	Kind       Type          // object type of values of this type
+	Dir        ast.ChanDir   // direction of channel types
+	Elem       *TypeObject   // element type of slice, map, pointer and channel types
+	Key        *TypeObject   // key type of map types
+	Params     []*TypeObject // parameter types of function types
+	Results    []*TypeObject // result types of function types
+	Underlying *TypeObject   // underlying type of defined types; nil for predeclared and composite types
+}
+
+// NewDefinedType returns a new defined type with the given name and underlying type t.
+func NewDefinedType(name string, t *TypeObject) *TypeObject {
+	u := t.UnderlyingType()
+	return &TypeObject{
+		Name:       name,
+		Kind:       u.Kind,
+		Dir:        u.Dir,
+		Elem:       u.Elem,
+		Key:        u.Key,
+		Params:     u.Params,
+		Results:    u.Results,
+		Underlying: u,
+	}
+}
+
+// UnderlyingType returns the underlying type of this type.
+func (t *TypeObject) UnderlyingType() *TypeObject {
+	if t.Underlying != nil {
+		return t.Underlying
+	}
+	return t
+}
+
+// IsDefinedBasic returns true for defined types with basic underlying type.
+// Values of such types are represented by Named objects.
+func (t *TypeObject) IsDefinedBasic() bool {
+	return t.Underlying != nil && t.Kind.IsBasic()
+}
+
+// Type returns TypeObjectType.
+func (t *TypeObject) Type() Type { return TypeObjectType }
+
+func (t *TypeObject) String() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	switch t.Kind {
+	case SliceType:
+		return "[]" + t.Elem.String()
+	case MapType:
+		return "map[" + t.Key.String() + "]" + t.Elem.String()
+	case PointerType:
+		return "*" + t.Elem.String()
+	case ChannelType:
+		switch t.Dir {
+		case ast.SendOnly:
+			return "chan<- " + t.Elem.String()
+		case ast.RecvOnly:
+			return "<-chan " + t.Elem.String()
+		default:
+			return "chan " + t.Elem.String()
+		}
+	case InterfaceType:
+		return "interface {}"
+	case FunctionType:
+		params := make([]string, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = p.String()
+		}
+		results := make([]string, len(t.Results))
+		for i, r := range t.Results {
+			results[i] = r.String()
+		}
+		res := "func(" + strings.Join(params, ", ") + ")"
+		switch len(results) {
+		case 0:
+			return res
+		case 1:
+			return res + " " + results[0]
+		default:
+			return res + " (" + strings.Join(results, ", ") + ")"
+		}
+	default:
+		return t.Kind.Name()
+	}
+}
+
+// Identical returns true if both types are identical.
+func (t *TypeObject) Identical(other *TypeObject) bool {
+	if t == other {
+		return true
+	}
+	if t == nil || other == nil || t.Name != "" || other.Name != "" {
+		return false
+	}
+	return t.String() == other.String()
+}
+
+// Zero returns a zero value of this type.
+func (t *TypeObject) Zero() Object {
+	switch {
+	case t.IsDefinedBasic():
+		return &Named{T: t, Value: t.Underlying.Zero()}
+	case t.Kind.IsInteger():
+		return NewInteger(t.Kind, 0)
+	case t.Kind.IsFloat():
+		return NewFloat(t.Kind, 0)
+	case t.Kind.IsComplex():
+		return NewComplex(t.Kind, 0)
+	case t.Kind == BooleanType:
+		return False
+	case t.Kind == StringType:
+		return &String{}
+	case t.Kind == InterfaceType:
+		return &Interface{T: t}
+	case t.Kind.IsNillable():
+		return &Nil{T: t}
+	default:
+		panic(fmt.Sprintf("Zero: unexpected type %s", t.Kind))
+	}
+}
+
+// predeclared types; byte and rune are aliases for uint8 and int32
+var predeclaredTypes = map[string]*TypeObject{}
+
+// basicTypes maps kinds of basic objects to predeclared types, so TypeOf does not look them up by name.
+var basicTypes []*TypeObject
+
+func init() {
+	for _, t := range []Type{
+		IntegerType, Int8Type, Int16Type, Int32Type, Int64Type,
+		UintType, Uint8Type, Uint16Type, Uint32Type, Uint64Type, UintptrType,
+		FloatType, Float32Type, ComplexType, Complex64Type,
+		BooleanType, StringType,
+	} {
+		predeclaredTypes[t.Name()] = &TypeObject{Name: t.Name(), Kind: t}
+		for int(t) >= len(basicTypes) {
+			basicTypes = append(basicTypes, nil)
+		}
+		basicTypes[t] = predeclaredTypes[t.Name()]
+	}
+	predeclaredTypes["byte"] = predeclaredTypes["uint8"]
+	predeclaredTypes["rune"] = predeclaredTypes["int32"]
+}
+
+// TypeOf returns the type of the given object, or nil if it is not known.
+func TypeOf(o Object) *TypeObject {
+	switch o := o.(type) {
+	case *Nil:
+		return o.T
+	case *Interface:
+		return o.T
+	case *Slice:
+		return o.T
+	case *Channel:
+		return o.T
+	case *Named:
+		return o.T
+	case *GoValue:
+		return typeFromGo(o.Value.Type())
+	default:
+		if t := o.Type(); t >= 0 && int(t) < len(basicTypes) {
+			return basicTypes[t]
+		}
+		return nil
+	}
+}
+
+// check interfaces
+var (
+	_ Object = (*TypeObject)(nil)
+)
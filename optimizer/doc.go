@@ -0,0 +1,12 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package optimizer implements optional optimization pass over Gosh programs.
+//
+// Optimized program produces the same results and output as the source program, with one exception:
+// runtime errors in inlined functions are reported at the call site.
+package optimizer // import "gosh-lang.org/gosh/optimizer"
@@ -0,0 +1,184 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package optimizer
+
+import (
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// declare registers top-level variable id with initial value exp as a function that can be inlined, if it is.
+//
+// Such function is never assigned, has no parameter types and named results, and its body is a single return statement
+// with non-constant expression of its parameters, constants and operators. It can't call anything,
+// so it is not recursive, and evaluating the expression in place of the call gives the same result.
+func (o *optimizer) declare(id *ast.Identifier, exp ast.Expression) {
+	lit, ok := exp.(*ast.FunctionLiteral)
+	if !ok || o.assigned[id.Value] || lit.Types != nil || len(lit.Results) != 0 || len(lit.Body.Statements) != 1 {
+		return
+	}
+	ret, ok := lit.Body.Statements[0].(*ast.ReturnStatement)
+	if !ok || ret.Value == nil || ops.IsConstant(ret.Value) || !inlinable(ret.Value, len(lit.Parameters)) {
+		return
+	}
+	o.functions[id.Value] = lit
+}
+
+// inlinable returns true if expression consists only of parameters of the enclosing function,
+// constants, and unary and binary operators.
+func inlinable(exp ast.Expression, params int) bool {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		// parameters are the first slots of the function's block
+		return exp.Local && exp.Depth == 0 && exp.Slot < params
+	case *ast.PrefixExpression:
+		return exp.Token.Type != tokens.Arrow && inlinable(exp.Right, params)
+	case *ast.InfixExpression:
+		return inlinable(exp.Left, params) && inlinable(exp.Right, params)
+	default:
+		return ops.IsConstant(exp)
+	}
+}
+
+// inline returns the expression of function called by call expression node with substituted arguments,
+// or nil if the call can't be inlined.
+func (o *optimizer) inline(node *ast.CallExpression) ast.Expression {
+	// the function should not be shadowed by a local entity
+	id, ok := node.Function.(*ast.Identifier)
+	if !ok || id.Local {
+		return nil
+	}
+	lit := o.functions[id.Value]
+	if lit == nil || len(node.Arguments) != len(lit.Parameters) {
+		return nil
+	}
+
+	// arguments are evaluated when they are used, so they should not have side effects;
+	// constant arguments are not allowed as they would be converted to the type of other operand
+	for _, a := range node.Arguments {
+		if _, ok := a.(*ast.Identifier); !ok {
+			return nil
+		}
+	}
+
+	return substitute(lit.Body.Statements[0].(*ast.ReturnStatement).Value, node.Arguments)
+}
+
+// substitute returns a copy of inlinable expression with parameters replaced by arguments.
+func substitute(exp ast.Expression, args []ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return args[exp.Slot]
+	case *ast.PrefixExpression:
+		res := *exp
+		res.Right = substitute(exp.Right, args)
+		return &res
+	case *ast.InfixExpression:
+		res := *exp
+		res.Left = substitute(exp.Left, args)
+		res.Right = substitute(exp.Right, args)
+		return &res
+	default:
+		return exp
+	}
+}
+
+// escapes returns true if node uses variables declared outside of it.
+// Depth is the number of block scopes between node and the outside.
+//nolint:gocyclo
+func escapes(node ast.Node, depth int) bool {
+	// blocks without slots have no scopes
+	inner := func(slots int) int {
+		if slots > 0 {
+			return depth + 1
+		}
+		return depth
+	}
+
+	var res bool
+	ast.Inspect(node, func(n ast.Node) bool {
+		if res {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.Identifier:
+			res = n.Local && n.Depth >= depth
+
+		case *ast.BlockStatement:
+			d := inner(n.Slots)
+			for _, s := range n.Statements {
+				res = res || escapes(s, d)
+			}
+			return false
+
+		case *ast.FunctionLiteral:
+			// types of named results are in the outer block
+			d := inner(n.Body.Slots)
+			for _, r := range n.Results {
+				res = res || r.Type != nil && escapes(r.Type, depth)
+			}
+			for _, s := range n.Body.Statements {
+				res = res || escapes(s, d)
+			}
+			return false
+
+		case *ast.ForStatement:
+			d := inner(n.Slots)
+			for _, c := range []ast.Node{n.Init, n.Cond, n.Post} {
+				res = res || !isNil(c) && escapes(c, d)
+			}
+			res = res || escapes(n.Body, d)
+			return false
+
+		case *ast.RangeStatement:
+			d := depth
+			if n.Define {
+				d = inner(n.Slots)
+			}
+			for _, id := range []*ast.Identifier{n.Key, n.Value} {
+				res = res || id != nil && escapes(id, d)
+			}
+			res = res || escapes(n.X, depth) || escapes(n.Body, d)
+			return false
+
+		case *ast.SelectStatement:
+			// channels and values are in the outer block, assigned variables are in the clause
+			for _, c := range n.Cases {
+				d := inner(c.Slots)
+				if a, ok := c.Comm.(*ast.AssignStatement); ok {
+					res = res || escapes(a.Value, depth) || escapes(a.Name, d)
+					for _, id := range a.Rest {
+						res = res || escapes(id, d)
+					}
+				} else if c.Comm != nil {
+					res = res || escapes(c.Comm, depth)
+				}
+				for _, s := range c.Body {
+					res = res || escapes(s, d)
+				}
+			}
+			return false
+		}
+		return !res
+	})
+	return res
+}
+
+// isNil returns true if node is nil or a typed nil pointer stored in interface.
+func isNil(node ast.Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *ast.AssignStatement:
+		return node == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,352 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package optimizer
+
+import (
+	"go/constant"
+	"runtime"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// optimizer optimizes a program.
+type optimizer struct {
+	functions map[string]*ast.FunctionLiteral // top-level functions that can be inlined
+	assigned  map[string]bool                 // top-level variables that are assigned after declaration
+}
+
+// Optimize returns optimized program that should be evaluated in the given scope.
+// It folds constant expressions, removes if statements with constant false condition,
+// simplifies logical operations with constant operands, and inlines small top-level functions.
+//
+// Program is not modified; nodes that are not changed are shared by both programs.
+// If program has errors that are reported before execution, it is returned as is,
+// so they are reported exactly as without optimization.
+func Optimize(program *ast.Program, scope *objects.Scope) *ast.Program {
+	if _, msg := ops.Check(program, scope); msg != "" {
+		return program
+	}
+
+	o := &optimizer{
+		functions: make(map[string]*ast.FunctionLiteral),
+		assigned:  assignedGlobals(program),
+	}
+
+	res := &ast.Program{
+		File:       program.File,
+		Package:    program.Package,
+		Statements: make([]ast.Statement, len(program.Statements)),
+		Globals:    program.Globals,
+		Lines:      program.Lines,
+	}
+	for n, s := range program.Statements {
+		res.Statements[n] = o.statement(s)
+
+		// calls in the following statements can inline function declared by this one
+		switch s := res.Statements[n].(type) {
+		case *ast.VarStatement:
+			o.declare(s.Name, s.Value)
+		case *ast.AssignStatement:
+			if s.Token.Type == tokens.Define && s.Rest == nil {
+				o.declare(s.Name.(*ast.Identifier), s.Value)
+			}
+		}
+	}
+	return res
+}
+
+// assignedGlobals returns names of top-level variables that are assigned anywhere in program.
+func assignedGlobals(program *ast.Program) map[string]bool {
+	res := make(map[string]bool)
+	assign := func(id *ast.Identifier) {
+		if id != nil && !id.Local {
+			res[id.Value] = true
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStatement:
+			// multi-value := can assign to declared variables
+			if node.Token.Type != tokens.Define || node.Rest != nil {
+				if id, ok := node.Name.(*ast.Identifier); ok {
+					assign(id)
+				}
+				for _, id := range node.Rest {
+					assign(id)
+				}
+			}
+		case *ast.RangeStatement:
+			if !node.Define {
+				assign(node.Key)
+				assign(node.Value)
+			}
+		case *ast.IncrementDecrementStatement:
+			if id, ok := node.X.(*ast.Identifier); ok {
+				assign(id)
+			}
+		}
+		return true
+	})
+	return res
+}
+
+func (o *optimizer) statements(statements []ast.Statement) []ast.Statement {
+	res := make([]ast.Statement, len(statements))
+	for n, s := range statements {
+		res[n] = o.statement(s)
+	}
+	return res
+}
+
+func (o *optimizer) block(node *ast.BlockStatement) *ast.BlockStatement {
+	res := *node
+	res.Statements = o.statements(node.Statements)
+	return &res
+}
+
+//nolint:gocyclo
+func (o *optimizer) statement(node ast.Statement) ast.Statement {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		return o.block(node)
+
+	case *ast.ExpressionStatement:
+		res := *node
+		if node.Expression != nil {
+			res.Expression = o.expression(node.Expression)
+		}
+		return &res
+
+	case *ast.ReturnStatement:
+		res := *node
+		if node.Value != nil {
+			res.Value = o.expression(node.Value)
+		}
+		return &res
+
+	case *ast.DeferStatement:
+		res := *node
+		res.Call = o.arguments(node.Call)
+		return &res
+
+	case *ast.GoStatement:
+		res := *node
+		res.Call = o.arguments(node.Call)
+		return &res
+
+	case *ast.SendStatement:
+		res := *node
+		res.Channel = o.expression(node.Channel)
+		res.Value = o.expression(node.Value)
+		return &res
+
+	case *ast.SelectStatement:
+		res := *node
+		res.Cases = make([]*ast.CommClause, len(node.Cases))
+		for n, c := range node.Cases {
+			cc := *c
+			if c.Comm != nil {
+				cc.Comm = o.statement(c.Comm)
+			}
+			cc.Body = o.statements(c.Body)
+			res.Cases[n] = &cc
+		}
+		return &res
+
+	case *ast.VarStatement:
+		res := *node
+		if node.Value != nil {
+			res.Value = o.expression(node.Value)
+		}
+		return &res
+
+	case *ast.AssignStatement:
+		res := *node
+		if _, ok := node.Name.(*ast.Identifier); !ok {
+			res.Name = o.expression(node.Name)
+		}
+		res.Value = o.expression(node.Value)
+		return &res
+
+	case *ast.ForStatement:
+		res := *node
+		if node.Init != nil {
+			res.Init = o.statement(node.Init).(*ast.AssignStatement)
+		}
+		if node.Cond != nil {
+			res.Cond = o.expression(node.Cond)
+		}
+		if node.Post != nil {
+			res.Post = o.statement(node.Post)
+		}
+		res.Body = o.block(node.Body)
+		return &res
+
+	case *ast.RangeStatement:
+		res := *node
+		res.X = o.expression(node.X)
+		res.Body = o.block(node.Body)
+		return &res
+
+	case *ast.IfStatement:
+		res := *node
+		res.Cond = o.expression(node.Cond)
+		if c, ok := res.Cond.(*ast.Constant); ok && c.Value.Kind() == constant.Bool && !constant.BoolVal(c.Value) {
+			// removed body should not contain the only use of a variable, or it would be reported as unused
+			if !escapes(node.Body, 0) {
+				return &ast.ExpressionStatement{Token: node.Token}
+			}
+		}
+		res.Body = o.block(node.Body)
+		return &res
+
+	default:
+		// import, type, increment/decrement and continue statements
+		return node
+	}
+}
+
+// fold returns constant node with the value of constant expression,
+// or nil if it can't be evaluated; that error is reported by the interpreter.
+func fold(exp ast.Expression) (res *ast.Constant) {
+	defer func() {
+		if p := recover(); p != nil {
+			if _, ok := p.(runtime.Error); ok {
+				panic(p)
+			}
+			if _, ok := p.(error); !ok {
+				panic(p)
+			}
+			res = nil
+		}
+	}()
+
+	return ops.MakeConstant(exp)
+}
+
+// comparison returns true if expression is a comparison; its result is always an untyped boolean.
+func comparison(exp ast.Expression) bool {
+	infix, ok := exp.(*ast.InfixExpression)
+	if !ok {
+		return false
+	}
+	switch infix.Token.Type {
+	case tokens.Equal, tokens.NotEqual, tokens.Less, tokens.LessOrEqual, tokens.Greater, tokens.GreaterOrEqual:
+		return true
+	default:
+		return false
+	}
+}
+
+//nolint:gocyclo
+func (o *optimizer) expression(node ast.Expression) ast.Expression {
+	switch node := node.(type) {
+	case *ast.StringLiteral, *ast.Constant:
+		// nothing to evaluate
+		return node
+	}
+
+	// boolean literals are folded too, so conditions are checked in one place
+	if ops.IsConstant(node) {
+		if c := fold(node); c != nil {
+			return c
+		}
+		return node
+	}
+
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		res := *node
+		res.Right = o.expression(node.Right)
+		return &res
+
+	case *ast.InfixExpression:
+		res := *node
+		res.Left = o.expression(node.Left)
+		res.Right = o.expression(node.Right)
+		if node.Token.Type == tokens.LogicalAnd || node.Token.Type == tokens.LogicalOr {
+			if simplified := logical(node, res.Left, res.Right); simplified != nil {
+				return simplified
+			}
+		}
+		return &res
+
+	case *ast.IndexExpression:
+		res := *node
+		res.Left = o.expression(node.Left)
+		res.Index = o.expression(node.Index)
+		return &res
+
+	case *ast.SliceExpression:
+		res := *node
+		res.Left = o.expression(node.Left)
+		if node.Low != nil {
+			res.Low = o.expression(node.Low)
+		}
+		if node.High != nil {
+			res.High = o.expression(node.High)
+		}
+		return &res
+
+	case *ast.SelectorExpression:
+		res := *node
+		res.X = o.expression(node.X)
+		return &res
+
+	case *ast.CallExpression:
+		res := o.arguments(node)
+		if inlined := o.inline(res); inlined != nil {
+			return inlined
+		}
+		return res
+
+	case *ast.FunctionLiteral:
+		res := *node
+		res.Body = o.block(node.Body)
+		return &res
+
+	default:
+		// identifiers and types
+		return node
+	}
+}
+
+// logical simplifies logical operation node with optimized operands,
+// or returns nil if it can't be simplified.
+func logical(node *ast.InfixExpression, left, right ast.Expression) ast.Expression {
+	c, ok := left.(*ast.Constant)
+	if !ok || c.Value.Kind() != constant.Bool {
+		return nil
+	}
+
+	// the right operand is not evaluated; its variables are still used
+	if constant.BoolVal(c.Value) == (node.Token.Type == tokens.LogicalOr) {
+		return &ast.Constant{X: node, Value: c.Value}
+	}
+
+	// the result is the right operand if it is an untyped boolean
+	if comparison(right) {
+		return right
+	}
+	return nil
+}
+
+// arguments returns call expression node with optimized function and arguments.
+func (o *optimizer) arguments(node *ast.CallExpression) *ast.CallExpression {
+	res := *node
+	res.Function = o.expression(node.Function)
+	res.Arguments = make([]ast.Expression, len(node.Arguments))
+	for n, a := range node.Arguments {
+		res.Arguments[n] = o.expression(a)
+	}
+	return &res
+}
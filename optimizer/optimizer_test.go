@@ -0,0 +1,144 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package optimizer
+
+import (
+	"go/constant"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+)
+
+func optimize(t *testing.T, input string) (*ast.Program, *ast.Program) {
+	t.Helper()
+
+	s, err := scanner.New(input, nil)
+	require.NoError(t, err)
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	require.Nil(t, p.Errors())
+	original := program.String()
+
+	res := Optimize(program, objects.NewScope(objects.Builtin(ioutil.Discard)))
+	assert.Equal(t, original, program.String(), "program should not be modified")
+	return program, res
+}
+
+// value returns initial value of the n-th top-level variable declaration.
+func value(program *ast.Program, n int) ast.Expression {
+	return program.Statements[n].(*ast.VarStatement).Value
+}
+
+func TestFold(t *testing.T) {
+	_, res := optimize(t, `var x = 1 << 100 >> 98; var r = 'a' + 1; var f = 1.0 / 3; var s = "a" + "b"; println(x, r, f, s)`)
+
+	x := value(res, 0).(*ast.Constant)
+	assert.Equal(t, constant.MakeInt64(4), x.Value)
+	assert.False(t, x.Rune)
+	assert.Equal(t, "1 << 100 >> 98", x.String(), "original expression is kept for messages")
+
+	r := value(res, 1).(*ast.Constant)
+	assert.Equal(t, constant.MakeInt64('b'), r.Value)
+	assert.True(t, r.Rune)
+
+	f := value(res, 2).(*ast.Constant)
+	assert.Equal(t, constant.Float, f.Value.Kind())
+	assert.Equal(t, "0.333333", f.Value.String())
+
+	assert.Equal(t, "ab", constant.StringVal(value(res, 3).(*ast.Constant).Value))
+}
+
+func TestDeadIf(t *testing.T) {
+	program, res := optimize(t, `var x = 1; if (1 > 2) { println(x) }; var f = func(a) { if (false) { println(a) }; if (false) { var b = 1; println(b) } }`)
+
+	// top-level variables are looked up by name, so they are not used in the only place
+	assert.Equal(t, &ast.ExpressionStatement{Token: program.Statements[1].(*ast.IfStatement).Token}, res.Statements[1])
+
+	body := value(res, 2).(*ast.FunctionLiteral).Body
+	assert.IsType(t, (*ast.IfStatement)(nil), body.Statements[0], "a is used only there")
+	assert.IsType(t, (*ast.ExpressionStatement)(nil), body.Statements[1])
+}
+
+func TestLogical(t *testing.T) {
+	_, res := optimize(t, `var y = 1; var ok = true; var a = false && y > 0; var b = true && y > 0; var c = true && ok; var d = 1 < 2 || ok`)
+
+	a := value(res, 2).(*ast.Constant)
+	assert.Equal(t, constant.MakeBool(false), a.Value)
+	assert.Equal(t, "false && y > 0", a.String())
+
+	assert.Equal(t, "y > 0", value(res, 3).String())
+	assert.IsType(t, (*ast.InfixExpression)(nil), value(res, 3))
+
+	// result of && has the type of ok, so it is not simplified
+	c := value(res, 4).(*ast.InfixExpression)
+	assert.Equal(t, "true && ok", c.String())
+
+	assert.Equal(t, constant.MakeBool(true), value(res, 5).(*ast.Constant).Value)
+}
+
+func TestInline(t *testing.T) {
+	program, res := optimize(t, `
+var add = func(a, b) { return a + b * 2 }
+var sub = func(a, b) { return a - b }
+var x = 1
+var y = 2
+var z = add(y, x)
+var w = add(x, 1)
+var v = sub(x, y)
+sub = func(a, b) { return b - a }
+var f = func(x) { return add(x, x) }
+println(z, w, v, f(y))
+`)
+
+	z := value(res, 4).(*ast.InfixExpression)
+	assert.Equal(t, "y + x * 2", z.String())
+	call := value(program, 4).(*ast.CallExpression)
+	assert.True(t, call.Arguments[0] == z.Left)
+	assert.True(t, call.Arguments[1] == z.Right.(*ast.InfixExpression).Left)
+
+	assert.IsType(t, (*ast.CallExpression)(nil), value(res, 5), "constant arguments are not inlined")
+	assert.IsType(t, (*ast.CallExpression)(nil), value(res, 6), "assigned functions are not inlined")
+
+	// local identifiers are substituted as is
+	ret := value(res, 8).(*ast.FunctionLiteral).Body.Statements[0].(*ast.ReturnStatement)
+	assert.Equal(t, "x + x * 2", ret.Value.String())
+	assert.True(t, ret.Value.(*ast.InfixExpression).Left.(*ast.Identifier).Local)
+}
+
+func TestErrors(t *testing.T) {
+	program, res := optimize(t, `var x = 1; if (false) { y }`)
+	assert.True(t, program == res, "program with errors is not optimized")
+}
+
+func TestEscapes(t *testing.T) {
+	_, res := optimize(t, `
+var f = func(a) {
+	for i := 0; i < 1; i++ {
+		if (false) { for j := 0; j < 1; j++ { println(j) } }
+		if (false) { for j := 0; j < 1; j++ { println(i) } }
+		if (false) { for k := range "ab" { println(k, a) } }
+		if (false) { g := func(b) { return b }; println(g(1)) }
+		if (false) { g := func(b) { return i }; println(g(1)) }
+	}
+}
+`)
+
+	body := value(res, 0).(*ast.FunctionLiteral).Body.Statements[0].(*ast.ForStatement).Body
+	assert.IsType(t, (*ast.ExpressionStatement)(nil), body.Statements[0])
+	assert.IsType(t, (*ast.IfStatement)(nil), body.Statements[1])
+	assert.IsType(t, (*ast.IfStatement)(nil), body.Statements[2])
+	assert.IsType(t, (*ast.ExpressionStatement)(nil), body.Statements[3])
+	assert.IsType(t, (*ast.IfStatement)(nil), body.Statements[4])
+}
@@ -8,7 +8,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"go/constant"
+	"go/token"
 	"strconv"
 	"strings"
 
@@ -55,17 +58,30 @@ func New(s *scanner.Scanner, config *Config) *Parser {
 
 		tokens.Integer:    p.parseIntegerLiteral,
 		tokens.Float:      p.parseFloatLiteral,
+		tokens.Imaginary:  p.parseImaginaryLiteral,
+		tokens.Rune:       p.parseRuneLiteral,
 		tokens.String:     p.parseStringLiteral,
 		tokens.Identifier: p.parseIdentifier,
 
+		tokens.Sum:        p.parsePrefixExpression,
 		tokens.Difference: p.parsePrefixExpression,
 
+		tokens.BitwiseXor: p.parsePrefixExpression,
+
 		tokens.Not: p.parsePrefixExpression,
 
+		tokens.Arrow: p.parseReceiveExpression,
+
 		tokens.LPAREN: p.parseGroupedExpression,
 
 		tokens.Func: p.parseFunctionLiteral,
 
+		// types in conversions like []byte(s)
+		tokens.LBRACK:    p.parseType,
+		tokens.Chan:      p.parseType,
+		tokens.Interface: p.parseType,
+		tokens.Map:       p.parseType,
+
 		// TODO remove
 		tokens.True:  p.parseBooleanLiteral,
 		tokens.False: p.parseBooleanLiteral,
@@ -80,7 +96,13 @@ func New(s *scanner.Scanner, config *Config) *Parser {
 		tokens.Quotient:   p.parseInfixExpression,
 		tokens.Remainder:  p.parseInfixExpression,
 
-		// TODO AND, OR, XOR
+		tokens.BitwiseAnd:    p.parseInfixExpression,
+		tokens.BitwiseOr:     p.parseInfixExpression,
+		tokens.BitwiseXor:    p.parseInfixExpression,
+		tokens.BitwiseAndNot: p.parseInfixExpression,
+
+		tokens.ShiftLeft:  p.parseInfixExpression,
+		tokens.ShiftRight: p.parseInfixExpression,
 
 		tokens.LogicalAnd: p.parseInfixExpression,
 		tokens.LogicalOr:  p.parseInfixExpression,
@@ -93,6 +115,8 @@ func New(s *scanner.Scanner, config *Config) *Parser {
 		tokens.GreaterOrEqual: p.parseInfixExpression,
 
 		tokens.LPAREN: p.parseCallExpression,
+		tokens.LBRACK: p.parseIndexOrSliceExpression,
+		tokens.Period: p.parseSelectorExpression,
 	} {
 		p.registerInfix(t, f)
 	}
@@ -117,37 +141,6 @@ const (
 )
 
 var precedences = map[tokens.Type]int{
-	// TODO make LowestPrec default, remove those
-	tokens.Illegal:              LowestPrec,
-	tokens.EOF:                  LowestPrec,
-	tokens.Comment:              LowestPrec,
-	tokens.LBRACE:               LowestPrec,
-	tokens.RBRACE:               LowestPrec,
-	tokens.RPAREN:               LowestPrec,
-	tokens.Period:               LowestPrec,
-	tokens.Colon:                LowestPrec,
-	tokens.Identifier:           LowestPrec,
-	tokens.Assignment:           LowestPrec,
-	tokens.Define:               LowestPrec,
-	tokens.If:                   LowestPrec,
-	tokens.Else:                 LowestPrec,
-	tokens.Return:               LowestPrec,
-	tokens.True:                 LowestPrec,
-	tokens.False:                LowestPrec,
-	tokens.Func:                 LowestPrec,
-	tokens.Integer:              LowestPrec,
-	tokens.Float:                LowestPrec,
-	tokens.Increment:            LowestPrec,
-	tokens.Switch:               LowestPrec,
-	tokens.Case:                 LowestPrec,
-	tokens.Var:                  LowestPrec,
-	tokens.For:                  LowestPrec,
-	tokens.SumAssignment:        LowestPrec,
-	tokens.DifferenceAssignment: LowestPrec,
-	tokens.ProductAssignment:    LowestPrec,
-	tokens.QuotientAssignment:   LowestPrec,
-	tokens.RemainderAssignment:  LowestPrec,
-
 	tokens.LogicalOr: 1,
 
 	tokens.LogicalAnd: 2,
@@ -164,14 +157,19 @@ var precedences = map[tokens.Type]int{
 	tokens.BitwiseOr:  4,
 	tokens.BitwiseXor: 4,
 
-	tokens.Product:    5,
-	tokens.Quotient:   5,
-	tokens.Remainder:  5,
-	tokens.BitwiseAnd: 5,
+	tokens.Product:       5,
+	tokens.Quotient:      5,
+	tokens.Remainder:     5,
+	tokens.BitwiseAnd:    5,
+	tokens.BitwiseAndNot: 5,
+	tokens.ShiftLeft:     5,
+	tokens.ShiftRight:    5,
 
 	tokens.Not: UnaryPrec,
 
 	tokens.LPAREN: HighestPrec,
+	tokens.LBRACK: HighestPrec,
+	tokens.Period: HighestPrec,
 }
 
 func (p *Parser) crash(format string, a ...interface{}) {
@@ -179,24 +177,14 @@ func (p *Parser) crash(format string, a ...interface{}) {
 	panic(fmt.Errorf("%s\ncurToken: %s\npeekToken: %s\nerrors: %s", msg, p.curToken, p.peekToken, p.errors))
 }
 
+// peekPrecedence returns precedence of peekToken, or LowestPrec if it is not an operator.
 func (p *Parser) peekPrecedence() int {
-	t := p.peekToken.Type
-	if p, ok := precedences[t]; ok {
-		return p
-	}
-
-	p.crash("precedence for %s not found", t) // TODO remove
-	return LowestPrec
+	return precedences[p.peekToken.Type]
 }
 
+// curPrecedence returns precedence of curToken, or LowestPrec if it is not an operator.
 func (p *Parser) curPrecedence() int {
-	t := p.curToken.Type
-	if p, ok := precedences[t]; ok {
-		return p
-	}
-
-	p.crash("precedence for %s not found", t) // TODO remove
-	return LowestPrec
+	return precedences[p.curToken.Type]
 }
 
 func (p *Parser) addParsingError(format string, a ...interface{}) {
@@ -295,13 +283,17 @@ func (p *Parser) parseComment() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
+	// untyped constants may overflow any Go type
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-	if err != nil {
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
 		p.addParsingError("could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 
-	lit.Value = int(value)
+	if err == nil && int64(int(value)) == value {
+		lit.Value = int(value)
+	}
+	lit.Exact = constant.MakeFromLiteral(p.curToken.Literal, token.INT, 0)
 	return lit
 }
 
@@ -309,22 +301,56 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	lit := &ast.FloatLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
-	if err != nil {
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
 		p.addParsingError("could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
 
 	lit.Value = value
+	lit.Exact = constant.MakeFromLiteral(p.curToken.Literal, token.FLOAT, 0)
+	return lit
+}
+
+func (p *Parser) parseImaginaryLiteral() ast.Expression {
+	lit := &ast.ImaginaryLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(strings.TrimSuffix(p.curToken.Literal, "i"), 64)
+	if err != nil {
+		p.addParsingError("could not parse %q as imaginary", p.curToken.Literal)
+		return nil
+	}
+
+	lit.Value = complex(0, value)
+	lit.Exact = constant.MakeFromLiteral(p.curToken.Literal, token.IMAG, 0)
 	return lit
 }
 
 func (p *Parser) parseStringLiteral() ast.Expression {
 	s := p.curToken.Literal
-	if !strings.HasPrefix(s, `"`) || !strings.HasSuffix(s, `"`) {
+	if !strings.HasPrefix(s, `"`) {
+		p.addParsingError("could not parse %q as string", s)
+		return nil
+	}
+	value, err := strconv.Unquote(s)
+	if err != nil {
 		p.addParsingError("could not parse %q as string", s)
 		return nil
 	}
-	return &ast.StringLiteral{Token: p.curToken, Value: s[1 : len(s)-1]}
+	return &ast.StringLiteral{Token: p.curToken, Value: value}
+}
+
+func (p *Parser) parseRuneLiteral() ast.Expression {
+	s := p.curToken.Literal
+	if len(s) < 3 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		p.addParsingError("could not parse %q as rune", s)
+		return nil
+	}
+	r, _, tail, err := strconv.UnquoteChar(s[1:len(s)-1], '\'')
+	if err != nil || tail != "" {
+		p.addParsingError("could not parse %q as rune", s)
+		return nil
+	}
+	return &ast.RuneLiteral{Token: p.curToken, Value: r, Exact: constant.MakeInt64(int64(r))}
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
@@ -370,6 +396,14 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return expression
 }
 
+// parseReceiveExpression parses receive expression `<-ch`, or receive-only channel type `<-chan T`.
+func (p *Parser) parseReceiveExpression() ast.Expression {
+	if p.peekToken.Type == tokens.Chan {
+		return p.parseType()
+	}
+	return p.parsePrefixExpression()
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 	exp := p.parseExpression(LowestPrec)
@@ -405,8 +439,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	if !p.expectPeek(tokens.LPAREN) {
 		return nil
 	}
-
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseSignature(lit) {
+		return nil
+	}
 
 	if !p.expectPeek(tokens.LBRACE) {
 		return nil
@@ -416,27 +451,131 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// parseSignature parses parameters and results of function literal starting at `(` token.
+// It returns false if that fails.
+func (p *Parser) parseSignature(lit *ast.FunctionLiteral) bool {
+	lit.Parameters, lit.Types = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return false
+	}
+
+	switch p.peekToken.Type {
+	case tokens.LPAREN:
+		p.nextToken()
+		lit.Results = p.parseFunctionResults()
+		return lit.Results != nil
+	case tokens.LBRACE:
+		return true
+	default:
+		// unnamed result
+		p.nextToken()
+		tok := p.curToken
+		t := p.parseType()
+		if t == nil {
+			return false
+		}
+		lit.Results = []*ast.Result{{Name: &ast.Identifier{Token: tok, Value: ast.UnnamedResult(0)}, Type: t}}
+		return true
+	}
+}
+
+// parseFunctionParameters parses parameters list with optional types like `(a, b int, s string)`.
+// Types are nil for untyped parameters.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.Expression) {
+	identifiers := []*ast.Identifier{}
+	var types []ast.Expression
 	if p.peekToken.Type == tokens.RPAREN {
 		p.nextToken()
-		return []*ast.Identifier{}
+		return identifiers, nil
 	}
 
-	p.nextToken()
+	var untyped int // the first parameter without type yet
+	for {
+		if !p.expectPeek(tokens.Identifier) {
+			return nil, nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
 
-	identifiers := []*ast.Identifier{{Token: p.curToken, Value: p.curToken.Literal}}
+		if p.peekToken.Type != tokens.Comma && p.peekToken.Type != tokens.RPAREN {
+			p.nextToken()
+			t := p.parseType()
+			if t == nil {
+				return nil, nil
+			}
+			for len(types) < len(identifiers) {
+				types = append(types, t)
+			}
+			untyped = len(identifiers)
+		}
 
-	for p.peekToken.Type == tokens.Comma {
-		p.nextToken()
+		if p.peekToken.Type != tokens.Comma {
+			break
+		}
 		p.nextToken()
-		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
 	}
 
 	if !p.expectPeek(tokens.RPAREN) {
+		return nil, nil
+	}
+	if types != nil && untyped != len(identifiers) {
+		p.addParsingError("mixed typed and untyped parameters")
+		return nil, nil
+	}
+	return identifiers, types
+}
+
+// parseFunctionStatement parses function declaration like `func name(params) results { body }`.
+// It is a shorthand for variable declaration with function literal value.
+func (p *Parser) parseFunctionStatement() *ast.VarStatement {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.expectPeek(tokens.Identifier) {
 		return nil
 	}
+	stmt := &ast.VarStatement{
+		Token: lit.Token,
+		Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		Value: lit,
+	}
 
-	return identifiers
+	if !p.expectPeek(tokens.LPAREN) {
+		return nil
+	}
+	if !p.parseSignature(lit) {
+		return nil
+	}
+	if !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	lit.Body = p.parseBlockStatement()
+
+	p.skipSemicolons()
+	return stmt
+}
+
+// parseFunctionResults parses named results list starting at `(` token.
+func (p *Parser) parseFunctionResults() []*ast.Result {
+	var results []*ast.Result
+	for {
+		if !p.expectPeek(tokens.Identifier) {
+			return nil
+		}
+		r := &ast.Result{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if p.peekToken.Type != tokens.Comma && p.peekToken.Type != tokens.RPAREN {
+			p.nextToken()
+			r.Type = p.parseExpression(LowestPrec)
+		}
+		results = append(results, r)
+
+		if p.peekToken.Type != tokens.Comma {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(tokens.RPAREN) {
+		return nil
+	}
+	return results
 }
 
 func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
@@ -452,12 +591,54 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseIndexOrSliceExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+	p.nextToken()
+
+	var low ast.Expression
+	if p.curToken.Type != tokens.Colon {
+		if low = p.parseExpression(LowestPrec); low == nil {
+			return nil
+		}
+		if p.peekToken.Type == tokens.RBRACK {
+			p.nextToken()
+			return &ast.IndexExpression{Token: tok, Left: left, Index: low}
+		}
+		if !p.expectPeek(tokens.Colon) {
+			return nil
+		}
+	}
+
+	exp := &ast.SliceExpression{Token: tok, Left: left, Low: low}
+	if p.peekToken.Type == tokens.RBRACK {
+		p.nextToken()
+		return exp
+	}
+	p.nextToken()
+	if exp.High = p.parseExpression(LowestPrec); exp.High == nil {
+		return nil
+	}
+	if !p.expectPeek(tokens.RBRACK) {
+		return nil
+	}
+	return exp
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseCallArguments()
 	return exp
 }
 
+func (p *Parser) parseSelectorExpression(x ast.Expression) ast.Expression {
+	exp := &ast.SelectorExpression{Token: p.curToken, X: x}
+	if !p.expectPeek(tokens.Identifier) {
+		return nil
+	}
+	exp.Sel = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
 func (p *Parser) parseCallArguments() []ast.Expression {
 	args := []ast.Expression{}
 
@@ -489,9 +670,20 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	if !p.expectPeek(tokens.Assignment) {
-		return nil
+	if p.peekToken.Type != tokens.Assignment {
+		p.nextToken()
+		stmt.Type = p.parseType()
+		if stmt.Type == nil {
+			return nil
+		}
+		if p.peekToken.Type != tokens.Assignment {
+			for p.peekToken.Type == tokens.Semicolon {
+				p.nextToken()
+			}
+			return stmt
+		}
 	}
+	p.nextToken()
 
 	p.nextToken()
 	stmt.Value = p.parseExpression(LowestPrec)
@@ -503,21 +695,237 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 	return stmt
 }
 
-func (p *Parser) parseIfStatement() *ast.IfStatement {
-	if !p.expectCurrent(tokens.If) {
+// skipSemicolons skips semicolons after the current token.
+func (p *Parser) skipSemicolons() {
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+}
+
+// parseImportStatement parses import declaration with a single or grouped import specs.
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if p.peekToken.Type != tokens.LPAREN {
+		p.nextToken()
+		spec := p.parseImportSpec()
+		if spec == nil {
+			return nil
+		}
+		stmt.Specs = append(stmt.Specs, spec)
+		p.skipSemicolons()
+		return stmt
+	}
+
+	p.nextToken()
+	p.skipSemicolons()
+	for p.peekToken.Type != tokens.RPAREN {
+		p.nextToken()
+		spec := p.parseImportSpec()
+		if spec == nil {
+			return nil
+		}
+		stmt.Specs = append(stmt.Specs, spec)
+		p.skipSemicolons()
+	}
+	p.nextToken()
+	p.skipSemicolons()
+	return stmt
+}
+
+// parseImportSpec parses import spec starting at the current token.
+func (p *Parser) parseImportSpec() *ast.ImportSpec {
+	spec := new(ast.ImportSpec)
+	if p.curToken.Type == tokens.Identifier {
+		spec.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+	}
+	if !p.expectCurrent(tokens.String) {
 		return nil
 	}
-	stmt := &ast.IfStatement{Token: p.curToken}
+	path, ok := p.parseStringLiteral().(*ast.StringLiteral)
+	if !ok {
+		return nil
+	}
+	if path.Value == "" {
+		p.addParsingError("invalid import path: %s", path)
+		return nil
+	}
+	spec.Path = path
+	return spec
+}
 
-	if !p.expectPeek(tokens.LPAREN) {
+func (p *Parser) parseTypeStatement() *ast.TypeStatement {
+	stmt := &ast.TypeStatement{Token: p.curToken}
+	if !p.expectPeek(tokens.Identifier) {
 		return nil
 	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	p.nextToken()
-	stmt.Cond = p.parseExpression(LowestPrec)
+	if stmt.Type = p.parseType(); stmt.Type == nil {
+		return nil
+	}
+
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseType parses a type starting at the current token.
+func (p *Parser) parseType() ast.Expression {
+	switch p.curToken.Type {
+	case tokens.Identifier:
+		id := p.parseIdentifier()
+		if p.peekToken.Type != tokens.Period {
+			return id
+		}
+
+		// qualified type name like strings.Builder
+		p.nextToken()
+		return p.parseSelectorExpression(id)
+
+	case tokens.LBRACK:
+		t := &ast.SliceType{Token: p.curToken}
+		if !p.expectPeek(tokens.RBRACK) {
+			return nil
+		}
+		p.nextToken()
+		if t.Elem = p.parseType(); t.Elem == nil {
+			return nil
+		}
+		return t
+
+	case tokens.Map:
+		t := &ast.MapType{Token: p.curToken}
+		if !p.expectPeek(tokens.LBRACK) {
+			return nil
+		}
+		p.nextToken()
+		if t.Key = p.parseType(); t.Key == nil {
+			return nil
+		}
+		if !p.expectPeek(tokens.RBRACK) {
+			return nil
+		}
+		p.nextToken()
+		if t.Value = p.parseType(); t.Value == nil {
+			return nil
+		}
+		return t
+
+	case tokens.Product:
+		t := &ast.PointerType{Token: p.curToken}
+		p.nextToken()
+		if t.Elem = p.parseType(); t.Elem == nil {
+			return nil
+		}
+		return t
+
+	case tokens.Chan:
+		t := &ast.ChanType{Token: p.curToken}
+		if p.peekToken.Type == tokens.Arrow {
+			p.nextToken()
+			t.Dir = ast.SendOnly
+		}
+		p.nextToken()
+		if t.Elem = p.parseType(); t.Elem == nil {
+			return nil
+		}
+		return t
+
+	case tokens.Arrow:
+		t := &ast.ChanType{Token: p.curToken, Dir: ast.RecvOnly}
+		if !p.expectPeek(tokens.Chan) {
+			return nil
+		}
+		p.nextToken()
+		if t.Elem = p.parseType(); t.Elem == nil {
+			return nil
+		}
+		return t
+
+	case tokens.Func:
+		t := &ast.FuncType{Token: p.curToken}
+		if !p.expectPeek(tokens.LPAREN) {
+			return nil
+		}
+		if t.Params = p.parseTypeList(); t.Params == nil {
+			return nil
+		}
+		switch p.peekToken.Type {
+		case tokens.LPAREN:
+			p.nextToken()
+			if t.Results = p.parseTypeList(); t.Results == nil {
+				return nil
+			}
+		case tokens.Identifier, tokens.LBRACK, tokens.Map, tokens.Product, tokens.Chan, tokens.Arrow, tokens.Func, tokens.Interface:
+			p.nextToken()
+			r := p.parseType()
+			if r == nil {
+				return nil
+			}
+			t.Results = []ast.Expression{r}
+		}
+		return t
+
+	case tokens.Interface:
+		t := &ast.InterfaceType{Token: p.curToken}
+		if !p.expectPeek(tokens.LBRACE) {
+			return nil
+		}
+		if !p.expectPeek(tokens.RBRACE) {
+			return nil
+		}
+		return t
+
+	default:
+		p.addParsingError("expected type, got %s", p.curToken)
+		return nil
+	}
+}
+
+// parseTypeList parses a parenthesized list of types; current token should be tokens.LPAREN.
+// It returns nil on error.
+func (p *Parser) parseTypeList() []ast.Expression {
+	types := []ast.Expression{}
+	if p.peekToken.Type == tokens.RPAREN {
+		p.nextToken()
+		return types
+	}
+
+	for {
+		p.nextToken()
+		t := p.parseType()
+		if t == nil {
+			return nil
+		}
+		types = append(types, t)
+
+		if p.peekToken.Type != tokens.Comma {
+			break
+		}
+		p.nextToken()
+	}
 
 	if !p.expectPeek(tokens.RPAREN) {
 		return nil
 	}
+	return types
+}
+
+func (p *Parser) parseIfStatement() *ast.IfStatement {
+	if !p.expectCurrent(tokens.If) {
+		return nil
+	}
+	stmt := &ast.IfStatement{Token: p.curToken}
+
+	// parentheses around the condition are optional, like in Go
+	p.nextToken()
+	stmt.Cond = p.parseExpression(LowestPrec)
+
 	if !p.expectPeek(tokens.LBRACE) {
 		return nil
 	}
@@ -532,31 +940,90 @@ func (p *Parser) parseIfStatement() *ast.IfStatement {
 
 var assignTokens = []tokens.Type{
 	tokens.Assignment,
+	tokens.Define,
 	tokens.SumAssignment,
 	tokens.DifferenceAssignment,
 	tokens.ProductAssignment,
 	tokens.QuotientAssignment,
 	tokens.RemainderAssignment,
+	tokens.BitwiseAndAssignment,
+	tokens.BitwiseOrAssignment,
+	tokens.BitwiseXorAssignment,
+	tokens.BitwiseAndNotAssignment,
+	tokens.ShiftLeftAssignment,
+	tokens.ShiftRightAssignment,
 }
 
-func (p *Parser) parseAssignStatement() *ast.AssignStatement {
-	if !p.expectCurrent(tokens.Identifier) {
+// parseAssignStatement parses assignment statement starting at the last token of the assigned expression x.
+func (p *Parser) parseAssignStatement(x ast.Expression) *ast.AssignStatement {
+	if !p.expectPeek(assignTokens...) {
 		return nil
 	}
-	stmt := &ast.AssignStatement{
-		Name: &ast.Identifier{
-			Token: p.curToken,
-			Value: p.curToken.Literal,
-		},
+	stmt := &ast.AssignStatement{Token: p.curToken, Name: x}
+	if !p.checkAssigned(stmt) {
+		return nil
 	}
 
-	if !p.expectPeek(assignTokens...) {
+	p.nextToken()
+	return p.parseAssignValue(stmt)
+}
+
+// checkAssigned checks that the left side of assignment statement is an identifier
+// for `:=` and multi-value assignments, and an identifier, index or selector expression otherwise.
+func (p *Parser) checkAssigned(stmt *ast.AssignStatement) bool {
+	switch stmt.Name.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.IndexExpression, *ast.SelectorExpression:
+		switch {
+		case stmt.Token.Type == tokens.Define:
+			p.addParsingError("non-name %s on left side of :=", stmt.Name)
+		case stmt.Rest != nil:
+			p.addParsingError("non-name %s on left side of multi-value assignment", stmt.Name)
+		default:
+			return true
+		}
+	default:
+		p.addParsingError("cannot assign to %s", stmt.Name)
+	}
+	return false
+}
+
+// parseAssignValue parses the right side of assignment statement starting at the current token.
+func (p *Parser) parseAssignValue(stmt *ast.AssignStatement) *ast.AssignStatement {
+	stmt.Value = p.parseExpression(LowestPrec)
+
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseIncrementDecrementStatement(x ast.Expression) *ast.IncrementDecrementStatement {
+	stmt := &ast.IncrementDecrementStatement{X: x}
+
+	if !p.expectPeek(tokens.Increment, tokens.Decrement) {
 		return nil
 	}
 	stmt.Token = p.curToken
 
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	stmt := &ast.DeferStatement{Token: p.curToken}
 	p.nextToken()
-	stmt.Value = p.parseExpression(LowestPrec)
+
+	exp := p.parseExpression(LowestPrec)
+	call, ok := exp.(*ast.CallExpression)
+	if !ok {
+		p.addParsingError("expression in defer must be function call")
+		return nil
+	}
+	stmt.Call = call
 
 	for p.peekToken.Type == tokens.Semicolon {
 		p.nextToken()
@@ -564,21 +1031,83 @@ func (p *Parser) parseAssignStatement() *ast.AssignStatement {
 	return stmt
 }
 
-func (p *Parser) parseIncrementDecrementStatement() *ast.IncrementDecrementStatement {
-	if !p.expectCurrent(tokens.Identifier) {
+func (p *Parser) parseGoStatement() *ast.GoStatement {
+	stmt := &ast.GoStatement{Token: p.curToken}
+	p.nextToken()
+
+	exp := p.parseExpression(LowestPrec)
+	call, ok := exp.(*ast.CallExpression)
+	if !ok {
+		p.addParsingError("expression in go must be function call")
 		return nil
 	}
-	stmt := &ast.IncrementDecrementStatement{
-		Name: &ast.Identifier{
-			Token: p.curToken,
-			Value: p.curToken.Literal,
-		},
+	stmt.Call = call
+
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
 	}
+	return stmt
+}
 
-	if !p.expectPeek(tokens.Increment, tokens.Decrement) {
+// parseSendStatement parses send statement starting at the last token of channel expression.
+func (p *Parser) parseSendStatement(ch ast.Expression) *ast.SendStatement {
+	if !p.expectPeek(tokens.Arrow) {
+		return nil
+	}
+	stmt := &ast.SendStatement{Token: p.curToken, Channel: ch}
+
+	p.nextToken()
+	if stmt.Value = p.parseExpression(LowestPrec); stmt.Value == nil {
+		return nil
+	}
+
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseMultiAssignStatement parses assignment statement with several variables
+// (e.g. `v, ok := <-ch` or `n, err := strconv.Atoi(s)`) starting at the last token of the first variable x.
+func (p *Parser) parseMultiAssignStatement(x ast.Expression) *ast.AssignStatement {
+	var rest []*ast.Identifier
+	for p.peekToken.Type == tokens.Comma {
+		p.nextToken()
+		if !p.expectPeek(tokens.Identifier) {
+			return nil
+		}
+		rest = append(rest, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(tokens.Define, tokens.Assignment) {
+		return nil
+	}
+	stmt := &ast.AssignStatement{Token: p.curToken, Name: x, Rest: rest}
+	if !p.checkAssigned(stmt) {
 		return nil
 	}
-	stmt.Token = p.curToken
+
+	p.nextToken()
+	return p.parseAssignValue(stmt)
+}
+
+func (p *Parser) parseSelectStatement() *ast.SelectStatement {
+	stmt := &ast.SelectStatement{Token: p.curToken}
+	if !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+	for p.curToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+
+	for p.curToken.Type != tokens.RBRACE {
+		c := p.parseCommClause()
+		if c == nil {
+			return nil
+		}
+		stmt.Cases = append(stmt.Cases, c)
+	}
 
 	for p.peekToken.Type == tokens.Semicolon {
 		p.nextToken()
@@ -586,10 +1115,51 @@ func (p *Parser) parseIncrementDecrementStatement() *ast.IncrementDecrementState
 	return stmt
 }
 
+// parseCommClause parses a case of select statement starting at `case` or `default` token.
+// It stops at the next `case`, `default` or `}` token.
+func (p *Parser) parseCommClause() *ast.CommClause {
+	if !p.expectCurrent(tokens.Case, tokens.Default) {
+		return nil
+	}
+	c := &ast.CommClause{Token: p.curToken}
+	if c.Token.Type == tokens.Case {
+		p.nextToken()
+		if c.Comm = p.parseExpressionOrAssignmentStatement(); c.Comm == nil {
+			return nil
+		}
+		if a, ok := c.Comm.(*ast.AssignStatement); ok {
+			if _, ok = a.Name.(*ast.Identifier); !ok {
+				p.addParsingError("non-name %s on left side of select case assignment", a.Name)
+				return nil
+			}
+		}
+	}
+	if !p.expectPeek(tokens.Colon) {
+		return nil
+	}
+
+	p.nextToken()
+	for {
+		switch p.curToken.Type {
+		case tokens.Case, tokens.Default, tokens.RBRACE:
+			return c
+		case tokens.EOF:
+			p.addParsingError("expected case, default or RBRACE, got %s", p.curToken)
+			return nil
+		}
+		if stmt := p.parseStatement(); stmt != nil {
+			c.Body = append(c.Body, stmt)
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
-	p.nextToken()
-	stmt.Value = p.parseExpression(LowestPrec)
+	if p.peekToken.Type != tokens.Semicolon && p.peekToken.Type != tokens.RBRACE && p.peekToken.Type != tokens.EOF {
+		p.nextToken()
+		stmt.Value = p.parseExpression(LowestPrec)
+	}
 
 	for p.peekToken.Type == tokens.Semicolon {
 		p.nextToken()
@@ -612,14 +1182,44 @@ func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
 	return stmt
 }
 
-func (p *Parser) parseForStatement() *ast.ForStatement {
+func (p *Parser) parseForStatement() ast.Statement {
 	if !p.expectCurrent(tokens.For) {
 		return nil
 	}
 	stmt := &ast.ForStatement{Token: p.curToken}
 
 	p.nextToken()
-	stmt.Init = p.parseAssignStatement()
+	switch {
+	case p.curToken.Type == tokens.LBRACE:
+		// infinite loop
+		stmt.Body = p.parseBlockStatement()
+		for p.peekToken.Type == tokens.Semicolon {
+			p.nextToken()
+		}
+		return stmt
+	case p.curToken.Type == tokens.Range:
+		return p.parseRangeStatement(stmt.Token)
+	case p.curToken.Type == tokens.Identifier && p.peekToken.Type == tokens.Comma:
+		return p.parseRangeStatement(stmt.Token)
+	case p.curToken.Type == tokens.Identifier && p.peekToken.Type == tokens.Define:
+		// both `for k := range x` and `for i := 0; ...` start with `k :=`
+		key := &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+		init := &ast.AssignStatement{Name: key}
+		p.nextToken()
+		init.Token = p.curToken
+		p.nextToken()
+		if p.curToken.Type == tokens.Range {
+			return p.parseRangeClause(&ast.RangeStatement{Token: stmt.Token, Key: key, Define: true})
+		}
+		stmt.Init = p.parseAssignValue(init)
+	default:
+		if stmt.Init = p.parseAssignStatement(p.parseExpression(LowestPrec)); stmt.Init == nil {
+			return nil
+		}
+	}
 
 	if !p.expectCurrent(tokens.Semicolon) {
 		return nil
@@ -643,6 +1243,47 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 	return stmt
 }
 
+// parseRangeStatement parses a for statement with a range clause starting after `for` token.
+func (p *Parser) parseRangeStatement(forToken tokens.Token) ast.Statement {
+	stmt := &ast.RangeStatement{Token: forToken}
+	if p.curToken.Type == tokens.Identifier {
+		stmt.Key = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekToken.Type == tokens.Comma {
+			p.nextToken()
+			if !p.expectPeek(tokens.Identifier) {
+				return nil
+			}
+			stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		}
+		if !p.expectPeek(tokens.Define, tokens.Assignment) {
+			return nil
+		}
+		stmt.Define = p.curToken.Type == tokens.Define
+		p.nextToken()
+	}
+	return p.parseRangeClause(stmt)
+}
+
+// parseRangeClause parses the rest of for statement with a range clause starting at `range` token.
+func (p *Parser) parseRangeClause(stmt *ast.RangeStatement) ast.Statement {
+	if !p.expectCurrent(tokens.Range) {
+		return nil
+	}
+	p.nextToken()
+	if stmt.X = p.parseExpression(LowestPrec); stmt.X == nil {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Body = p.parseBlockStatement()
+
+	for p.peekToken.Type == tokens.Semicolon {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionOrAssignmentStatement() ast.Statement {
 	cur := p.curToken
 	exp := p.parseExpression(LowestPrec)
@@ -650,11 +1291,23 @@ func (p *Parser) parseExpressionOrAssignmentStatement() ast.Statement {
 	var stmt ast.Statement
 	switch p.peekToken.Type {
 	case tokens.Increment, tokens.Decrement:
-		stmt = p.parseIncrementDecrementStatement()
+		stmt = p.parseIncrementDecrementStatement(exp)
+	case tokens.Arrow:
+		stmt = p.parseSendStatement(exp)
+	case tokens.Comma:
+		s := p.parseMultiAssignStatement(exp)
+		if s == nil {
+			return nil
+		}
+		stmt = s
 	default:
 		for _, t := range assignTokens {
 			if p.peekToken.Type == t {
-				stmt = p.parseAssignStatement()
+				s := p.parseAssignStatement(exp)
+				if s == nil {
+					return nil
+				}
+				stmt = s
 				break
 			}
 		}
@@ -674,14 +1327,33 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case tokens.Var:
 		return p.parseVarStatement()
+	case tokens.Func:
+		if p.peekToken.Type == tokens.Identifier {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionOrAssignmentStatement()
+	case tokens.TypeKeyword:
+		return p.parseTypeStatement()
 	case tokens.If:
 		return p.parseIfStatement()
 	case tokens.Return:
 		return p.parseReturnStatement()
+	case tokens.Defer:
+		return p.parseDeferStatement()
+	case tokens.Go:
+		return p.parseGoStatement()
+	case tokens.Select:
+		return p.parseSelectStatement()
 	case tokens.Continue:
 		return p.parseContinueStatement()
 	case tokens.For:
 		return p.parseForStatement()
+	case tokens.Package:
+		p.addParsingError("package statement must be first")
+		return nil
+	case tokens.Import:
+		p.addParsingError("imports must appear before other declarations")
+		return nil
 	default:
 		return p.parseExpressionOrAssignmentStatement()
 	}
@@ -691,6 +1363,22 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{
 		Statements: make([]ast.Statement, 0, 8),
+		Lines:      p.s.Lines(),
+	}
+
+	// optional package clause and imports are before other statements
+	if p.curToken.Type == tokens.Package {
+		if p.expectPeek(tokens.Identifier) {
+			program.Package = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		}
+		p.skipSemicolons()
+		p.nextToken()
+	}
+	for p.curToken.Type == tokens.Import {
+		if stmt := p.parseImportStatement(); stmt != nil {
+			program.Statements = append(program.Statements, stmt)
+		}
+		p.nextToken()
 	}
 
 	for p.curToken.Type != tokens.EOF {
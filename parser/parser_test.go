@@ -8,6 +8,8 @@
 package parser
 
 import (
+	"go/constant"
+	"go/token"
 	"strings"
 	"testing"
 
@@ -84,6 +86,12 @@ func TestGolden(t *testing.T) {
 }
 
 func TestParser(t *testing.T) {
+	// grouped parameters share the type expression
+	intType := &ast.Identifier{
+		Token: tokens.Token{Offset: 14, Type: tokens.Identifier, Literal: "int"},
+		Value: "int",
+	}
+
 	for source, expected := range map[string]ast.Statement{
 		"var answer = 42": &ast.VarStatement{
 			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
@@ -94,6 +102,187 @@ func TestParser(t *testing.T) {
 			Value: &ast.IntegerLiteral{
 				Token: tokens.Token{Offset: 13, Type: tokens.Integer, Literal: "42"},
 				Value: 42,
+				Exact: constant.MakeInt64(42),
+			},
+		},
+
+		"var answer int8 = 42": &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "answer"},
+				Value: "answer",
+			},
+			Type: &ast.Identifier{
+				Token: tokens.Token{Offset: 11, Type: tokens.Identifier, Literal: "int8"},
+				Value: "int8",
+			},
+			Value: &ast.IntegerLiteral{
+				Token: tokens.Token{Offset: 18, Type: tokens.Integer, Literal: "42"},
+				Value: 42,
+				Exact: constant.MakeInt64(42),
+			},
+		},
+
+		"var answer uint64": &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "answer"},
+				Value: "answer",
+			},
+			Type: &ast.Identifier{
+				Token: tokens.Token{Offset: 11, Type: tokens.Identifier, Literal: "uint64"},
+				Value: "uint64",
+			},
+		},
+
+		"type Celsius float64": &ast.TypeStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.TypeKeyword, Literal: "type"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 5, Type: tokens.Identifier, Literal: "Celsius"},
+				Value: "Celsius",
+			},
+			Type: &ast.Identifier{
+				Token: tokens.Token{Offset: 13, Type: tokens.Identifier, Literal: "float64"},
+				Value: "float64",
+			},
+		},
+
+		"[]byte('\\n')": &ast.ExpressionStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.LBRACK, Literal: "["},
+			Expression: &ast.CallExpression{
+				Token: tokens.Token{Offset: 6, Type: tokens.LPAREN, Literal: "("},
+				Function: &ast.SliceType{
+					Token: tokens.Token{Offset: 0, Type: tokens.LBRACK, Literal: "["},
+					Elem: &ast.Identifier{
+						Token: tokens.Token{Offset: 2, Type: tokens.Identifier, Literal: "byte"},
+						Value: "byte",
+					},
+				},
+				Arguments: []ast.Expression{
+					&ast.RuneLiteral{
+						Token: tokens.Token{Offset: 7, Type: tokens.Rune, Literal: "'\\n'"},
+						Value: '\n',
+						Exact: constant.MakeInt64('\n'),
+					},
+				},
+			},
+		},
+
+		`strings.Repeat("ab", 2)`: &ast.ExpressionStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "strings"},
+			Expression: &ast.CallExpression{
+				Token: tokens.Token{Offset: 14, Type: tokens.LPAREN, Literal: "("},
+				Function: &ast.SelectorExpression{
+					Token: tokens.Token{Offset: 7, Type: tokens.Period, Literal: "."},
+					X: &ast.Identifier{
+						Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "strings"},
+						Value: "strings",
+					},
+					Sel: &ast.Identifier{
+						Token: tokens.Token{Offset: 8, Type: tokens.Identifier, Literal: "Repeat"},
+						Value: "Repeat",
+					},
+				},
+				Arguments: []ast.Expression{
+					&ast.StringLiteral{
+						Token: tokens.Token{Offset: 15, Type: tokens.String, Literal: `"ab"`},
+						Value: "ab",
+					},
+					&ast.IntegerLiteral{
+						Token: tokens.Token{Offset: 21, Type: tokens.Integer, Literal: "2"},
+						Value: 2,
+						Exact: constant.MakeInt64(2),
+					},
+				},
+			},
+		},
+
+		"var b strings.Builder": &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "b"},
+				Value: "b",
+			},
+			Type: &ast.SelectorExpression{
+				Token: tokens.Token{Offset: 13, Type: tokens.Period, Literal: "."},
+				X: &ast.Identifier{
+					Token: tokens.Token{Offset: 6, Type: tokens.Identifier, Literal: "strings"},
+					Value: "strings",
+				},
+				Sel: &ast.Identifier{
+					Token: tokens.Token{Offset: 14, Type: tokens.Identifier, Literal: "Builder"},
+					Value: "Builder",
+				},
+			},
+		},
+
+		`import u "./lib/util.gosh"`: &ast.ImportStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Import, Literal: "import"},
+			Specs: []*ast.ImportSpec{{
+				Name: &ast.Identifier{
+					Token: tokens.Token{Offset: 7, Type: tokens.Identifier, Literal: "u"},
+					Value: "u",
+				},
+				Path: &ast.StringLiteral{
+					Token: tokens.Token{Offset: 9, Type: tokens.String, Literal: `"./lib/util.gosh"`},
+					Value: "./lib/util.gosh",
+				},
+			}},
+		},
+
+		"import (\n\"fmt\"\ns \"strings\"\n)": &ast.ImportStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Import, Literal: "import"},
+			Specs: []*ast.ImportSpec{{
+				Path: &ast.StringLiteral{
+					Token: tokens.Token{Offset: 9, Type: tokens.String, Literal: `"fmt"`},
+					Value: "fmt",
+				},
+			}, {
+				Name: &ast.Identifier{
+					Token: tokens.Token{Offset: 15, Type: tokens.Identifier, Literal: "s"},
+					Value: "s",
+				},
+				Path: &ast.StringLiteral{
+					Token: tokens.Token{Offset: 17, Type: tokens.String, Literal: `"strings"`},
+					Value: "strings",
+				},
+			}},
+		},
+
+		"s[1:]": &ast.ExpressionStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "s"},
+			Expression: &ast.SliceExpression{
+				Token: tokens.Token{Offset: 1, Type: tokens.LBRACK, Literal: "["},
+				Left: &ast.Identifier{
+					Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "s"},
+					Value: "s",
+				},
+				Low: &ast.IntegerLiteral{
+					Token: tokens.Token{Offset: 2, Type: tokens.Integer, Literal: "1"},
+					Value: 1,
+					Exact: constant.MakeInt64(1),
+				},
+			},
+		},
+
+		"for i, r := range s {\n}": &ast.RangeStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.For, Literal: "for"},
+			Key: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "i"},
+				Value: "i",
+			},
+			Value: &ast.Identifier{
+				Token: tokens.Token{Offset: 7, Type: tokens.Identifier, Literal: "r"},
+				Value: "r",
+			},
+			Define: true,
+			X: &ast.Identifier{
+				Token: tokens.Token{Offset: 18, Type: tokens.Identifier, Literal: "s"},
+				Value: "s",
+			},
+			Body: &ast.BlockStatement{
+				Token:      tokens.Token{Offset: 20, Type: tokens.LBRACE, Literal: "{"},
+				Statements: []ast.Statement{},
 			},
 		},
 
@@ -106,6 +295,47 @@ func TestParser(t *testing.T) {
 			Value: &ast.IntegerLiteral{
 				Token: tokens.Token{Offset: 9, Type: tokens.Integer, Literal: "42"},
 				Value: 42,
+				Exact: constant.MakeInt64(42),
+			},
+		},
+
+		"util.Counter = 5": &ast.AssignStatement{
+			Token: tokens.Token{Offset: 13, Type: tokens.Assignment, Literal: "="},
+			Name: &ast.SelectorExpression{
+				Token: tokens.Token{Offset: 4, Type: tokens.Period, Literal: "."},
+				X: &ast.Identifier{
+					Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "util"},
+					Value: "util",
+				},
+				Sel: &ast.Identifier{
+					Token: tokens.Token{Offset: 5, Type: tokens.Identifier, Literal: "Counter"},
+					Value: "Counter",
+				},
+			},
+			Value: &ast.IntegerLiteral{
+				Token: tokens.Token{Offset: 15, Type: tokens.Integer, Literal: "5"},
+				Value: 5,
+				Exact: constant.MakeInt64(5),
+			},
+		},
+
+		"foo.x = 7": &ast.AssignStatement{
+			Token: tokens.Token{Offset: 6, Type: tokens.Assignment, Literal: "="},
+			Name: &ast.SelectorExpression{
+				Token: tokens.Token{Offset: 3, Type: tokens.Period, Literal: "."},
+				X: &ast.Identifier{
+					Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "foo"},
+					Value: "foo",
+				},
+				Sel: &ast.Identifier{
+					Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "x"},
+					Value: "x",
+				},
+			},
+			Value: &ast.IntegerLiteral{
+				Token: tokens.Token{Offset: 8, Type: tokens.Integer, Literal: "7"},
+				Value: 7,
+				Exact: constant.MakeInt64(7),
 			},
 		},
 
@@ -120,6 +350,7 @@ func TestParser(t *testing.T) {
 				Right: &ast.IntegerLiteral{
 					Token: tokens.Token{Offset: 10, Type: tokens.Integer, Literal: "42"},
 					Value: 42,
+					Exact: constant.MakeInt64(42),
 				},
 			},
 		},
@@ -133,22 +364,196 @@ func TestParser(t *testing.T) {
 			Value: &ast.IntegerLiteral{
 				Token: tokens.Token{Offset: 10, Type: tokens.Integer, Literal: "42"},
 				Value: 42,
+				Exact: constant.MakeInt64(42),
 			},
 		},
 
 		"answer++": &ast.IncrementDecrementStatement{
 			Token: tokens.Token{Offset: 6, Type: tokens.Increment, Literal: "++"},
-			Name: &ast.Identifier{
+			X: &ast.Identifier{
 				Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "answer"},
 				Value: "answer",
 			},
 		},
 
+		"a[i]--": &ast.IncrementDecrementStatement{
+			Token: tokens.Token{Offset: 4, Type: tokens.Decrement, Literal: "--"},
+			X: &ast.IndexExpression{
+				Token: tokens.Token{Offset: 1, Type: tokens.LBRACK, Literal: "["},
+				Left: &ast.Identifier{
+					Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "a"},
+					Value: "a",
+				},
+				Index: &ast.Identifier{
+					Token: tokens.Token{Offset: 2, Type: tokens.Identifier, Literal: "i"},
+					Value: "i",
+				},
+			},
+		},
+
+		"x &^= 1 << n": &ast.AssignStatement{
+			Token: tokens.Token{Offset: 2, Type: tokens.BitwiseAndNotAssignment, Literal: "&^="},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "x"},
+				Value: "x",
+			},
+			Value: &ast.InfixExpression{
+				Token: tokens.Token{Offset: 8, Type: tokens.ShiftLeft, Literal: "<<"},
+				Left: &ast.IntegerLiteral{
+					Token: tokens.Token{Offset: 6, Type: tokens.Integer, Literal: "1"},
+					Value: 1,
+					Exact: constant.MakeInt64(1),
+				},
+				Right: &ast.Identifier{
+					Token: tokens.Token{Offset: 11, Type: tokens.Identifier, Literal: "n"},
+					Value: "n",
+				},
+			},
+		},
+
+		"x := 42": &ast.AssignStatement{
+			Token: tokens.Token{Offset: 2, Type: tokens.Define, Literal: ":="},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "x"},
+				Value: "x",
+			},
+			Value: &ast.IntegerLiteral{
+				Token: tokens.Token{Offset: 5, Type: tokens.Integer, Literal: "42"},
+				Value: 42,
+				Exact: constant.MakeInt64(42),
+			},
+		},
+
+		"defer f(x)": &ast.DeferStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Defer, Literal: "defer"},
+			Call: &ast.CallExpression{
+				Token: tokens.Token{Offset: 7, Type: tokens.LPAREN, Literal: "("},
+				Function: &ast.Identifier{
+					Token: tokens.Token{Offset: 6, Type: tokens.Identifier, Literal: "f"},
+					Value: "f",
+				},
+				Arguments: []ast.Expression{
+					&ast.Identifier{
+						Token: tokens.Token{Offset: 8, Type: tokens.Identifier, Literal: "x"},
+						Value: "x",
+					},
+				},
+			},
+		},
+
+		"go f(x)": &ast.GoStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Go, Literal: "go"},
+			Call: &ast.CallExpression{
+				Token: tokens.Token{Offset: 4, Type: tokens.LPAREN, Literal: "("},
+				Function: &ast.Identifier{
+					Token: tokens.Token{Offset: 3, Type: tokens.Identifier, Literal: "f"},
+					Value: "f",
+				},
+				Arguments: []ast.Expression{
+					&ast.Identifier{
+						Token: tokens.Token{Offset: 5, Type: tokens.Identifier, Literal: "x"},
+						Value: "x",
+					},
+				},
+			},
+		},
+
+		"ch <- 42": &ast.SendStatement{
+			Token: tokens.Token{Offset: 3, Type: tokens.Arrow, Literal: "<-"},
+			Channel: &ast.Identifier{
+				Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "ch"},
+				Value: "ch",
+			},
+			Value: &ast.IntegerLiteral{
+				Token: tokens.Token{Offset: 6, Type: tokens.Integer, Literal: "42"},
+				Value: 42,
+				Exact: constant.MakeInt64(42),
+			},
+		},
+
+		"v, ok := (<-ch)": &ast.AssignStatement{
+			Token: tokens.Token{Offset: 6, Type: tokens.Define, Literal: ":="},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 0, Type: tokens.Identifier, Literal: "v"},
+				Value: "v",
+			},
+			Rest: []*ast.Identifier{{
+				Token: tokens.Token{Offset: 3, Type: tokens.Identifier, Literal: "ok"},
+				Value: "ok",
+			}},
+			Value: &ast.PrefixExpression{
+				Token: tokens.Token{Offset: 10, Type: tokens.Arrow, Literal: "<-"},
+				Right: &ast.Identifier{
+					Token: tokens.Token{Offset: 12, Type: tokens.Identifier, Literal: "ch"},
+					Value: "ch",
+				},
+			},
+		},
+
+		"select {\ncase v := (<-ch):\nv;\ndefault:\n}": &ast.SelectStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Select, Literal: "select"},
+			Cases: []*ast.CommClause{{
+				Token: tokens.Token{Offset: 9, Type: tokens.Case, Literal: "case"},
+				Comm: &ast.AssignStatement{
+					Token: tokens.Token{Offset: 16, Type: tokens.Define, Literal: ":="},
+					Name: &ast.Identifier{
+						Token: tokens.Token{Offset: 14, Type: tokens.Identifier, Literal: "v"},
+						Value: "v",
+					},
+					Value: &ast.PrefixExpression{
+						Token: tokens.Token{Offset: 20, Type: tokens.Arrow, Literal: "<-"},
+						Right: &ast.Identifier{
+							Token: tokens.Token{Offset: 22, Type: tokens.Identifier, Literal: "ch"},
+							Value: "ch",
+						},
+					},
+				},
+				Body: []ast.Statement{
+					&ast.ExpressionStatement{
+						Token: tokens.Token{Offset: 27, Type: tokens.Identifier, Literal: "v"},
+						Expression: &ast.Identifier{
+							Token: tokens.Token{Offset: 27, Type: tokens.Identifier, Literal: "v"},
+							Value: "v",
+						},
+					},
+				},
+			}, {
+				Token: tokens.Token{Offset: 30, Type: tokens.Default, Literal: "default"},
+			}},
+		},
+
+		"func() (r int) {\n}": &ast.ExpressionStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Func, Literal: "func"},
+			Expression: &ast.FunctionLiteral{
+				Token:      tokens.Token{Offset: 0, Type: tokens.Func, Literal: "func"},
+				Parameters: []*ast.Identifier{},
+				Results: []*ast.Result{{
+					Name: &ast.Identifier{
+						Token: tokens.Token{Offset: 8, Type: tokens.Identifier, Literal: "r"},
+						Value: "r",
+					},
+					Type: &ast.Identifier{
+						Token: tokens.Token{Offset: 10, Type: tokens.Identifier, Literal: "int"},
+						Value: "int",
+					},
+				}},
+				Body: &ast.BlockStatement{
+					Token:      tokens.Token{Offset: 15, Type: tokens.LBRACE, Literal: "{"},
+					Statements: []ast.Statement{},
+				},
+			},
+		},
+
+		"return": &ast.ReturnStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Return, Literal: "return"},
+		},
+
 		"return 42": &ast.ReturnStatement{
 			Token: tokens.Token{Offset: 0, Type: tokens.Return, Literal: "return"},
 			Value: &ast.IntegerLiteral{
 				Token: tokens.Token{Offset: 7, Type: tokens.Integer, Literal: "42"},
 				Value: 42,
+				Exact: constant.MakeInt64(42),
 			},
 		},
 
@@ -161,15 +566,18 @@ func TestParser(t *testing.T) {
 					Left: &ast.IntegerLiteral{
 						Token: tokens.Token{Offset: 4, Type: tokens.Integer, Literal: "6"},
 						Value: 6,
+						Exact: constant.MakeInt64(6),
 					},
 					Right: &ast.IntegerLiteral{
 						Token: tokens.Token{Offset: 8, Type: tokens.Integer, Literal: "9"},
 						Value: 9,
+						Exact: constant.MakeInt64(9),
 					},
 				},
 				Right: &ast.IntegerLiteral{
 					Token: tokens.Token{Offset: 13, Type: tokens.Integer, Literal: "42"},
 					Value: 42,
+					Exact: constant.MakeInt64(42),
 				},
 			},
 			Body: &ast.BlockStatement{
@@ -193,6 +601,14 @@ func TestParser(t *testing.T) {
 			},
 		},
 
+		"for {\n}": &ast.ForStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.For, Literal: "for"},
+			Body: &ast.BlockStatement{
+				Token:      tokens.Token{Offset: 4, Type: tokens.LBRACE, Literal: "{"},
+				Statements: []ast.Statement{},
+			},
+		},
+
 		"for i = 1; i <= 100; i++ {\n}": &ast.ForStatement{
 			Token: tokens.Token{Offset: 0, Type: tokens.For, Literal: "for"},
 			Init: &ast.AssignStatement{
@@ -204,6 +620,7 @@ func TestParser(t *testing.T) {
 				Value: &ast.IntegerLiteral{
 					Token: tokens.Token{Offset: 8, Type: tokens.Integer, Literal: "1"},
 					Value: 1,
+					Exact: constant.MakeInt64(1),
 				},
 			},
 			Cond: &ast.InfixExpression{
@@ -215,11 +632,12 @@ func TestParser(t *testing.T) {
 				Right: &ast.IntegerLiteral{
 					Token: tokens.Token{Offset: 16, Type: tokens.Integer, Literal: "100"},
 					Value: 100,
+					Exact: constant.MakeInt64(100),
 				},
 			},
 			Post: &ast.IncrementDecrementStatement{
 				Token: tokens.Token{Offset: 22, Type: tokens.Increment, Literal: "++"},
-				Name: &ast.Identifier{
+				X: &ast.Identifier{
 					Token: tokens.Token{Offset: 21, Type: tokens.Identifier, Literal: "i"},
 					Value: "i",
 				},
@@ -255,6 +673,121 @@ func TestParser(t *testing.T) {
 			Value: &ast.FloatLiteral{
 				Token: tokens.Token{Offset: 14, Type: tokens.Float, Literal: "3.4"},
 				Value: 3.4,
+				Exact: constant.MakeFromLiteral("3.4", token.FLOAT, 0),
+			},
+		},
+		`var s []int`: &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "s"},
+				Value: "s",
+			},
+			Type: &ast.SliceType{
+				Token: tokens.Token{Offset: 6, Type: tokens.LBRACK, Literal: "["},
+				Elem: &ast.Identifier{
+					Token: tokens.Token{Offset: 8, Type: tokens.Identifier, Literal: "int"},
+					Value: "int",
+				},
+			},
+		},
+		`var c chan<- int`: &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "c"},
+				Value: "c",
+			},
+			Type: &ast.ChanType{
+				Token: tokens.Token{Offset: 6, Type: tokens.Chan, Literal: "chan"},
+				Dir:   ast.SendOnly,
+				Elem: &ast.Identifier{
+					Token: tokens.Token{Offset: 13, Type: tokens.Identifier, Literal: "int"},
+					Value: "int",
+				},
+			},
+		},
+		`var c <-chan int`: &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "c"},
+				Value: "c",
+			},
+			Type: &ast.ChanType{
+				Token: tokens.Token{Offset: 6, Type: tokens.Arrow, Literal: "<-"},
+				Dir:   ast.RecvOnly,
+				Elem: &ast.Identifier{
+					Token: tokens.Token{Offset: 13, Type: tokens.Identifier, Literal: "int"},
+					Value: "int",
+				},
+			},
+		},
+		`var e interface{}`: &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "e"},
+				Value: "e",
+			},
+			Type: &ast.InterfaceType{
+				Token: tokens.Token{Offset: 6, Type: tokens.Interface, Literal: "interface"},
+			},
+		},
+		`var mycomplex = 1.5i`: &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Var, Literal: "var"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 4, Type: tokens.Identifier, Literal: "mycomplex"},
+				Value: "mycomplex",
+			},
+			Value: &ast.ImaginaryLiteral{
+				Token: tokens.Token{Offset: 16, Type: tokens.Imaginary, Literal: "1.5i"},
+				Value: 1.5i,
+				Exact: constant.MakeFromLiteral("1.5i", token.IMAG, 0),
+			},
+		},
+		"func add(a, b int, s string) int {\nreturn a + b;\n}": &ast.VarStatement{
+			Token: tokens.Token{Offset: 0, Type: tokens.Func, Literal: "func"},
+			Name: &ast.Identifier{
+				Token: tokens.Token{Offset: 5, Type: tokens.Identifier, Literal: "add"},
+				Value: "add",
+			},
+			Value: &ast.FunctionLiteral{
+				Token: tokens.Token{Offset: 0, Type: tokens.Func, Literal: "func"},
+				Parameters: []*ast.Identifier{
+					{Token: tokens.Token{Offset: 9, Type: tokens.Identifier, Literal: "a"}, Value: "a"},
+					{Token: tokens.Token{Offset: 12, Type: tokens.Identifier, Literal: "b"}, Value: "b"},
+					{Token: tokens.Token{Offset: 19, Type: tokens.Identifier, Literal: "s"}, Value: "s"},
+				},
+				Types: []ast.Expression{intType, intType, &ast.Identifier{
+					Token: tokens.Token{Offset: 21, Type: tokens.Identifier, Literal: "string"},
+					Value: "string",
+				}},
+				Results: []*ast.Result{{
+					Name: &ast.Identifier{
+						Token: tokens.Token{Offset: 29, Type: tokens.Identifier, Literal: "int"},
+						Value: "~r0",
+					},
+					Type: &ast.Identifier{
+						Token: tokens.Token{Offset: 29, Type: tokens.Identifier, Literal: "int"},
+						Value: "int",
+					},
+				}},
+				Body: &ast.BlockStatement{
+					Token: tokens.Token{Offset: 33, Type: tokens.LBRACE, Literal: "{"},
+					Statements: []ast.Statement{
+						&ast.ReturnStatement{
+							Token: tokens.Token{Offset: 35, Type: tokens.Return, Literal: "return"},
+							Value: &ast.InfixExpression{
+								Token: tokens.Token{Offset: 44, Type: tokens.Sum, Literal: "+"},
+								Left: &ast.Identifier{
+									Token: tokens.Token{Offset: 42, Type: tokens.Identifier, Literal: "a"},
+									Value: "a",
+								},
+								Right: &ast.Identifier{
+									Token: tokens.Token{Offset: 46, Type: tokens.Identifier, Literal: "b"},
+									Value: "b",
+								},
+							},
+						},
+					},
+				},
 			},
 		},
 		`myfloat += 2.0`: &ast.AssignStatement{
@@ -266,6 +799,7 @@ func TestParser(t *testing.T) {
 			Value: &ast.FloatLiteral{
 				Token: tokens.Token{Offset: 11, Type: tokens.Float, Literal: "2.0"},
 				Value: 2.0,
+				Exact: constant.MakeFromLiteral("2.0", token.FLOAT, 0),
 			},
 		},
 	} {
@@ -292,12 +826,68 @@ func TestParser(t *testing.T) {
 	}
 }
 
+func TestProgram(t *testing.T) {
+	input := "package util\n\nimport \"strings\"\n\nvar Sep = strings.Repeat(\"-\", 3)\n"
+	s, err := scanner.New(input, nil)
+	require.NoError(t, err)
+	p := New(s, &Config{
+		crashOnError: true,
+	})
+	program := p.ParseProgram()
+	require.NotNil(t, program)
+	assert.Equal(t, &ast.Identifier{
+		Token: tokens.Token{Offset: 8, Type: tokens.Identifier, Literal: "util"},
+		Value: "util",
+	}, program.Package)
+	require.Len(t, program.Statements, 2)
+	assert.Equal(t, "strings", program.Statements[0].(*ast.ImportStatement).Specs[0].LocalName())
+	assert.Equal(t, "package util;\nimport \"strings\";\nvar Sep = strings.Repeat(\"-\", 3);\n", program.String())
+}
+
+func TestGoSyntax(t *testing.T) {
+	input := "package main\n\nfunc main() {\n\tif ok {\n\t\tprintln(ok)\n\t}\n}\n"
+	s, err := scanner.New(input, nil)
+	require.NoError(t, err)
+	p := New(s, &Config{
+		crashOnError: true,
+	})
+	program := p.ParseProgram()
+	require.NotNil(t, program)
+	assert.Equal(t, "package main;\nfunc main() {\nif (ok) {\nprintln(ok);\n};\n};\n", program.String())
+}
+
 func TestErrors(t *testing.T) {
 	for input, errors := range map[string][]error{
 		`(`: {
 			&Error{Err: "no prefix parse function for EOF found (token [ 1: EOF ])"},
 			&Error{Err: "expected next token to be RPAREN, got [ 2: EOF ] instead"},
 		},
+		"x := 1\nimport \"fmt\"": {
+			&Error{Err: "imports must appear before other declarations"},
+		},
+		"import \"fmt\"\npackage main": {
+			&Error{Err: "package statement must be first"},
+		},
+		`import ""`: {
+			&Error{Err: `invalid import path: ""`},
+		},
+		`func(a, b int, c) {}`: {
+			&Error{Err: "mixed typed and untyped parameters"},
+			&Error{Err: "no prefix parse function for LBRACE found (token [ 18: LBRACE { ])"},
+			&Error{Err: "no prefix parse function for RBRACE found (token [ 19: RBRACE } ])"},
+		},
+		`a + b = 30`: {
+			&Error{Err: "cannot assign to a + b"},
+		},
+		`a[0] := 1`: {
+			&Error{Err: "non-name a[0] on left side of :="},
+		},
+		`v.x, ok = <-ch`: {
+			&Error{Err: "non-name v.x on left side of multi-value assignment"},
+		},
+		`x.1`: {
+			&Error{Err: "expected next token to be IDENTIFIER, got [ 2: INTEGER 1 ] instead"},
+		},
 	} {
 		t.Run(input, func(t *testing.T) {
 			gofuzz.AddDataToCorpus("parser", []byte(input))
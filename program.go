@@ -0,0 +1,124 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh
+
+import (
+	"errors"
+	"fmt"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/internal/compat"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+)
+
+// Program is a compiled Gosh program.
+// It is not modified by runs, so it can be run by many runtimes concurrently.
+type Program struct {
+	code *compiler.Program
+}
+
+// SyntaxError is returned for programs with syntax errors.
+type SyntaxError struct {
+	Errors []error
+}
+
+func (e *SyntaxError) Error() string {
+	switch len(e.Errors) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e.Errors[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e.Errors[0], len(e.Errors)-1)
+	}
+}
+
+// Compile compiles Gosh program src.
+// Syntax errors are returned as *SyntaxError. Other errors (like undefined names) depend on the runtime's globals,
+// so they are reported by Runtime when the program is run, like runtime errors.
+func Compile(src string) (*Program, error) {
+	program, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return compile(program)
+}
+
+// CompileGo compiles Go program src in compatibility mode.
+// It should be a `package main` source file with main function and only declarations at the top level.
+// When it is run, global variables are initialized in dependency order, like in Go; then init functions are called
+// in order of appearance, then main is called.
+// Standard library packages are imported like other modules; see Runtime.Import and package stdlib.
+//
+// Only Go features supported by Gosh can be used.
+func CompileGo(src string) (*Program, error) {
+	return compileFile(src, "", true)
+}
+
+// compileFile compiles program src read from the named file, optionally in Go compatibility mode.
+func compileFile(src, name string, goMode bool) (*Program, error) {
+	program, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	program.File = name
+	if goMode {
+		if err = compat.Program(program); err != nil {
+			return nil, &SyntaxError{Errors: []error{err}}
+		}
+	}
+	return compile(program)
+}
+
+// parse parses Gosh program src.
+func parse(src string) (*ast.Program, error) {
+	s, err := scanner.New(src, &scanner.Config{
+		SkipShebang: true,
+	})
+	if err != nil {
+		return nil, &SyntaxError{Errors: []error{err}}
+	}
+
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, &SyntaxError{Errors: errs}
+	}
+	return program, nil
+}
+
+// compile compiles parsed program.
+func compile(program *ast.Program) (*Program, error) {
+	code, err := compiler.Compile(program)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{code: code}, nil
+}
+
+// compileExpression compiles a program consisting of a single expression.
+func compileExpression(expr string) (*Program, error) {
+	program, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(program.Statements) != 1 {
+		return nil, errors.New("expected single expression")
+	}
+	if s, ok := program.Statements[0].(*ast.ExpressionStatement); !ok || s.Expression == nil {
+		return nil, fmt.Errorf("%s is not an expression", program.Statements[0])
+	}
+	return compile(program)
+}
+
+// check interfaces
+var (
+	_ error = (*SyntaxError)(nil)
+)
@@ -0,0 +1,13 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package resolver implements static resolution of Gosh identifiers.
+//
+// It runs after parsing and before execution, binds identifiers declared in blocks
+// to slots of block scopes, so that interpreter and virtual machine access them by index instead of by name,
+// and reports undefined names, unused variables and illegal redeclarations like Go compiler does.
+package resolver // import "gosh-lang.org/gosh/resolver"
@@ -0,0 +1,635 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Error is a resolver error.
+type Error struct {
+	Offset   int             // byte offset of the identifier
+	Position tokens.Position // position of Offset in the program's file; Line is 0 if unknown
+	Msg      string
+}
+
+func (e *Error) Error() string {
+	if e.Position.Line == 0 {
+		return fmt.Sprintf("%d: %s", e.Offset, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Position, e.Msg)
+}
+
+// block is a block being resolved.
+type block struct {
+	parent *block
+	names  map[string]*entity
+	slots  int  // number of entities bound to slots
+	top    bool // true for the program itself
+}
+
+// entity is a declared entity.
+type entity struct {
+	id       *ast.Identifier
+	slot     int
+	variable bool // true for variables which should be used
+	used     bool
+}
+
+// binding is an identifier bound to a slot.
+type binding struct {
+	id          *ast.Identifier
+	from        *block // block of the identifier
+	to          *block // block of the declaration; nil for entities looked up by name
+	slot        int
+	local       bool
+	global      bool
+	predeclared bool
+}
+
+// resolver resolves a program.
+type resolver struct {
+	program   *ast.Program
+	scope     *objects.Scope
+	block     *block
+	globals   map[string]bool // entities declared by the program itself and other files of its package
+	top       *block          // the program's block
+	topSlots  map[string]int  // slots of entities declared by the program itself
+	functions int             // depth of function literals
+	bindings  []*binding
+	entities  []*entity
+	uses      map[*ast.Identifier]*ast.Identifier // identifiers of declarations by uses
+	errors    []*Error
+}
+
+// Resolve binds identifiers of program to slots of block scopes, and sets the number of slots for blocks.
+// It also reports undefined names, unused variables and illegal redeclarations.
+//
+// The program itself is the outermost block. Its entities are bound to slots in the order of Globals,
+// so they can be also stored in the scope of the program by name, and they are not reported as unused.
+// Names that are not declared by the program are looked up in scope; if it is nil, they are not reported.
+// Function literals can use entities declared by the program later, and names listed in program.Globals,
+// declared by other files of the same package.
+//
+// Resolving already resolved program does not modify it, so that can be done concurrently.
+//
+// It returns errors sorted by offset.
+func Resolve(program *ast.Program, scope *objects.Scope) []*Error {
+	errs, _ := ResolveUses(program, scope)
+	return errs
+}
+
+// ResolveUses resolves program like Resolve. It also returns identifiers of declarations by identifiers
+// denoting entities declared in blocks of the program; other identifiers are not included.
+func ResolveUses(program *ast.Program, scope *objects.Scope) ([]*Error, map[*ast.Identifier]*ast.Identifier) {
+	r := &resolver{
+		program:  program,
+		scope:    scope,
+		globals:  make(map[string]bool),
+		topSlots: make(map[string]int),
+		uses:     make(map[*ast.Identifier]*ast.Identifier),
+	}
+	for _, name := range program.Globals {
+		r.globals[name] = true
+	}
+
+	r.push()
+	r.top = r.block
+	r.top.top = true
+	for _, name := range Globals(program) {
+		r.globals[name] = true
+		r.topSlots[name] = r.top.slots
+		r.top.slots++
+	}
+	r.statements(program.Statements)
+	set(&program.Slots, r.pop())
+
+	for _, b := range r.bindings {
+		// only blocks with slots have scopes; other identifiers are looked up from the program's scope
+		var depth, slot int
+		for bl := b.from; bl != b.to; bl = bl.parent {
+			if bl.slots > 0 {
+				depth++
+			}
+		}
+		if b.local || b.global {
+			slot = b.slot
+		}
+
+		if b.id.Local != b.local {
+			b.id.Local = b.local
+		}
+		if b.id.Global != b.global {
+			b.id.Global = b.global
+		}
+		if b.id.Predeclared != b.predeclared {
+			b.id.Predeclared = b.predeclared
+		}
+		set(&b.id.Depth, depth)
+		set(&b.id.Slot, slot)
+	}
+
+	for _, e := range r.entities {
+		if e.variable && !e.used {
+			r.errorf(e.id, "declared and not used: %s", e.id.Value)
+		}
+	}
+
+	sort.SliceStable(r.errors, func(i, j int) bool { return r.errors[i].Offset < r.errors[j].Offset })
+	return r.errors, r.uses
+}
+
+// Globals returns names of entities declared by global statements of program, in order of first declaration.
+func Globals(program *ast.Program) []string {
+	var res []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "_" && !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+
+	for _, s := range program.Statements {
+		switch s := s.(type) {
+		case *ast.ImportStatement:
+			for _, spec := range s.Specs {
+				add(spec.LocalName())
+			}
+		case *ast.VarStatement:
+			add(s.Name.Value)
+		case *ast.TypeStatement:
+			add(s.Name.Value)
+		case *ast.AssignStatement:
+			if s.Token.Type == tokens.Define {
+				add(s.Name.(*ast.Identifier).Value)
+				for _, id := range s.Rest {
+					add(id.Value)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// errorf adds an error at identifier id.
+func (r *resolver) errorf(id *ast.Identifier, format string, a ...interface{}) {
+	r.errors = append(r.errors, &Error{
+		Offset:   id.Token.Offset,
+		Position: r.program.Lines.Position(r.program.File, id.Token.Offset),
+		Msg:      fmt.Sprintf(format, a...),
+	})
+}
+
+// push starts a new block.
+func (r *resolver) push() {
+	r.block = &block{
+		parent: r.block,
+		names:  make(map[string]*entity),
+	}
+}
+
+// set sets resolved value *p to v.
+// Values are written only if they are changed, so already resolved programs can be resolved concurrently.
+func set(p *int, v int) {
+	if *p != v {
+		*p = v
+	}
+}
+
+// pop ends the current block and returns the number of its slots.
+func (r *resolver) pop() int {
+	b := r.block
+	r.block = b.parent
+	return b.slots
+}
+
+// declare declares entity id in the current block. Blank identifier is not declared.
+func (r *resolver) declare(id *ast.Identifier, variable bool) {
+	bl := r.block
+	b := &binding{id: id}
+	r.bindings = append(r.bindings, b)
+	if id.Value == "_" {
+		return
+	}
+
+	if _, ok := bl.names[id.Value]; ok {
+		r.errorf(id, "%s redeclared in this block", id.Value)
+		return
+	}
+
+	e := &entity{id: id}
+	bl.names[id.Value] = e
+	if bl.top {
+		e.slot = r.topSlots[id.Value]
+		b.from, b.to, b.slot, b.global = bl, bl, e.slot, true
+		return
+	}
+
+	e.slot = bl.slots
+	e.variable = variable
+	bl.slots++
+	r.entities = append(r.entities, e)
+	b.from, b.to, b.slot, b.local = bl, bl, e.slot, true
+}
+
+// declared returns true if name is declared in the current block.
+func (r *resolver) declared(name string) bool {
+	_, ok := r.block.names[name]
+	return ok
+}
+
+// resolve binds identifier id to the declared entity. If use is true, it is a use of variable.
+func (r *resolver) resolve(id *ast.Identifier, use bool) {
+	b := &binding{id: id, from: r.block}
+	r.bindings = append(r.bindings, b)
+	if id.Value == "_" {
+		if use {
+			r.errorf(id, "cannot use _ as value")
+		}
+		return
+	}
+
+	for bl := r.block; bl != nil; bl = bl.parent {
+		e, ok := bl.names[id.Value]
+		if !ok {
+			continue
+		}
+		if use {
+			e.used = true
+		}
+		r.uses[id] = e.id
+		b.from, b.to, b.slot = r.block, bl, e.slot
+		if bl.top {
+			b.global = true
+		} else {
+			b.local = true
+		}
+		return
+	}
+
+	// function literals can use entities declared by the program later, like Go functions use package-level ones
+	if r.functions > 0 {
+		if slot, ok := r.topSlots[id.Value]; ok {
+			b.to, b.slot, b.global = r.top, slot, true
+			return
+		}
+		if r.globals[id.Value] {
+			return
+		}
+	}
+	if r.scope == nil {
+		b.predeclared = !r.globals[id.Value]
+		return
+	}
+	obj, ok := r.scope.Lookup(id.Value)
+	if !ok {
+		r.errorf(id, "undefined: %s", id.Value)
+		return
+	}
+
+	// predeclared entities are in the outermost scope
+	universe := r.scope
+	for universe.Outer() != nil {
+		universe = universe.Outer()
+	}
+	u, ok := universe.LookupLocal(id.Value)
+	b.predeclared = ok && u == obj && !r.globals[id.Value]
+}
+
+func (r *resolver) statements(statements []ast.Statement) {
+	for _, s := range statements {
+		r.statement(s)
+	}
+}
+
+// blockStatement resolves block statement as a new block.
+func (r *resolver) blockStatement(node *ast.BlockStatement) {
+	r.push()
+	r.statements(node.Statements)
+	set(&node.Slots, r.pop())
+}
+
+//nolint:gocyclo
+func (r *resolver) statement(node ast.Statement) {
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		r.blockStatement(node)
+
+	case *ast.ExpressionStatement:
+		if node.Expression != nil {
+			r.expression(node.Expression)
+		}
+
+	case *ast.ReturnStatement:
+		if node.Value != nil {
+			r.expression(node.Value)
+		}
+
+	case *ast.DeferStatement:
+		r.expression(node.Call)
+
+	case *ast.GoStatement:
+		r.expression(node.Call)
+
+	case *ast.SendStatement:
+		r.expression(node.Channel)
+		r.expression(node.Value)
+
+	case *ast.SelectStatement:
+		r.selectStatement(node)
+
+	case *ast.VarStatement:
+		if node.Type != nil {
+			r.expression(node.Type)
+		}
+		r.define(node.Name, node.Value)
+
+	case *ast.TypeStatement:
+		r.expression(node.Type)
+		r.declare(node.Name, false)
+
+	case *ast.ImportStatement:
+		for _, spec := range node.Specs {
+			r.declare(&ast.Identifier{Token: spec.Path.Token, Value: spec.LocalName()}, false)
+		}
+
+	case *ast.AssignStatement:
+		r.assignStatement(node)
+
+	case *ast.ForStatement:
+		// variables declared by init statement are in the implicit block of for statement
+		r.push()
+		if node.Init != nil {
+			r.statement(node.Init)
+		}
+		if node.Cond != nil {
+			r.expression(node.Cond)
+		}
+		r.blockStatement(node.Body)
+		if node.Post != nil {
+			r.statement(node.Post)
+		}
+		set(&node.Slots, r.pop())
+
+	case *ast.RangeStatement:
+		r.expression(node.X)
+		if !node.Define {
+			for _, id := range []*ast.Identifier{node.Key, node.Value} {
+				if id != nil {
+					r.resolve(id, false)
+				}
+			}
+			r.blockStatement(node.Body)
+			break
+		}
+
+		// each iteration has its own variables
+		r.push()
+		for _, id := range []*ast.Identifier{node.Key, node.Value} {
+			if id != nil {
+				r.declare(id, true)
+			}
+		}
+		r.blockStatement(node.Body)
+		set(&node.Slots, r.pop())
+
+	case *ast.IfStatement:
+		r.expression(node.Cond)
+		r.blockStatement(node.Body)
+
+	case *ast.IncrementDecrementStatement:
+		if id, ok := node.X.(*ast.Identifier); ok {
+			r.update(id)
+			break
+		}
+		r.expression(node.X)
+
+	case *ast.ContinueStatement:
+		// nothing
+	}
+}
+
+// define declares variable id with the initial value exp (that may be nil).
+func (r *resolver) define(id *ast.Identifier, exp ast.Expression) {
+	// function literal assigned to a variable can call itself
+	if _, ok := exp.(*ast.FunctionLiteral); ok {
+		r.declare(id, true)
+		r.expression(exp)
+		return
+	}
+
+	if exp != nil {
+		r.expression(exp)
+	}
+	r.declare(id, true)
+}
+
+func (r *resolver) assignStatement(node *ast.AssignStatement) {
+	if node.Token.Type != tokens.Define {
+		r.expression(node.Value)
+		r.assigned(node.Name, node.Token.Type != tokens.Assignment)
+		for _, id := range node.Rest {
+			r.assigned(id, false)
+		}
+		return
+	}
+
+	if node.Rest == nil {
+		name := node.Name.(*ast.Identifier)
+		if name.Value == "_" || r.declared(name.Value) {
+			r.expression(node.Value)
+			r.errorf(name, "no new variables on left side of :=")
+			return
+		}
+		r.define(name, node.Value)
+		return
+	}
+
+	r.expression(node.Value)
+	r.multiVariables(node)
+}
+
+// assigned resolves assignment target x: a variable, or operands of index or selector expression.
+// If update is true, the variable is updated by x op= y statement.
+func (r *resolver) assigned(x ast.Expression, update bool) {
+	id, ok := x.(*ast.Identifier)
+	switch {
+	case !ok:
+		r.expression(x)
+	case update:
+		r.update(id)
+	default:
+		r.resolve(id, false)
+	}
+}
+
+// update resolves variable id updated by x op= y or x++ statement.
+// Like in Go, that is not a use of x, but it should have a value, so blank identifier can't be updated.
+func (r *resolver) update(id *ast.Identifier) {
+	if id.Value == "_" {
+		r.resolve(id, true)
+		return
+	}
+	r.resolve(id, false)
+}
+
+// multiVariables declares or resolves variables of multi-value assignment (e.g. `v, ok := <-ch`).
+// := redeclares variables declared in the same block.
+func (r *resolver) multiVariables(node *ast.AssignStatement) {
+	if node.Token.Type != tokens.Define {
+		r.assigned(node.Name, false)
+		for _, id := range node.Rest {
+			r.assigned(id, false)
+		}
+		return
+	}
+
+	name := node.Name.(*ast.Identifier)
+	names := append([]*ast.Identifier{name}, node.Rest...)
+
+	var declared bool
+	for _, id := range names {
+		if id.Value == "_" || r.declared(id.Value) {
+			r.resolve(id, false)
+			continue
+		}
+		r.declare(id, true)
+		declared = true
+	}
+	if !declared {
+		r.errorf(name, "no new variables on left side of :=")
+	}
+}
+
+func (r *resolver) selectStatement(node *ast.SelectStatement) {
+	// all channels and sent values are evaluated in the outer block
+	for _, c := range node.Cases {
+		switch comm := c.Comm.(type) {
+		case *ast.SendStatement:
+			r.expression(comm.Channel)
+			r.expression(comm.Value)
+		case *ast.ExpressionStatement:
+			if comm.Expression != nil {
+				r.expression(comm.Expression)
+			}
+		case *ast.AssignStatement:
+			r.expression(comm.Value)
+		}
+	}
+
+	// each clause is an implicit block
+	for _, c := range node.Cases {
+		r.push()
+		if a, ok := c.Comm.(*ast.AssignStatement); ok {
+			r.multiVariables(a)
+		}
+		r.statements(c.Body)
+		set(&c.Slots, r.pop())
+	}
+}
+
+func (r *resolver) function(node *ast.FunctionLiteral) {
+	// types of parameters and named results are evaluated in the outer block;
+	// grouped parameters share the type
+	for i, t := range node.Types {
+		if i == 0 || t != node.Types[i-1] {
+			r.expression(t)
+		}
+	}
+	for _, res := range node.Results {
+		if res.Type != nil {
+			r.expression(res.Type)
+		}
+	}
+
+	// parameters, named results and function body are in the same block
+	r.functions++
+	r.push()
+	for _, p := range node.Parameters {
+		r.declare(p, false)
+	}
+	for _, res := range node.Results {
+		r.declare(res.Name, false)
+	}
+	r.statements(node.Body.Statements)
+	set(&node.Body.Slots, r.pop())
+	r.functions--
+}
+
+//nolint:gocyclo
+func (r *resolver) expression(node ast.Expression) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		r.resolve(node, true)
+
+	case *ast.Constant:
+		// identifiers of the original expression are used
+		r.expression(node.X)
+
+	case *ast.PrefixExpression:
+		r.expression(node.Right)
+
+	case *ast.InfixExpression:
+		r.expression(node.Left)
+		r.expression(node.Right)
+
+	case *ast.IndexExpression:
+		r.expression(node.Left)
+		r.expression(node.Index)
+
+	case *ast.SliceExpression:
+		r.expression(node.Left)
+		if node.Low != nil {
+			r.expression(node.Low)
+		}
+		if node.High != nil {
+			r.expression(node.High)
+		}
+
+	case *ast.SelectorExpression:
+		// selected names are members of modules
+		r.expression(node.X)
+
+	case *ast.CallExpression:
+		r.expression(node.Function)
+		for _, a := range node.Arguments {
+			r.expression(a)
+		}
+
+	case *ast.FunctionLiteral:
+		r.function(node)
+
+	case *ast.SliceType:
+		r.expression(node.Elem)
+	case *ast.MapType:
+		r.expression(node.Key)
+		r.expression(node.Value)
+	case *ast.PointerType:
+		r.expression(node.Elem)
+	case *ast.ChanType:
+		r.expression(node.Elem)
+	case *ast.FuncType:
+		for _, p := range node.Params {
+			r.expression(p)
+		}
+		for _, res := range node.Results {
+			r.expression(res)
+		}
+	}
+}
+
+// check interfaces
+var (
+	_ error = (*Error)(nil)
+)
@@ -0,0 +1,150 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package resolver
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	s, err := scanner.New(input, nil)
+	require.NoError(t, err)
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	require.Nil(t, p.Errors())
+	return program
+}
+
+func TestResolve(t *testing.T) {
+	input := `var g = 1; var f = func(a) { var b = a; for i := 0; i < b; i++ { if (true) { var c = i; println(c, g, h) } } }; var h = 2`
+	program := parse(t, input)
+	require.Nil(t, Resolve(program, objects.Builtin(ioutil.Discard)))
+
+	f := program.Statements[1].(*ast.VarStatement).Value.(*ast.FunctionLiteral)
+	assert.Equal(t, 2, f.Body.Slots) // a, b
+	assert.Equal(t, &ast.Identifier{Token: f.Parameters[0].Token, Value: "a", Local: true}, f.Parameters[0])
+
+	loop := f.Body.Statements[1].(*ast.ForStatement)
+	assert.Equal(t, 1, loop.Slots)      // i
+	assert.Equal(t, 0, loop.Body.Slots) // body without declarations has no scope
+
+	// condition is in the scope of for statement, and b is one level up
+	cond := loop.Cond.(*ast.InfixExpression)
+	assert.Equal(t, [3]interface{}{true, 0, 0}, bound(cond.Left))
+	assert.Equal(t, [3]interface{}{true, 1, 1}, bound(cond.Right))
+
+	block := loop.Body.Statements[0].(*ast.IfStatement).Body
+	assert.Equal(t, 1, block.Slots) // c
+	call := block.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	args := call.Arguments
+	assert.Equal(t, [3]interface{}{true, 0, 0}, bound(args[0]))        // c
+	assert.Equal(t, [3]interface{}{false, 3, 0}, bound(args[1]))       // g is in the program's scope
+	assert.Equal(t, [3]interface{}{false, 3, 2}, bound(args[2]))       // h is declared later by the program
+	assert.Equal(t, [3]interface{}{false, 4, 0}, bound(call.Function)) // println is predeclared and looked up by name
+	assert.True(t, args[1].(*ast.Identifier).Global)
+	assert.True(t, args[2].(*ast.Identifier).Global)
+	assert.False(t, call.Function.(*ast.Identifier).Global)
+	assert.Equal(t, 3, program.Slots) // g, f, h
+
+	// resolving again gives the same result
+	require.Nil(t, Resolve(program, nil))
+	assert.Equal(t, [3]interface{}{true, 1, 1}, bound(cond.Right))
+}
+
+func TestResolvePredeclared(t *testing.T) {
+	input := `println(cap("a")); var f = func(cap) { println(cap) }; var complex = 1; println(complex)`
+	program := parse(t, input)
+	builtin := objects.Builtin(ioutil.Discard)
+	require.Nil(t, Resolve(program, builtin))
+
+	call := func(n int) *ast.CallExpression {
+		return program.Statements[n].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	}
+	assert.True(t, call(0).Function.(*ast.Identifier).Predeclared)
+	assert.True(t, call(0).Arguments[0].(*ast.CallExpression).Function.(*ast.Identifier).Predeclared)
+	f := program.Statements[1].(*ast.VarStatement).Value.(*ast.FunctionLiteral)
+	arg := f.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[0]
+	assert.False(t, arg.(*ast.Identifier).Predeclared)                  // parameter
+	assert.False(t, call(3).Arguments[0].(*ast.Identifier).Predeclared) // declared by the program
+
+	// entity declared in the scope by previous program shadows predeclared one
+	scope := objects.NewScope(builtin)
+	require.True(t, scope.Define("cap", &objects.Integer{Value: 1}))
+	require.Nil(t, Resolve(program, scope))
+	assert.False(t, call(0).Arguments[0].(*ast.CallExpression).Function.(*ast.Identifier).Predeclared)
+	assert.True(t, call(0).Function.(*ast.Identifier).Predeclared)
+}
+
+func TestResolveUses(t *testing.T) {
+	input := `var x int8; var f = func(x) { x = 1 }; x = 2; println(y); var y = 3`
+	program := parse(t, input)
+	errs, uses := ResolveUses(program, objects.Builtin(ioutil.Discard))
+	require.Equal(t, "1:55: undefined: y", errs[0].Error())
+
+	decl := program.Statements[0].(*ast.VarStatement).Name
+	f := program.Statements[1].(*ast.VarStatement).Value.(*ast.FunctionLiteral)
+	inner := f.Body.Statements[0].(*ast.AssignStatement).Name.(*ast.Identifier)
+	outer := program.Statements[2].(*ast.AssignStatement).Name.(*ast.Identifier)
+	assert.Equal(t, f.Parameters[0], uses[inner]) // parameter shadows variable
+	assert.Equal(t, decl, uses[outer])
+	assert.Len(t, uses, 2) // predeclared and undefined entities are not included
+}
+
+// bound returns Local, Depth and Slot of identifier.
+func bound(exp ast.Expression) [3]interface{} {
+	id := exp.(*ast.Identifier)
+	return [3]interface{}{id.Local, id.Depth, id.Slot}
+}
+
+func TestResolveErrors(t *testing.T) {
+	for input, expected := range map[string][]string{
+		`println(x)`:            {"1:9: undefined: x"},
+		`println(x); var x = 1`: {"1:9: undefined: x"},
+		`var f = func() { println(x) }; var x = 1`:                        nil,
+		`var f = func() { var x = 1; var x = 2; _ = x }`:                  {"1:33: x redeclared in this block"},
+		`var f = func(a) { a := 1 }`:                                      {"1:19: no new variables on left side of :="},
+		`var f = func() { _ := 1 }`:                                       {"1:18: no new variables on left side of :="},
+		`var f = func() { var x = 1; x = 2 }`:                             {"1:22: declared and not used: x"},
+		`var f = func() { var x = 1; x++ }`:                               {"1:22: declared and not used: x"},
+		`var f = func() { var x = 1; x += 2 }`:                            {"1:22: declared and not used: x"},
+		`var f = func() { var x = 1; x++; println(x) }`:                   nil,
+		`var f = func() { var a = make([]int, 1); a[0] = 2 }`:             nil,
+		`var f = func() { var a = make([]int, 1); var i = 0; a[i] += 2 }`: nil,
+		`var f = func() { a[0] = 1 }`:                                     {"1:18: undefined: a"},
+		`var f = func() { for _, r := range "ab" {} }`:                    {"1:25: declared and not used: r"},
+		`var f = func(a) { var b = _ }`:                                   {"1:23: declared and not used: b", "1:27: cannot use _ as value"},
+		`var f = func() { if (true) { var y = 1; _ = y }; println(y) }`:   {"1:58: undefined: y"},
+		`var f = func(ch) { select { case v := <-ch: } }`:                 {"1:34: declared and not used: v"},
+		`var f = func(ch) { v, ok := <-ch; println(v, ok); v, ok := <-ch }`: {
+			"1:51: no new variables on left side of :=",
+		},
+		`var f = func() { var g = func() { g() }; g() }`: nil,
+		`var x = 1; var x = 2`:                           {"1:16: x redeclared in this block"},
+		`import "lib/strings"; println(strings.X, y.Z)`:  {"1:42: undefined: y"},
+		`import "a"; var a = 1`:                          {"1:17: a redeclared in this block"},
+	} {
+		t.Run(input, func(t *testing.T) {
+			var actual []string
+			for _, e := range Resolve(parse(t, input), objects.Builtin(ioutil.Discard)) {
+				actual = append(actual, e.Error())
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
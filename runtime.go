@@ -0,0 +1,237 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gosh
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/vm"
+)
+
+// Runtime runs Gosh programs in its own global scope: globals defined by one program are available to the next ones.
+// Runtimes are isolated from each other.
+//
+// Runtime should not be used concurrently. Go functions called by programs can get it with FromContext,
+// and use its standard streams, arguments, environment variables, filesystem and modules.
+type Runtime struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	args    []string
+	env     []string
+	fsys    fs.FS
+	config  *interpreter.Config
+	modules map[string]*objects.GoPackage
+	globals map[string]interface{}
+	scope   *objects.Scope
+
+	modulePath []string                   // directories searched for Gosh modules
+	imported   map[string]*objects.Module // imported modules by Go import path or Gosh module file name
+	loading    []string                   // file names of Gosh modules being loaded, for cycle detection
+}
+
+// Option configures Runtime.
+type Option func(*Runtime)
+
+// WithStdin sets standard input; by default, it is empty.
+func WithStdin(r io.Reader) Option {
+	return func(rt *Runtime) { rt.stdin = r }
+}
+
+// WithStdout sets standard output used by print and println builtins; by default, output is discarded.
+func WithStdout(w io.Writer) Option {
+	return func(rt *Runtime) { rt.stdout = w }
+}
+
+// WithStderr sets standard error output; by default, output is discarded.
+func WithStderr(w io.Writer) Option {
+	return func(rt *Runtime) { rt.stderr = w }
+}
+
+// WithArgs sets program arguments.
+func WithArgs(args ...string) Option {
+	return func(rt *Runtime) { rt.args = args }
+}
+
+// WithEnv sets environment variables in "key=value" form, like os.Environ returns.
+func WithEnv(env []string) Option {
+	return func(rt *Runtime) { rt.env = env }
+}
+
+// WithFS sets filesystem available to programs.
+func WithFS(fsys fs.FS) Option {
+	return func(rt *Runtime) { rt.fsys = fsys }
+}
+
+// WithGlobals defines global variables; values are converted like Set does.
+func WithGlobals(globals map[string]interface{}) Option {
+	return func(rt *Runtime) {
+		for name, v := range globals {
+			rt.globals[name] = v
+		}
+	}
+}
+
+// WithLimits sets limits for each run; see interpreter.Config.
+// If config has no Importer, runtime imports modules itself; see Runtime.Import.
+func WithLimits(config *interpreter.Config) Option {
+	return func(rt *Runtime) { rt.config = config }
+}
+
+// WithModules adds modules (for example, host packages that are not registered with objects.RegisterGoPackage).
+// They take precedence over built-in package fmt and registered Go packages with the same import paths.
+func WithModules(modules ...*objects.GoPackage) Option {
+	return func(rt *Runtime) {
+		for _, m := range modules {
+			rt.modules[m.Path] = m
+		}
+	}
+}
+
+// New creates a new runtime with given options.
+func New(opts ...Option) *Runtime {
+	rt := &Runtime{
+		stdin:    strings.NewReader(""),
+		stdout:   ioutil.Discard,
+		stderr:   ioutil.Discard,
+		modules:  make(map[string]*objects.GoPackage),
+		globals:  make(map[string]interface{}),
+		imported: make(map[string]*objects.Module),
+	}
+	for _, o := range opts {
+		o(rt)
+	}
+	if rt.modules["fmt"] == nil {
+		rt.modules["fmt"] = fmtPackage(rt.stdout)
+	}
+
+	var config interpreter.Config
+	if rt.config != nil {
+		config = *rt.config
+	}
+	if config.Importer == nil {
+		config.Importer = rt
+	}
+	rt.config = &config
+
+	rt.scope = objects.NewScope(objects.Builtin(rt.stdout))
+	for name, v := range rt.globals {
+		rt.Set(name, v)
+	}
+	rt.globals = nil
+	return rt
+}
+
+// Run compiles and runs Gosh program src.
+// Syntax errors are returned as *SyntaxError, runtime errors as *interpreter.RuntimeError.
+// Program is stopped when ctx is done; in that case ctx.Err() is returned.
+func (rt *Runtime) Run(ctx context.Context, src string) error {
+	p, err := Compile(src)
+	if err != nil {
+		return err
+	}
+	return rt.RunProgram(ctx, p)
+}
+
+// RunProgram runs compiled program like Run does.
+func (rt *Runtime) RunProgram(ctx context.Context, p *Program) error {
+	_, err := rt.run(ctx, p)
+	return err
+}
+
+// Eval evaluates a single expression expr and returns its value.
+// The expression can use globals defined by previous programs.
+func (rt *Runtime) Eval(ctx context.Context, expr string) (objects.Object, error) {
+	p, err := compileExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	return rt.run(ctx, p)
+}
+
+// runtimeKey is a context key for Runtime.
+type runtimeKey struct{}
+
+// run runs compiled program in the runtime's global scope.
+func (rt *Runtime) run(ctx context.Context, p *Program) (objects.Object, error) {
+	ctx = context.WithValue(ctx, runtimeKey{}, rt)
+	return vm.New(rt.config).Run(ctx, p.code, rt.scope)
+}
+
+// FromContext returns runtime running Gosh program, or nil.
+// Go functions called by that program should use the context passed to them.
+func FromContext(ctx context.Context) *Runtime {
+	rt, _ := ctx.Value(runtimeKey{}).(*Runtime)
+	return rt
+}
+
+// Set defines or assigns global variable.
+// Gosh objects are used as is; Go values are converted with objects.FromGo,
+// so Go functions can be called by Gosh programs.
+func (rt *Runtime) Set(name string, v interface{}) {
+	obj, ok := v.(objects.Object)
+	if !ok {
+		obj = objects.FromGo(reflect.ValueOf(v))
+	}
+
+	// predeclared entities in the outer scope are shadowed, not assigned
+	if !rt.scope.Define(name, obj) {
+		rt.scope.Assign(name, obj)
+	}
+}
+
+// Get returns the value of global variable or predeclared entity, and true if it exists.
+func (rt *Runtime) Get(name string) (objects.Object, bool) {
+	return rt.scope.Lookup(name)
+}
+
+// Stdin returns standard input.
+func (rt *Runtime) Stdin() io.Reader { return rt.stdin }
+
+// Stdout returns standard output.
+func (rt *Runtime) Stdout() io.Writer { return rt.stdout }
+
+// Stderr returns standard error output.
+func (rt *Runtime) Stderr() io.Writer { return rt.stderr }
+
+// Args returns program arguments.
+func (rt *Runtime) Args() []string { return rt.args }
+
+// Environ returns environment variables in "key=value" form.
+func (rt *Runtime) Environ() []string { return rt.env }
+
+// LookupEnv returns the value of environment variable, and true if it is set.
+func (rt *Runtime) LookupEnv(key string) (string, bool) {
+	// like exec.Cmd.Env, the last value wins
+	for n := len(rt.env) - 1; n >= 0; n-- {
+		if k, v, ok := strings.Cut(rt.env[n], "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// FS returns filesystem, or nil.
+func (rt *Runtime) FS() fs.FS { return rt.fsys }
+
+// Module returns module with given import path: one added with WithModules, built-in package fmt
+// that prints to the runtime's standard output, or registered Go package.
+// It returns nil if there is no such module.
+func (rt *Runtime) Module(path string) *objects.GoPackage {
+	if m := rt.modules[path]; m != nil {
+		return m
+	}
+	return objects.LookupGoPackage(path)
+}
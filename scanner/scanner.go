@@ -17,6 +17,7 @@ import (
 type Scanner struct {
 	config *Config
 	input  []rune
+	lines  tokens.Lines
 
 	rPos            int  // current rune position in input
 	r               rune // current rune; the same as input[rPos]
@@ -55,6 +56,7 @@ var keywords = map[string]tokens.Type{
 	"select":      tokens.Select,
 	"struct":      tokens.Struct,
 	"switch":      tokens.Switch,
+	"type":        tokens.TypeKeyword,
 	"var":         tokens.Var,
 
 	// TODO remove - those are not keywords
@@ -80,12 +82,18 @@ func New(input string, config *Config) (*Scanner, error) {
 	l := &Scanner{
 		config: config,
 		input:  runes,
+		lines:  tokens.NewLines(runes),
 		rPos:   -1,
 	}
 	l.readRune()
 	return l, nil
 }
 
+// Lines returns line offsets of the input.
+func (s *Scanner) Lines() tokens.Lines {
+	return s.lines
+}
+
 func isLetter(r rune) bool {
 	switch {
 	case 'a' <= r && r <= 'z':
@@ -155,7 +163,7 @@ func (s *Scanner) readInt() (string, bool) {
 		s.readRune()
 	}
 	ok := true
-	if isLetter(s.r) {
+	if isLetter(s.r) && !s.isImaginarySuffix() && !s.isExponent() {
 		ok = false
 		for isLetter(s.r) || isDigit(s.r) {
 			s.readRune()
@@ -164,11 +172,33 @@ func (s *Scanner) readInt() (string, bool) {
 	return string(s.input[pos:s.rPos]), ok
 }
 
+// isImaginarySuffix returns true if current rune is a suffix of imaginary literal (e.g. `i` in `42i`).
+func (s *Scanner) isImaginarySuffix() bool {
+	peek := s.peekRune()
+	return s.r == 'i' && !isLetter(peek) && !isDigit(peek)
+}
+
+// isExponent returns true if current rune starts an exponent of float literal (e.g. `e3` in `1e3` or `E-3` in `1E-3`).
+func (s *Scanner) isExponent() bool {
+	if s.r != 'e' && s.r != 'E' {
+		return false
+	}
+	pos := s.rPos + 1
+	if pos < len(s.input) && (s.input[pos] == '+' || s.input[pos] == '-') {
+		pos++
+	}
+	return pos < len(s.input) && isDigit(s.input[pos])
+}
+
 func (s *Scanner) readString() (string, bool) {
 	pos := s.rPos
 	for {
 		s.readRune()
-		if s.r == '"' || s.r == 0 {
+		if s.r == '\\' {
+			s.readRune()
+			continue
+		}
+		if s.r == '"' || s.r == '\n' || s.r == 0 {
 			break
 		}
 	}
@@ -179,6 +209,25 @@ func (s *Scanner) readString() (string, bool) {
 	return string(s.input[pos:s.rPos]), ok
 }
 
+func (s *Scanner) readRuneLiteral() (string, bool) {
+	pos := s.rPos
+	for {
+		s.readRune()
+		if s.r == '\\' {
+			s.readRune()
+			continue
+		}
+		if s.r == '\'' || s.r == '\n' || s.r == 0 {
+			break
+		}
+	}
+	ok := s.r == '\''
+	if ok {
+		s.readRune()
+	}
+	return string(s.input[pos:s.rPos]), ok
+}
+
 func (s *Scanner) readIdentifier() string {
 	pos := s.rPos
 	for isLetter(s.r) || isDigit(s.r) {
@@ -322,6 +371,21 @@ func (s *Scanner) NextToken() tokens.Token {
 			s.readRune()
 			tok.Type = tokens.LogicalAnd
 			tok.Literal = "&&"
+		case '=':
+			s.readRune()
+			tok.Type = tokens.BitwiseAndAssignment
+			tok.Literal = "&="
+		case '^':
+			s.readRune()
+			switch s.peekRune() {
+			case '=':
+				s.readRune()
+				tok.Type = tokens.BitwiseAndNotAssignment
+				tok.Literal = "&^="
+			default:
+				tok.Type = tokens.BitwiseAndNot
+				tok.Literal = "&^"
+			}
 		default:
 			tok.Type = tokens.BitwiseAnd
 			tok.Literal = "&"
@@ -332,13 +396,24 @@ func (s *Scanner) NextToken() tokens.Token {
 			s.readRune()
 			tok.Type = tokens.LogicalOr
 			tok.Literal = "||"
+		case '=':
+			s.readRune()
+			tok.Type = tokens.BitwiseOrAssignment
+			tok.Literal = "|="
 		default:
 			tok.Type = tokens.BitwiseOr
 			tok.Literal = "|"
 		}
 	case '^':
-		tok.Type = tokens.BitwiseXor
-		tok.Literal = "^"
+		switch s.peekRune() {
+		case '=':
+			s.readRune()
+			tok.Type = tokens.BitwiseXorAssignment
+			tok.Literal = "^="
+		default:
+			tok.Type = tokens.BitwiseXor
+			tok.Literal = "^"
+		}
 
 	case '!':
 		switch s.peekRune() {
@@ -353,16 +428,42 @@ func (s *Scanner) NextToken() tokens.Token {
 
 	case '<':
 		switch s.peekRune() {
+		case '<':
+			s.readRune()
+			switch s.peekRune() {
+			case '=':
+				s.readRune()
+				tok.Type = tokens.ShiftLeftAssignment
+				tok.Literal = "<<="
+			default:
+				tok.Type = tokens.ShiftLeft
+				tok.Literal = "<<"
+			}
 		case '=':
 			s.readRune()
 			tok.Type = tokens.LessOrEqual
 			tok.Literal = "<="
+		case '-':
+			s.readRune()
+			tok.Type = tokens.Arrow
+			tok.Literal = "<-"
 		default:
 			tok.Type = tokens.Less
 			tok.Literal = "<"
 		}
 	case '>':
 		switch s.peekRune() {
+		case '>':
+			s.readRune()
+			switch s.peekRune() {
+			case '=':
+				s.readRune()
+				tok.Type = tokens.ShiftRightAssignment
+				tok.Literal = ">>="
+			default:
+				tok.Type = tokens.ShiftRight
+				tok.Literal = ">>"
+			}
 		case '=':
 			s.readRune()
 			tok.Type = tokens.GreaterOrEqual
@@ -396,6 +497,13 @@ func (s *Scanner) NextToken() tokens.Token {
 		tok.Type = tokens.RBRACE
 		tok.Literal = "}"
 		insertSemicolon = true
+	case '[':
+		tok.Type = tokens.LBRACK
+		tok.Literal = "["
+	case ']':
+		tok.Type = tokens.RBRACK
+		tok.Literal = "]"
+		insertSemicolon = true
 
 	case '"':
 		lit, ok := s.readString()
@@ -405,6 +513,14 @@ func (s *Scanner) NextToken() tokens.Token {
 		}
 		insertSemicolon = true
 		return tok // l.readRune() already called by l.readString(), so exit early
+	case '\'':
+		lit, ok := s.readRuneLiteral()
+		tok.Literal = lit
+		if ok {
+			tok.Type = tokens.Rune
+		}
+		insertSemicolon = true
+		return tok // l.readRune() already called by l.readRuneLiteral(), so exit early
 
 	default:
 		switch {
@@ -443,6 +559,29 @@ func (s *Scanner) NextToken() tokens.Token {
 				}
 			}
 
+			if tok.Type != tokens.Illegal && s.isExponent() {
+				// consume 'e' or 'E' and optional sign
+				exp := string(s.r)
+				s.readRune()
+				if s.r == '+' || s.r == '-' {
+					exp += string(s.r)
+					s.readRune()
+				}
+				tok.Type = tokens.Float
+
+				lit, ok = s.readInt()
+				tok.Literal += exp + lit
+				if !ok {
+					tok.Type = tokens.Illegal
+				}
+			}
+
+			if tok.Type != tokens.Illegal && s.isImaginarySuffix() {
+				s.readRune()
+				tok.Type = tokens.Imaginary
+				tok.Literal += "i"
+			}
+
 			insertSemicolon = true
 			return tok // l.readRune() already called by l.readInt(), so exit early
 
@@ -90,11 +90,45 @@ func TestScanner(t *testing.T) {
 			{Offset: 12, Type: tokens.Float, Literal: `1.42`},
 			{Offset: 16, Type: tokens.EOF},
 		},
-		// TODO Character, Rune, Byte?
+		`3i 2.5i 5.i 0i`: {
+			{Offset: 0, Type: tokens.Imaginary, Literal: `3i`},
+			{Offset: 3, Type: tokens.Imaginary, Literal: `2.5i`},
+			{Offset: 8, Type: tokens.Imaginary, Literal: `5.i`},
+			{Offset: 12, Type: tokens.Imaginary, Literal: `0i`},
+			{Offset: 14, Type: tokens.EOF},
+		},
+		`1e3 2.5E-3 5.e+2 1e300i 0E0i`: {
+			{Offset: 0, Type: tokens.Float, Literal: `1e3`},
+			{Offset: 4, Type: tokens.Float, Literal: `2.5E-3`},
+			{Offset: 11, Type: tokens.Float, Literal: `5.e+2`},
+			{Offset: 17, Type: tokens.Imaginary, Literal: `1e300i`},
+			{Offset: 24, Type: tokens.Imaginary, Literal: `0E0i`},
+			{Offset: 28, Type: tokens.EOF},
+		},
+		`1e3x`: {
+			{Type: tokens.Illegal, Literal: `1e3x`},
+		},
+		`42if`: {
+			{Type: tokens.Illegal, Literal: `42if`},
+		},
+		`'a' '\n' '\'' '\u00e9'`: {
+			{Offset: 0, Type: tokens.Rune, Literal: `'a'`},
+			{Offset: 4, Type: tokens.Rune, Literal: `'\n'`},
+			{Offset: 9, Type: tokens.Rune, Literal: `'\''`},
+			{Offset: 14, Type: tokens.Rune, Literal: `'\u00e9'`},
+			{Offset: 22, Type: tokens.EOF},
+		},
+		`'a`: {
+			{Type: tokens.Illegal, Literal: `'a`},
+		},
 		`"Hello, world!"`: {
 			{Offset: 0, Type: tokens.String, Literal: `"Hello, world!"`},
 			{Offset: 15, Type: tokens.EOF},
 		},
+		`"say \"hi\"\n"`: {
+			{Offset: 0, Type: tokens.String, Literal: `"say \"hi\"\n"`},
+			{Offset: 14, Type: tokens.EOF},
+		},
 
 		`=:=`: {
 			{Offset: 0, Type: tokens.Assignment, Literal: `=`},
@@ -126,11 +160,28 @@ func TestScanner(t *testing.T) {
 			{Offset: 4, Type: tokens.EOF},
 		},
 
-		`&|^`: {
+		`& | ^ &^`: {
 			{Offset: 0, Type: tokens.BitwiseAnd, Literal: `&`},
-			{Offset: 1, Type: tokens.BitwiseOr, Literal: `|`},
-			{Offset: 2, Type: tokens.BitwiseXor, Literal: `^`},
-			{Offset: 3, Type: tokens.EOF},
+			{Offset: 2, Type: tokens.BitwiseOr, Literal: `|`},
+			{Offset: 4, Type: tokens.BitwiseXor, Literal: `^`},
+			{Offset: 6, Type: tokens.BitwiseAndNot, Literal: `&^`},
+			{Offset: 8, Type: tokens.EOF},
+		},
+
+		`&=|=^=&^=`: {
+			{Offset: 0, Type: tokens.BitwiseAndAssignment, Literal: `&=`},
+			{Offset: 2, Type: tokens.BitwiseOrAssignment, Literal: `|=`},
+			{Offset: 4, Type: tokens.BitwiseXorAssignment, Literal: `^=`},
+			{Offset: 6, Type: tokens.BitwiseAndNotAssignment, Literal: `&^=`},
+			{Offset: 9, Type: tokens.EOF},
+		},
+
+		`<< >> <<= >>=`: {
+			{Offset: 0, Type: tokens.ShiftLeft, Literal: `<<`},
+			{Offset: 3, Type: tokens.ShiftRight, Literal: `>>`},
+			{Offset: 6, Type: tokens.ShiftLeftAssignment, Literal: `<<=`},
+			{Offset: 10, Type: tokens.ShiftRightAssignment, Literal: `>>=`},
+			{Offset: 13, Type: tokens.EOF},
 		},
 
 		`&&||`: {
@@ -144,14 +195,23 @@ func TestScanner(t *testing.T) {
 			{Offset: 1, Type: tokens.EOF},
 		},
 
-		`==!=<=<>>=`: {
+		`==!=<=<> >=`: {
 			{Offset: 0, Type: tokens.Equal, Literal: `==`},
 			{Offset: 2, Type: tokens.NotEqual, Literal: `!=`},
 			{Offset: 4, Type: tokens.LessOrEqual, Literal: `<=`},
 			{Offset: 6, Type: tokens.Less, Literal: `<`},
 			{Offset: 7, Type: tokens.Greater, Literal: `>`},
-			{Offset: 8, Type: tokens.GreaterOrEqual, Literal: `>=`},
-			{Offset: 10, Type: tokens.EOF},
+			{Offset: 9, Type: tokens.GreaterOrEqual, Literal: `>=`},
+			{Offset: 11, Type: tokens.EOF},
+		},
+
+		`ch<-v<-ch`: {
+			{Offset: 0, Type: tokens.Identifier, Literal: `ch`},
+			{Offset: 2, Type: tokens.Arrow, Literal: `<-`},
+			{Offset: 4, Type: tokens.Identifier, Literal: `v`},
+			{Offset: 5, Type: tokens.Arrow, Literal: `<-`},
+			{Offset: 7, Type: tokens.Identifier, Literal: `ch`},
+			{Offset: 9, Type: tokens.EOF},
 		},
 
 		`:;,.`: {
@@ -162,12 +222,14 @@ func TestScanner(t *testing.T) {
 			{Offset: 4, Type: tokens.EOF},
 		},
 
-		`(){}`: {
+		`(){}[]`: {
 			{Offset: 0, Type: tokens.LPAREN, Literal: `(`},
 			{Offset: 1, Type: tokens.RPAREN, Literal: `)`},
 			{Offset: 2, Type: tokens.LBRACE, Literal: `{`},
 			{Offset: 3, Type: tokens.RBRACE, Literal: `}`},
-			{Offset: 4, Type: tokens.EOF},
+			{Offset: 4, Type: tokens.LBRACK, Literal: `[`},
+			{Offset: 5, Type: tokens.RBRACK, Literal: `]`},
+			{Offset: 6, Type: tokens.EOF},
 		},
 
 		`break case chan const continue default defer else fallthrough for func go ` +
@@ -0,0 +1,19 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package stdlib registers bindings for Go standard library packages math, strconv and strings,
+// so Gosh programs (including Go programs run by Gosh) can import them.
+//
+// It is used for side effects:
+//	import _ "gosh-lang.org/gosh/stdlib"
+//
+// Package fmt is provided by gosh.Runtime itself, so output goes to the runtime's standard output.
+package stdlib // import "gosh-lang.org/gosh/stdlib"
+
+//go:generate go run gosh-lang.org/gosh/cmd/gosh bind -o math_bind.go -p stdlib --go go1.23 math
+//go:generate go run gosh-lang.org/gosh/cmd/gosh bind -o strconv_bind.go -p stdlib --go go1.23 strconv
+//go:generate go run gosh-lang.org/gosh/cmd/gosh bind -o strings_bind.go -p stdlib --go go1.23 strings
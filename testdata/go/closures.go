@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+func fib(n int) int {
+	if n < 2 {
+		return n
+	}
+	return fib(n-1) + fib(n-2)
+}
+
+func counter() func() int {
+	n := 0
+	return func() int {
+		n++
+		return n
+	}
+}
+
+func main() {
+	for i := 0; i < 10; i++ {
+		fmt.Printf("fib(%d) = %d\n", i, fib(i))
+	}
+
+	next := counter()
+	next()
+	next()
+	fmt.Println("counter:", next())
+}
@@ -0,0 +1,10 @@
+package main
+
+import "fmt"
+
+var a = b + 1
+var b = 2
+
+func main() {
+	fmt.Println(a)
+}
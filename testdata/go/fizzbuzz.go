@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func fizzbuzz(i int) string {
+	if i%15 == 0 {
+		return "FizzBuzz"
+	}
+	if i%3 == 0 {
+		return "Fizz"
+	}
+	if i%5 == 0 {
+		return "Buzz"
+	}
+	return strconv.Itoa(i)
+}
+
+func main() {
+	for i := 1; i <= 20; i++ {
+		fmt.Println(fizzbuzz(i))
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+func worker(id int, jobs <-chan int, results chan<- int) {
+	for j := range jobs {
+		results <- j * id
+	}
+}
+
+func safeDiv(a, b int) (res int) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			fmt.Println("recovered:", r)
+			res = -1
+		}
+	}()
+	return a / b
+}
+
+func main() {
+	jobs := make(chan int, 10)
+	results := make(chan int, 10)
+	go worker(2, jobs, results)
+	for i := 1; i <= 5; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	sum := 0
+	for i := 0; i < 5; i++ {
+		sum += <-results
+	}
+	fmt.Println("sum:", sum)
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		fmt.Println("in goroutine")
+	}()
+	<-done
+
+	fmt.Println(safeDiv(10, 2), safeDiv(1, 0))
+}
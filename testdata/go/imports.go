@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func parse(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		fmt.Println("error:", err)
+		return 0
+	}
+	return n
+}
+
+func main() {
+	sum := 0
+	for _, s := range strings.Split("1,2,x,40", ",") {
+		sum += parse(s)
+	}
+	fmt.Println("sum:", sum, strconv.Itoa(sum)+"!")
+	fmt.Println(math.Sqrt(2), math.Floor(math.Pi*100)/100, math.MaxInt8)
+	fmt.Printf("%.3f %q\n", math.Abs(-1.5), strconv.Quote("hi"))
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+var order = "var"
+
+func init() {
+	order += " init1"
+}
+
+func main() {
+	fmt.Println(order, "main")
+}
+
+func init() {
+	order += " init2"
+}
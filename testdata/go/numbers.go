@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+type Celsius float64
+
+func fahrenheit(c Celsius) float64 {
+	return float64(c)*9/5 + 32
+}
+
+func hypot(a, b float64) float64 {
+	return math.Sqrt(a*a + b*b)
+}
+
+func main() {
+	var c Celsius = 100
+	fmt.Println(c, fahrenheit(c), fahrenheit(-40))
+	fmt.Printf("%.3f %v\n", hypot(3, 4), math.Pi)
+	fmt.Println(7/2, 7.0/2, 7%3, -7/2, 1<<10)
+	var x int8 = 127
+	x++
+	fmt.Println(x, math.MaxInt32)
+	fmt.Println(1.5, 0.1+0.2, float32(0.1), 100000000.0*10000000000000.0)
+}
@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+func divide(a, b int) int {
+	return a / b
+}
+
+func safe(f func()) (msg string) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			msg = fmt.Sprint("recovered: ", r)
+		}
+	}()
+	f()
+	return "ok"
+}
+
+func check(n int) {
+	if n > 2 {
+		panic(fmt.Sprintf("too big: %d", n))
+	}
+	fmt.Println("checked", n)
+}
+
+func main() {
+	fmt.Println(safe(func() { divide(1, 0) }))
+	fmt.Println(safe(func() { panic("boom") }))
+	fmt.Println(safe(func() {}))
+
+	defer fmt.Println("deferred")
+	for i := 0; i < 5; i++ {
+		check(i)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func shout(s string, n int) string {
+	return strings.Repeat(strings.ToUpper(s)+"!", n)
+}
+
+func main() {
+	words := strings.Fields("  the quick brown fox ")
+	fmt.Println(len(words), words)
+	for i, w := range words {
+		fmt.Printf("%d:%q ", i, w)
+	}
+	fmt.Println()
+	fmt.Println(shout("go", 3))
+	fmt.Println(strings.Join(words, "-"), strings.Contains("gosh", "go"))
+
+	var b []byte
+	b = []byte("héllo")
+	fmt.Println(len(b), len("héllo"), string(b[1:3]))
+	for _, r := range "hé" {
+		fmt.Print(r, " ")
+	}
+	fmt.Println()
+}
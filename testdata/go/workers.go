@@ -0,0 +1,58 @@
+package main
+
+import "fmt"
+
+func produce(n int, out chan<- int) {
+	defer close(out)
+	for i := 1; i <= n; i++ {
+		out <- i * i
+	}
+}
+
+func square(in <-chan int, out chan<- string, done chan<- bool) {
+	for v := range in {
+		out <- fmt.Sprint("got ", v)
+	}
+	done <- true
+}
+
+func main() {
+	nums := make(chan int)
+	strs := make(chan string, 10)
+	done := make(chan bool)
+	go produce(4, nums)
+	go square(nums, strs, done)
+	<-done
+	close(strs)
+	for s := range strs {
+		fmt.Println(s)
+	}
+
+	results := make(chan int)
+	go func() {
+		defer func() {
+			r := recover()
+			if r != nil {
+				fmt.Println("goroutine recovered:", r)
+				results <- -1
+			}
+		}()
+		var s []int
+		results <- s[3]
+	}()
+	fmt.Println(<-results)
+
+	timeout := make(chan bool, 1)
+	timeout <- true
+	select {
+	case v := <-results:
+		fmt.Println("unexpected", v)
+	case <-timeout:
+		fmt.Println("timeout")
+	}
+
+	go func() {
+		panic("goroutine failed")
+	}()
+	select {}
+}
@@ -0,0 +1,70 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tokens
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Position describes a source position of the token.
+type Position struct {
+	File   string // file name; empty for programs without files
+	Offset int    // offset of the character, starting at 0
+	Line   int    // line number, starting at 1; 0 if unknown
+	Column int    // column number in characters, starting at 1
+}
+
+// String returns a position in one of those forms:
+//
+//	file:line:column  valid position with file name
+//	line:column       valid position without file name
+//	offset            position without line information
+func (pos Position) String() string {
+	switch {
+	case pos.Line == 0:
+		return fmt.Sprint(pos.Offset)
+	case pos.File == "":
+		return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	default:
+		return fmt.Sprintf("%s:%d:%d", pos.File, pos.Line, pos.Column)
+	}
+}
+
+// Lines contains offsets of the first characters of source lines.
+// The first line starts at 0.
+type Lines []int
+
+// NewLines returns line offsets of source.
+func NewLines(source []rune) Lines {
+	res := Lines{0}
+	for i, r := range source {
+		if r == '\n' {
+			res = append(res, i+1)
+		}
+	}
+	return res
+}
+
+// Position returns the position of offset in the named file with those lines.
+// Line and column are zero if lines are unknown.
+func (l Lines) Position(file string, offset int) Position {
+	pos := Position{File: file, Offset: offset}
+	if len(l) == 0 || offset < 0 {
+		return pos
+	}
+	n := sort.SearchInts(l, offset+1) - 1
+	pos.Line = n + 1
+	pos.Column = offset - l[n] + 1
+	return pos
+}
+
+// check interfaces
+var (
+	_ fmt.Stringer = Position{}
+)
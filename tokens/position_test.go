@@ -0,0 +1,34 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tokens
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPosition(t *testing.T) {
+	lines := NewLines([]rune("a\nbé\n\nc"))
+	assert.Equal(t, Lines{0, 2, 5, 6}, lines)
+
+	for offset, expected := range map[int]string{
+		0: "f.gosh:1:1",
+		1: "f.gosh:1:2",
+		2: "f.gosh:2:1",
+		4: "f.gosh:2:3",
+		5: "f.gosh:3:1",
+		6: "f.gosh:4:1",
+		7: "f.gosh:4:2",
+	} {
+		assert.Equal(t, expected, lines.Position("f.gosh", offset).String())
+	}
+
+	assert.Equal(t, "2:1", lines.Position("", 2).String())
+	assert.Equal(t, "42", Lines(nil).Position("f.gosh", 42).String())
+}
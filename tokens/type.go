@@ -28,8 +28,9 @@ const (
 	Identifier Type = "IDENTIFIER"
 	Integer    Type = "INTEGER"
 	Float           = "FLOAT"
-	// Rune       = "RUNE"
-	String Type = "STRING"
+	Imaginary  Type = "IMAGINARY"
+	Rune       Type = "RUNE"
+	String     Type = "STRING"
 
 	Assignment Type = "ASSIGNMENT" // =
 	Define     Type = "DEFINE"     // :=
@@ -49,21 +50,21 @@ const (
 	Increment Type = "INCREMENT" // ++
 	Decrement Type = "DECREMENT" // --
 
-	BitwiseAnd Type = "BITWISE_AND" // &
-	BitwiseOr  Type = "BITWISE_OR"  // |
-	BitwiseXor Type = "BITWISE_XOR" // ^
-	// TODO &^
+	BitwiseAnd    Type = "BITWISE_AND"     // &
+	BitwiseOr     Type = "BITWISE_OR"      // |
+	BitwiseXor    Type = "BITWISE_XOR"     // ^
+	BitwiseAndNot Type = "BITWISE_AND_NOT" // &^
 
-	// TODO &=
-	// TODO |=
-	// TODO ^=
-	// TODO &^=
+	BitwiseAndAssignment    Type = "BITWISE_AND_ASSIGNMENT"     // &=
+	BitwiseOrAssignment     Type = "BITWISE_OR_ASSIGNMENT"      // |=
+	BitwiseXorAssignment    Type = "BITWISE_XOR_ASSIGNMENT"     // ^=
+	BitwiseAndNotAssignment Type = "BITWISE_AND_NOT_ASSIGNMENT" // &^=
 
-	// TODO <<
-	// TODO >>
+	ShiftLeft  Type = "SHIFT_LEFT"  // <<
+	ShiftRight Type = "SHIFT_RIGHT" // >>
 
-	// TODO <<=
-	// TODO >>=
+	ShiftLeftAssignment  Type = "SHIFT_LEFT_ASSIGNMENT"  // <<=
+	ShiftRightAssignment Type = "SHIFT_RIGHT_ASSIGNMENT" // >>=
 
 	LogicalAnd Type = "LOGICAL_AND" // &&
 	LogicalOr  Type = "LOGICAL_OR"  // ||
@@ -79,7 +80,7 @@ const (
 	Greater        Type = "GREATER"          // >
 	GreaterOrEqual Type = "GREATER_OR_EQUAL" // >=
 
-	// TODO <-
+	Arrow Type = "ARROW" // <-
 
 	// delimiters
 	Colon     Type = "COLON"     // :
@@ -92,6 +93,8 @@ const (
 	RPAREN Type = "RPAREN" // )
 	LBRACE Type = "LBRACE" // {
 	RBRACE Type = "RBRACE" // }
+	LBRACK Type = "LBRACK" // [
+	RBRACK Type = "RBRACK" // ]
 
 	// keywords
 	Break       Type = "BREAK"
@@ -117,8 +120,8 @@ const (
 	Select      Type = "SELECT"
 	Struct      Type = "STRUCT"
 	Switch      Type = "SWITCH"
-	// TODO Type
-	Var Type = "VAR"
+	TypeKeyword Type = "TYPE"
+	Var         Type = "VAR"
 
 	// TODO remove
 	True  Type = "TRUE"
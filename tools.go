@@ -7,7 +7,7 @@
 
 // +build tools
 
-package main
+package gosh
 
 import (
 	_ "github.com/dvyukov/go-fuzz/go-fuzz"
@@ -0,0 +1,84 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vm
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/internal/runtime"
+	"gosh-lang.org/gosh/objects"
+)
+
+// engine executes function bodies and types for the goroutine of the virtual machine.
+type engine struct {
+	vm *VM
+}
+
+// ParamType implements runtime.Engine.
+func (e engine) ParamType(ctx context.Context, caller *runtime.CallFrame, f *objects.Function, n int) *objects.TypeObject {
+	return e.vm.execute(ctx, caller, e.vm.code(f).Types[n], f.Scope).(*objects.TypeObject)
+}
+
+// ResultType implements runtime.Engine.
+func (e engine) ResultType(ctx context.Context, caller *runtime.CallFrame, f *objects.Function, n int) *objects.TypeObject {
+	return e.vm.execute(ctx, caller, e.vm.code(f).Results[n], f.Scope).(*objects.TypeObject)
+}
+
+// Body implements runtime.Engine.
+func (e engine) Body(ctx context.Context, fr *runtime.CallFrame, f *objects.Function, scope *objects.Scope) objects.Object {
+	return e.vm.execute(ctx, fr, e.vm.code(f), scope)
+}
+
+// Go implements runtime.Engine.
+func (e engine) Go(g *runtime.Goroutine) runtime.Engine {
+	return engine{&VM{g: g}}
+}
+
+// code returns compiled body of function f.
+// Functions created by the interpreter are compiled on each call.
+func (vm *VM) code(f *objects.Function) *compiler.Function {
+	if code, ok := f.Code.(*compiler.Function); ok {
+		return code
+	}
+
+	code, err := compiler.CompileFunction(&ast.FunctionLiteral{
+		Token:      f.Body.Token,
+		Parameters: f.Parameters,
+		Types:      f.Types,
+		Results:    f.Results,
+		Body:       f.Body,
+	})
+	if err != nil {
+		vm.g.Crash("%s", err)
+	}
+	return code
+}
+
+// pops removes n values from the top of the stack and returns them.
+func (vm *VM) pops(n int) []objects.Object {
+	sp := len(vm.g.Stack) - n
+	res := make([]objects.Object, n)
+	copy(res, vm.g.Stack[sp:])
+	vm.g.Truncate(sp)
+	return res
+}
+
+// deferCall pops arguments and function of deferred call, and adds it to frame fr.
+func (vm *VM) deferCall(fr *runtime.CallFrame, node *ast.CallExpression) {
+	// function value and arguments are evaluated at defer time
+	args := vm.pops(len(node.Arguments))
+	f := vm.pop()
+	vm.g.Defer(fr, node, f, args)
+}
+
+// check interfaces
+var (
+	_ runtime.Engine = engine{}
+)
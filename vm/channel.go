@@ -0,0 +1,61 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vm
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/objects"
+)
+
+// receive receives a value from channel of receive expression.
+// It returns received value and false if it is a zero value received because the channel is closed.
+func (vm *VM) receive(ctx context.Context, node *ast.PrefixExpression, ch objects.Object) (objects.Object, bool) {
+	// nil channel blocks forever
+	return vm.g.Receive(ctx, vm.g.ReceiveChannel(node, ch))
+}
+
+// selectStatement pops case values of select statement, performs it,
+// and returns the index of the chosen clause in node.Cases.
+// For receive clauses with assignment, assigned values are pushed.
+func (vm *VM) selectStatement(ctx context.Context, node *ast.SelectStatement) int {
+	def := -1
+	clauses := make([]int, 0, len(node.Cases))
+	for n, c := range node.Cases {
+		if c.Comm == nil {
+			def = n
+			continue
+		}
+		clauses = append(clauses, n)
+	}
+
+	// case values were pushed in source order
+	cases := make([]objects.SelectCase, len(clauses))
+	for n := len(clauses) - 1; n >= 0; n-- {
+		if _, ok := node.Cases[clauses[n]].Comm.(*ast.SendStatement); ok {
+			cases[n].Value = vm.pop()
+			cases[n].Send = true
+		}
+		cases[n].Chan, _ = vm.pop().(*objects.Channel)
+	}
+
+	n, val, ok := vm.g.Select(ctx, cases, def < 0)
+	if n < 0 {
+		return def
+	}
+
+	clause := clauses[n]
+	if a, isAssign := node.Cases[clause].Comm.(*ast.AssignStatement); isAssign {
+		for _, v := range ops.ReceivedValues(a, val, ok) {
+			vm.push(v)
+		}
+	}
+	return clause
+}
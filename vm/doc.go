@@ -5,13 +5,8 @@
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at http://mozilla.org/MPL/2.0/.
 
-package tokens
-
-import (
-	"testing"
-)
-
-// This dummy test exists for correct test coverage calculations.
-// Remove it when real tests are added.
-func TestDummy(t *testing.T) {
-}
+// Package vm implements Gosh virtual machine that runs programs compiled by package compiler.
+//
+// It produces the same results, output and runtime errors as package interpreter,
+// and uses its Config, RuntimeError and Frame types.
+package vm // import "gosh-lang.org/gosh/vm"
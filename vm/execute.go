@@ -0,0 +1,695 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vm
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/internal/runtime"
+	"gosh-lang.org/gosh/objects"
+)
+
+// iterator is an active iteration of range statement.
+type iterator struct {
+	s      string
+	values []objects.Object
+	ch     *objects.Channel
+	isChan bool
+	n      int
+}
+
+// execute executes compiled function fn in frame fr and the given scope, and returns its result.
+//nolint:gocyclo
+func (vm *VM) execute(ctx context.Context, fr *runtime.CallFrame, fn *compiler.Function, scope *objects.Scope) objects.Object {
+	p := fn.Program
+	ins := fn.Instructions
+	sp := len(vm.g.Stack)
+
+	var res objects.Object // the result of the current statement; nil for Nil
+	var iters []*iterator  // active range statements, innermost last
+
+	// entered reusable block scopes, innermost last, and left ones that can be reused
+	reusable := make([]*objects.Scope, 0, 4)
+	free := make([]*objects.Scope, 0, 4)
+	limited := vm.g.Config.MaxSteps > 0
+
+	for ip := 0; ; {
+		if limited {
+			vm.g.Step()
+		}
+
+		op := compiler.Opcode(ins[ip])
+		ip++
+		switch op {
+		case compiler.OpPos:
+			fr.Offset = int(compiler.ReadUint32(ins[ip:]))
+			ip += 4
+			res = nil
+
+		case compiler.OpFail:
+			vm.g.Crash("%s", p.Constants[u16(ins, ip)].(*objects.String).Value)
+
+		case compiler.OpCheck:
+			node := p.Nodes[u16(ins, ip)].(*ast.Program)
+			ip += 2
+			if offset, msg := ops.Check(node, scope); msg != "" {
+				e := vm.g.RuntimeError(offset, msg)
+				e.Static = true
+				panic(e)
+			}
+			scope = ops.ProgramScope(node, scope)
+
+		case compiler.OpConstant:
+			vm.push(p.Constants[u16(ins, ip)])
+			ip += 2
+
+		case compiler.OpNil:
+			vm.push(nil)
+
+		case compiler.OpPop:
+			vm.pop()
+
+		case compiler.OpDup:
+			n := int(ins[ip])
+			ip++
+			vm.g.Stack = append(vm.g.Stack, vm.g.Stack[len(vm.g.Stack)-n:]...)
+
+		case compiler.OpResult:
+			res = vm.pop()
+
+		case compiler.OpNilResult:
+			res = nil
+
+		case compiler.OpContinueResult:
+			res = &objects.Continue{}
+
+		case compiler.OpGet:
+			name := p.Names[u16(ins, ip)]
+			val, ok := scope.LookupFrom(u16(ins, ip+2), name)
+			ip += 4
+			if !ok {
+				vm.g.Crash("identifier not found: %s", name)
+			}
+			vm.push(val)
+
+		case compiler.OpGetLocal:
+			val := scope.Get(u16(ins, ip+2), u16(ins, ip+4))
+			if val == nil {
+				vm.g.Crash("identifier not found: %s", p.Names[u16(ins, ip)])
+			}
+			ip += 6
+			vm.push(val)
+
+		case compiler.OpTypeOf:
+			name := p.Names[u16(ins, ip)]
+			old, ok := lookup(scope, name, u16(ins, ip+2), u16(ins, ip+4))
+			ip += 6
+			if !ok {
+				vm.g.Crash("undefined: %s", name)
+			}
+			vm.push(objects.TypeOf(old))
+
+		case compiler.OpValueType:
+			vm.push(objects.TypeOf(vm.pop()))
+
+		case compiler.OpDefine:
+			name, slot := p.Names[u16(ins, ip)], u16(ins, ip+2)
+			ip += 4
+			val := vm.pop()
+			if n, ok := val.(*objects.Nil); ok && n.T == nil {
+				vm.g.Crash("use of untyped nil")
+			}
+			if !define(scope, name, slot, val) {
+				vm.g.Crash("%s redeclared in this block", name)
+			}
+
+		case compiler.OpShortDefine:
+			name, slot := p.Names[u16(ins, ip)], u16(ins, ip+2)
+			ip += 4
+			val := vm.pop()
+			if n, ok := val.(*objects.Nil); ok && n.T == nil {
+				vm.g.Crash("use of untyped nil in assignment")
+			}
+			if name == "_" || !define(scope, name, slot, val) {
+				vm.g.Crash("no new variables on left side of :=")
+			}
+
+		case compiler.OpAssign:
+			name := p.Names[u16(ins, ip)]
+			if !scope.AssignFrom(u16(ins, ip+2), name, vm.pop()) {
+				vm.g.Crash("undefined: %s", name)
+			}
+			ip += 4
+
+		case compiler.OpAssignLocal:
+			if !scope.SetSlot(u16(ins, ip+2), u16(ins, ip+4), vm.pop()) {
+				vm.g.Crash("undefined: %s", p.Names[u16(ins, ip)])
+			}
+			ip += 6
+
+		case compiler.OpPushScope:
+			slots := u16(ins, ip)
+			if ins[ip+2] == 0 {
+				scope = objects.NewBlockScope(scope, slots)
+			} else {
+				if n := len(free); n > 0 {
+					scope = free[n-1].Reset(scope, slots)
+					free = free[:n-1]
+				} else {
+					scope = objects.NewBlockScope(scope, slots)
+				}
+				reusable = append(reusable, scope)
+			}
+			ip += 3
+
+		case compiler.OpPopScope:
+			for n := u16(ins, ip); n > 0; n-- {
+				if n := len(reusable); n > 0 && reusable[n-1] == scope {
+					reusable = reusable[:n-1]
+					free = append(free, scope)
+				}
+				scope = scope.Outer()
+			}
+			ip += 2
+
+		case compiler.OpCopyScope:
+			scope = scope.Copy()
+
+		case compiler.OpType:
+			exp := p.Nodes[u16(ins, ip)]
+			ip += 2
+			if _, ok := vm.top().(*objects.TypeObject); !ok {
+				vm.g.Crash("%s is not a type", exp)
+			}
+
+		case compiler.OpMakeType:
+			node := p.Nodes[u16(ins, ip)]
+			ip += 2
+			vm.push(vm.makeType(node))
+
+		case compiler.OpDefineType:
+			node := p.Nodes[u16(ins, ip)].(*ast.TypeStatement)
+			ip += 2
+			vm.g.Define(scope, node.Name, objects.NewDefinedType(node.Name.Value, vm.pop().(*objects.TypeObject)))
+
+		case compiler.OpZero:
+			vm.push(vm.pop().(*objects.TypeObject).Zero())
+
+		case compiler.OpAssigned:
+			exp := p.Nodes[u16(ins, ip)].(ast.Expression)
+			ip += 2
+			val := vm.pop()
+			t := typeOf(vm.pop())
+			vm.push(ops.ConvertAssigned(exp, val, t))
+
+		case compiler.OpAssignedConst:
+			exp := p.Nodes[u16(ins, ip)].(ast.Expression)
+			def := p.Constants[u16(ins, ip+2)]
+			ip += 4
+			t := typeOf(vm.pop())
+			switch {
+			case def != nil && t == objects.TypeOf(def):
+				// the constant has the default type
+				vm.push(def)
+			case t == nil || !t.Kind.IsBasic():
+				vm.push(ops.ConvertAssigned(exp, ops.Untyped(exp), t))
+			default:
+				vm.push(ops.Assigned(exp, t))
+			}
+
+		case compiler.OpUnary:
+			node := p.Nodes[u16(ins, ip)].(*ast.PrefixExpression)
+			ip += 2
+			vm.push(ops.Unary(node.Token.Literal, vm.pop()))
+
+		case compiler.OpBinary:
+			node := p.Nodes[u16(ins, ip)].(*ast.InfixExpression)
+			ip += 2
+			right := vm.pop()
+			left := vm.pop()
+			vm.push(vm.binary(node, node.Token.Literal, left, right))
+
+		case compiler.OpConstOperand:
+			node := p.Nodes[u16(ins, ip)].(*ast.InfixExpression)
+			def := p.Constants[u16(ins, ip+2)]
+			side := ins[ip+4]
+			ip += 5
+			exp := node.Right
+			if side == 0 {
+				exp = node.Left
+			}
+			val := def
+			if t := objects.TypeOf(vm.top()); def == nil || (t != nil && t.Kind.IsBasic() && t != objects.TypeOf(def)) {
+				// the other operand has a different basic type
+				val = ops.ConstantOperand(node, exp, vm.top())
+			}
+			if side == 0 {
+				// replace placeholder or untyped value of the left operand
+				vm.g.Stack[len(vm.g.Stack)-2] = val
+			} else {
+				vm.push(val)
+			}
+
+		case compiler.OpLogical:
+			node := p.Nodes[u16(ins, ip)].(*ast.InfixExpression)
+			target := int(compiler.ReadUint32(ins[ip+2:]))
+			ip += 6
+			operator := node.Token.Literal
+			left := vm.top()
+			b, ok := ops.Underlying(left).(*objects.Boolean)
+			if !ok {
+				vm.g.Crash("invalid operation: operator %s not defined on %s (type %s)", operator, node.Left, ops.TypeString(left))
+			}
+			if b.Value == (operator == "||") {
+				ip = target
+			}
+
+		case compiler.OpShift:
+			node := p.Nodes[u16(ins, ip)].(*ast.InfixExpression)
+			ip += 2
+			count := vm.pop()
+			left := vm.pop()
+			vm.push(ops.Shift(node, left, count))
+
+		case compiler.OpJump:
+			ip = int(compiler.ReadUint32(ins[ip:]))
+
+		case compiler.OpJumpIfFalse:
+			cond := vm.pop()
+			b, ok := cond.(*objects.Boolean)
+			if !ok {
+				vm.g.Crash("expected boolean, got %T %s", cond, cond)
+			}
+			if b.Value {
+				ip += 4
+			} else {
+				ip = int(compiler.ReadUint32(ins[ip:]))
+			}
+
+		case compiler.OpJumpIfType:
+			node := p.Nodes[u16(ins, ip)].(*ast.CallExpression)
+			target := int(compiler.ReadUint32(ins[ip+2:]))
+			ip += 6
+			if t, ok := vm.top().(*objects.TypeObject); ok {
+				if len(node.Arguments) != 1 {
+					vm.g.Crash("wrong number of arguments in conversion to %s", t)
+				}
+				ip = target
+			}
+
+		case compiler.OpCall:
+			node := p.Nodes[u16(ins, ip)].(*ast.CallExpression)
+			mode := ins[ip+2]
+			ip += 3
+			// arguments stay on the stack during the call; the called function uses the stack above them
+			sp := len(vm.g.Stack) - len(node.Arguments)
+			res := vm.g.Call(ctx, node, vm.g.Stack[sp-1], vm.g.Stack[sp:len(vm.g.Stack):len(vm.g.Stack)])
+			vm.g.Truncate(sp - 1)
+			if mode != compiler.CallResults {
+				res = ops.SingleValue(node, res, mode == compiler.CallStatement)
+			}
+			vm.push(res)
+
+		case compiler.OpConvert:
+			node := p.Nodes[u16(ins, ip)].(*ast.CallExpression)
+			ip += 2
+			val := vm.pop()
+			t := vm.pop().(*objects.TypeObject)
+			vm.g.Alloc(ops.ConversionSize(val, t))
+			vm.push(ops.Convert(node, val, t))
+
+		case compiler.OpConvertConst:
+			node := p.Nodes[u16(ins, ip)].(*ast.CallExpression)
+			ip += 2
+			t := vm.pop().(*objects.TypeObject)
+			exp := node.Arguments[0]
+			if !ops.IsConstantConversion(exp, t) {
+				val := ops.Untyped(exp)
+				vm.g.Alloc(ops.ConversionSize(val, t))
+				vm.push(ops.Convert(node, val, t))
+				break
+			}
+			val, msg := ops.ConvertConstant(exp, t)
+			if val == nil {
+				vm.g.CrashAt(node.Token.Offset, "%s", msg)
+			}
+			vm.push(val)
+
+		case compiler.OpClosure:
+			code := p.Functions[u16(ins, ip)]
+			ip += 2
+			lit := code.Literal
+			vm.push(&objects.Function{
+				Parameters: lit.Parameters,
+				Types:      lit.Types,
+				Results:    lit.Results,
+				Body:       lit.Body,
+				Scope:      scope,
+				File:       fr.File,
+				Lines:      fr.Lines,
+				Code:       code,
+			})
+
+		case compiler.OpIndexable:
+			exp := p.Nodes[u16(ins, ip)].(ast.Expression)
+			ip += 2
+			x := ops.Underlying(vm.top())
+			if _, ok := exp.(*ast.IndexExpression); ok {
+				if _, ok = ops.GoMap(x); ok {
+					break
+				}
+			}
+			ops.Length(exp, x)
+
+		case compiler.OpAddressable:
+			exp := p.Nodes[u16(ins, ip)].(ast.Expression)
+			ip += 2
+			x := ops.Underlying(vm.top())
+			if _, ok := ops.GoMap(x); ok {
+				break
+			}
+			ops.Length(exp, x)
+			if _, ok := x.(*objects.String); ok {
+				vm.g.Crash("cannot assign to %s (neither addressable nor a map index expression)", exp)
+			}
+
+		case compiler.OpCheckIndex:
+			exp := p.Nodes[u16(ins, ip)].(ast.Expression)
+			ip += 2
+			ops.Index(exp, vm.top())
+
+		case compiler.OpIndex:
+			node := p.Nodes[u16(ins, ip)].(*ast.IndexExpression)
+			ip += 2
+			key := vm.pop()
+			x := ops.Underlying(vm.pop())
+			if m, ok := ops.GoMap(x); ok {
+				vm.push(ops.MapIndex(node, m, key))
+				break
+			}
+			idx := ops.Index(node.Index, key)
+			vm.push(ops.Element(x, idx))
+
+		case compiler.OpAssignIndex:
+			node := p.Nodes[u16(ins, ip)].(*ast.IndexExpression)
+			ip += 2
+			val := vm.pop()
+			key := vm.pop()
+			x := ops.Underlying(vm.pop())
+			if m, ok := ops.GoMap(x); ok {
+				ops.SetMapIndex(node, m, key, val)
+				break
+			}
+			idx := ops.Index(node.Index, key)
+			values := x.(*objects.Slice).Values
+			ops.CheckBounds(idx, len(values))
+			values[idx] = val
+
+		case compiler.OpSlice:
+			node := p.Nodes[u16(ins, ip)].(*ast.SliceExpression)
+			ip += 2
+			var low, high objects.Object
+			if node.High != nil {
+				high = vm.pop()
+			}
+			if node.Low != nil {
+				low = vm.pop()
+			}
+			vm.push(ops.Slice(node, vm.pop(), low, high))
+
+		case compiler.OpIncDecIndex:
+			node := p.Nodes[u16(ins, ip)].(*ast.IncrementDecrementStatement)
+			ip += 2
+			x := node.X.(*ast.IndexExpression)
+			key := vm.pop()
+			left := ops.Underlying(vm.pop())
+			if m, ok := ops.GoMap(left); ok {
+				ops.SetMapIndex(x, m, key, ops.IncDec(node, ops.MapIndex(x, m, key)))
+				break
+			}
+			idx := ops.Index(x.Index, key)
+			values := left.(*objects.Slice).Values
+			ops.CheckBounds(idx, len(values))
+			values[idx] = ops.IncDec(node, values[idx])
+
+		case compiler.OpIncDecValue:
+			node := p.Nodes[u16(ins, ip)].(*ast.IncrementDecrementStatement)
+			ip += 2
+			vm.push(ops.IncDec(node, vm.pop()))
+
+		case compiler.OpRange:
+			node := p.Nodes[u16(ins, ip)].(*ast.RangeStatement)
+			ip += 2
+			iters = append(iters, vm.iterator(node, vm.pop()))
+
+		case compiler.OpIterNext:
+			it := iters[len(iters)-1]
+			key, value, ok := vm.next(ctx, it)
+			if !ok {
+				iters = iters[:len(iters)-1]
+				ip = int(compiler.ReadUint32(ins[ip:]))
+				break
+			}
+			ip += 4
+			vm.push(key)
+			vm.push(value)
+
+		case compiler.OpRangeSet:
+			node := p.Nodes[u16(ins, ip)].(*ast.RangeStatement)
+			ip += 2
+			value := vm.pop()
+			key := vm.pop()
+			for _, v := range []struct {
+				id  *ast.Identifier
+				val objects.Object
+			}{{node.Key, key}, {node.Value, value}} {
+				switch {
+				case v.id == nil:
+					continue
+				case node.Define:
+					vm.g.Define(scope, v.id, v.val)
+				default:
+					vm.g.Assign(scope, v.id, v.val)
+				}
+			}
+
+		case compiler.OpReturn:
+			kind := ins[ip]
+			var val objects.Object
+			switch kind {
+			case compiler.ReturnResult:
+				val = res
+				if val == nil {
+					val = objects.UntypedNil
+				}
+			case compiler.ReturnValue:
+				val = vm.pop()
+			case compiler.ReturnNamed:
+				val = vm.g.NamedResult(fr)
+			}
+			vm.g.Truncate(sp)
+			return val
+
+		case compiler.OpResultType:
+			old, _ := ops.Lookup(fr.Scope, fr.Results[0].Name)
+			vm.push(objects.TypeOf(old))
+
+		case compiler.OpSetResult:
+			ops.Assign(fr.Scope, fr.Results[0].Name, vm.top())
+
+		case compiler.OpNotConversion:
+			node := p.Nodes[u16(ins, ip)]
+			ip += 2
+			if _, ok := vm.top().(*objects.TypeObject); ok {
+				if _, isGo := node.(*ast.GoStatement); isGo {
+					vm.g.Crash("go requires function call, not conversion")
+				}
+				vm.g.Crash("defer requires function call, not conversion")
+			}
+
+		case compiler.OpDefer:
+			node := p.Nodes[u16(ins, ip)].(*ast.DeferStatement)
+			ip += 2
+			vm.deferCall(fr, node.Call)
+
+		case compiler.OpGo:
+			node := p.Nodes[u16(ins, ip)].(*ast.GoStatement)
+			ip += 2
+			args := vm.pops(len(node.Call.Arguments))
+			f := vm.pop()
+			vm.g.Go(ctx, node, f, args)
+
+		case compiler.OpSendCheck:
+			node := p.Nodes[u16(ins, ip)].(*ast.SendStatement)
+			ip += 2
+			vm.push(vm.g.SendType(node, vm.top()))
+
+		case compiler.OpSend:
+			ip += 2
+			val := vm.pop()
+			c, _ := vm.pop().(*objects.Channel)
+
+			// nil channel blocks forever
+			vm.g.Send(ctx, c, val)
+
+		case compiler.OpRecvCheck:
+			node := p.Nodes[u16(ins, ip)].(*ast.PrefixExpression)
+			ip += 2
+			vm.g.ReceiveChannel(node, vm.top())
+
+		case compiler.OpRecv:
+			node := p.Nodes[u16(ins, ip)].(*ast.PrefixExpression)
+			ip += 2
+			val, _ := vm.receive(ctx, node, vm.pop())
+			vm.push(val)
+
+		case compiler.OpRecvOK:
+			node := p.Nodes[u16(ins, ip)].(*ast.PrefixExpression)
+			ip += 2
+			val, ok := vm.receive(ctx, node, vm.pop())
+			vm.push(val)
+			vm.push(objects.NewBoolean(ok))
+
+		case compiler.OpAssignValues:
+			node := p.Nodes[u16(ins, ip)].(*ast.AssignStatement)
+			ip += 2
+			var values []objects.Object
+			if _, ok := node.Value.(*ast.CallExpression); ok {
+				values = ops.CallValues(node, vm.pop())
+			} else {
+				sp := len(vm.g.Stack) - 1 - len(node.Rest)
+				values = append(values, vm.g.Stack[sp:]...)
+				vm.g.Truncate(sp)
+			}
+			vm.g.AssignValues(node, values, scope)
+
+		case compiler.OpSelect:
+			node := p.Nodes[u16(ins, ip)].(*ast.SelectStatement)
+			table := fn.Tables[u16(ins, ip+2)]
+			ip = table[vm.selectStatement(ctx, node)]
+
+		case compiler.OpCheckContext:
+			vm.g.CheckContext(ctx)
+
+		case compiler.OpImport:
+			node := p.Nodes[u16(ins, ip)].(*ast.ImportStatement)
+			ip += 2
+			vm.g.Import(ctx, node, scope)
+
+		case compiler.OpSelector:
+			node := p.Nodes[u16(ins, ip)].(*ast.SelectorExpression)
+			ip += 2
+			vm.push(ops.Select(node, vm.pop()))
+
+		case compiler.OpAssignSelector:
+			node := p.Nodes[u16(ins, ip)].(*ast.SelectorExpression)
+			ip += 2
+			val := vm.pop()
+			ops.AssignMember(node, vm.pop(), val)
+
+		default:
+			vm.g.Crash("unexpected opcode %s", op)
+		}
+	}
+}
+
+// u16 decodes 2-byte operand of instructions ins at ip.
+func u16(ins compiler.Instructions, ip int) int {
+	return int(ins[ip])<<8 | int(ins[ip+1])
+}
+
+// makeType returns a type of type literal node with component types on the stack.
+func (vm *VM) makeType(node ast.Node) *objects.TypeObject {
+	switch node := node.(type) {
+	case *ast.SliceType:
+		return &objects.TypeObject{Kind: objects.SliceType, Elem: vm.pop().(*objects.TypeObject)}
+	case *ast.MapType:
+		elem := vm.pop().(*objects.TypeObject)
+		return &objects.TypeObject{Kind: objects.MapType, Key: vm.pop().(*objects.TypeObject), Elem: elem}
+	case *ast.PointerType:
+		return &objects.TypeObject{Kind: objects.PointerType, Elem: vm.pop().(*objects.TypeObject)}
+	case *ast.ChanType:
+		return &objects.TypeObject{Kind: objects.ChannelType, Dir: node.Dir, Elem: vm.pop().(*objects.TypeObject)}
+	case *ast.InterfaceType:
+		return &objects.TypeObject{Kind: objects.InterfaceType}
+	case *ast.FuncType:
+		t := &objects.TypeObject{Kind: objects.FunctionType}
+		for _, r := range vm.pops(len(node.Results)) {
+			t.Results = append(t.Results, r.(*objects.TypeObject))
+		}
+		for _, p := range vm.pops(len(node.Params)) {
+			t.Params = append(t.Params, p.(*objects.TypeObject))
+		}
+		return t
+	}
+
+	vm.g.Crash("unexpected node %T:\n%#v", node, node)
+	panic("not reached")
+}
+
+// iterator checks the value of range expression and returns a new iteration over it.
+func (vm *VM) iterator(node *ast.RangeStatement, val objects.Object) *iterator {
+	switch x := ops.Underlying(val).(type) {
+	case *objects.String:
+		return &iterator{s: x.Value}
+
+	case *objects.Slice:
+		return &iterator{values: x.Values}
+
+	case *objects.Channel:
+		return vm.channelIterator(node, x)
+
+	case *objects.Nil:
+		if x.T != nil && x.T.Kind == objects.ChannelType {
+			return vm.channelIterator(node, x)
+		}
+		if x.T == nil || x.T.Kind != objects.SliceType {
+			vm.g.Crash("cannot range over %s", node.X)
+		}
+		return new(iterator)
+
+	default:
+		vm.g.Crash("cannot range over %s (type %s)", node.X, ops.TypeString(x))
+		panic("not reached")
+	}
+}
+
+// channelIterator returns a new iteration that receives values from channel ch until it is closed.
+func (vm *VM) channelIterator(node *ast.RangeStatement, ch objects.Object) *iterator {
+	return &iterator{ch: vm.g.RangeChannel(node, ch), isChan: true}
+}
+
+// next returns the next key and value of iteration, or false if it is finished.
+func (vm *VM) next(ctx context.Context, it *iterator) (objects.Object, objects.Object, bool) {
+	switch {
+	case it.isChan:
+		val, ok := vm.g.Receive(ctx, it.ch)
+		return val, nil, ok
+
+	case it.values != nil:
+		if it.n >= len(it.values) {
+			return nil, nil, false
+		}
+		key, value := objects.NewInt(it.n), it.values[it.n]
+		it.n++
+		return key, value, true
+
+	default:
+		if it.n >= len(it.s) {
+			return nil, nil, false
+		}
+		r, w := utf8.DecodeRuneInString(it.s[it.n:])
+		key, value := objects.NewInt(it.n), &objects.Int32{Value: r}
+		it.n += w
+		return key, value, true
+	}
+}
@@ -0,0 +1,102 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vm
+
+import (
+	"context"
+
+	"gosh-lang.org/gosh/ast"
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/internal/ops"
+	"gosh-lang.org/gosh/internal/runtime"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+)
+
+// VM runs compiled Gosh programs.
+type VM struct {
+	g *runtime.Goroutine // the goroutine executed by the virtual machine; its stack is the value stack
+}
+
+// New creates a new virtual machine.
+// Config.MaxSteps limits the number of executed instructions rather than evaluated AST nodes.
+func New(config *interpreter.Config) *VM {
+	vm := new(VM)
+	vm.g = runtime.New(config, engine{vm})
+	return vm
+}
+
+// Run runs compiled program in the given scope in the main goroutine.
+// It has the same semantics as interpreter's Eval of the source program:
+// goroutines started by it are stopped when it returns, runtime errors are returned as *interpreter.RuntimeError,
+// and execution is stopped when ctx is done.
+func (vm *VM) Run(ctx context.Context, program *compiler.Program, scope *objects.Scope) (objects.Object, error) {
+	return vm.g.Main(ctx, program.File, program.Lines, func(ctx context.Context) objects.Object {
+		// the program is the body of main function
+		fr := vm.g.Current()
+		return vm.g.Run(ctx, fr, func() objects.Object {
+			return vm.execute(ctx, fr, program.Main, scope)
+		})
+	})
+}
+
+// lookup returns the entity in the slot of block scope depth levels up from scope,
+// or named name within that scope and its outer scopes if slot is compiler.NoSlot.
+func lookup(scope *objects.Scope, name string, depth, slot int) (objects.Object, bool) {
+	if slot == compiler.NoSlot {
+		return scope.LookupFrom(depth, name)
+	}
+	obj := scope.Get(depth, slot)
+	return obj, obj != nil
+}
+
+// define declares the entity in the slot of scope, or named name if slot is compiler.NoSlot.
+// Blank identifier is not declared. It returns false if it is already declared in that scope.
+func define(scope *objects.Scope, name string, slot int, obj objects.Object) bool {
+	switch {
+	case name == "_":
+		return true
+	case slot == compiler.NoSlot:
+		return scope.Define(name, obj)
+	default:
+		return scope.DefineSlot(slot, obj)
+	}
+}
+
+// push pushes value on the stack.
+func (vm *VM) push(obj objects.Object) {
+	vm.g.Stack = append(vm.g.Stack, obj)
+}
+
+// pop removes the value from the top of the stack and returns it.
+func (vm *VM) pop() objects.Object {
+	sp := len(vm.g.Stack) - 1
+	res := vm.g.Stack[sp]
+	vm.g.Stack[sp] = nil
+	vm.g.Stack = vm.g.Stack[:sp]
+	return res
+}
+
+// top returns the value on the top of the stack.
+func (vm *VM) top() objects.Object {
+	return vm.g.Stack[len(vm.g.Stack)-1]
+}
+
+// typeOf returns type on the stack, or nil for no type.
+func typeOf(obj objects.Object) *objects.TypeObject {
+	t, _ := obj.(*objects.TypeObject)
+	return t
+}
+
+// binary performs binary operation on operand values; node is used in error messages, if not nil.
+func (vm *VM) binary(node ast.Expression, operator string, left, right objects.Object) objects.Object {
+	if operator == "+" {
+		vm.g.Alloc(ops.ConcatSize(left, right))
+	}
+	return ops.Binary(node, operator, left, right)
+}
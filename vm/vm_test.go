@@ -0,0 +1,56 @@
+// Gosh programming language.
+// Copyright (c) 2018 Alexey Palazhchenko and contributors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package vm
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gosh-lang.org/gosh/compiler"
+	"gosh-lang.org/gosh/interpreter"
+	"gosh-lang.org/gosh/objects"
+	"gosh-lang.org/gosh/parser"
+	"gosh-lang.org/gosh/scanner"
+	"gosh-lang.org/gosh/tokens"
+)
+
+// Semantics are tested by interpreter tests that are run with both engines.
+
+func TestRun(t *testing.T) {
+	s, err := scanner.New("var f = func(n) { println(n) }\nf(42)\nvar z = 0\n1 / z\n", nil)
+	require.NoError(t, err)
+	p := parser.New(s, nil)
+	program := p.ParseProgram()
+	require.Nil(t, p.Errors())
+	code, err := compiler.Compile(program)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	scope := objects.NewScope(objects.Builtin(&buf))
+	res, err := New(nil).Run(context.Background(), code, scope)
+	assert.Nil(t, res)
+	expected := &interpreter.RuntimeError{
+		Offset:    47,
+		Position:  tokens.Position{Offset: 47, Line: 4, Column: 1},
+		Msg:       "runtime error: integer divide by zero",
+		Stack:     []interpreter.Frame{{Function: "main", Offset: 47}},
+		Goroutine: 1,
+	}
+	assert.Equal(t, expected, err)
+	assert.Equal(t, "42\n", buf.String())
+
+	// compiled program can be run again
+	buf.Reset()
+	_, err = New(nil).Run(context.Background(), code, objects.NewScope(objects.Builtin(&buf)))
+	assert.Equal(t, expected, err)
+	assert.Equal(t, "42\n", buf.String())
+}